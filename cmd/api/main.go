@@ -9,7 +9,9 @@ import (
 	"esimulate-backend/internal/security"
 	"esimulate-backend/internal/service"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 	httpNet "net/http"
 )
 
@@ -17,6 +19,12 @@ func main() {
 	// 1. Config
 	cfg := config.LoadConfig()
 
+	// As chaves privadas JWT são gravadas cifradas no banco: sem a chave mestra a API não inicia
+	keyCipher, err := security.NewKeyCipher(cfg.JWTKeyEncryptionKey)
+	if err != nil {
+		logger.Fatal("JWT_KEY_ENCRYPTION_KEY:", err)
+	}
+
 	// 2. Database
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
@@ -36,6 +44,7 @@ func main() {
 	// 4. Layers Init
 	repo := postgres.NewPostgresRepo(db)
 	svc := service.NewService(repo, cfg)
+	svc.KeyCipher = keyCipher
 	
 	// 5. Initialize Admin User
 	if err := svc.InitializeAdmin(); err != nil {
@@ -44,48 +53,140 @@ func main() {
 		logger.Info("Admin user ready: %s", cfg.AdminEmail)
 	}
 	
+	// Chaves de assinatura JWT: cria a primeira chave se necessário e agenda a rotação
+	if err := svc.RotateSigningKeys(); err != nil {
+		logger.Fatal("Cannot load JWT signing keys:", err)
+	}
+	keyRotationService := service.NewKeyRotationService(svc, time.Minute)
+	keyRotationService.Start()
+	defer keyRotationService.Stop()
+
 	// 6. Start Cleanup Service (limpeza automática de dados expirados)
 	// Limpa tokens e links expirados diariamente às 3h da manhã
 	cleanupService := service.NewCleanupService(repo, 3)
 	cleanupService.Start()
 	defer cleanupService.Stop()
+
+	// Lembretes por email antes da abertura das janelas agendadas (exames e links com reminderMinutes)
+	reminderService := service.NewReminderService(svc, time.Minute)
+	reminderService.Start()
+	defer reminderService.Stop()
 	
 	// 7. Inicializar componentes de segurança
-	rateLimiter := security.NewRateLimiter()
-	auditLogger := security.NewAuditLogger()
-	tokenBlacklist := security.NewTokenBlacklist()
+	auditLogger := security.NewAuditLogger(repo)
+	rateLimiter := newRateLimiter(repo, auditLogger)
+	tokenBlacklist := newTokenBlacklist(repo)
 	
 	h := http.NewHandler(svc, rateLimiter, auditLogger, tokenBlacklist)
 
 	// 8. Router (Go 1.22)
 	mux := httpNet.NewServeMux()
 
-	// Auth com rate limiting
+	// Rate limiting (token bucket) por regra; rotas autenticadas usam a regra "api", por usuário
 	loginRateLimit := security.RateLimitMiddleware(rateLimiter, "login")
 	registerRateLimit := security.RateLimitMiddleware(rateLimiter, "register")
 	refreshRateLimit := security.RateLimitMiddleware(rateLimiter, "refresh")
 	forgotRateLimit := security.RateLimitMiddleware(rateLimiter, "forgot-password")
 	verifyRateLimit := security.RateLimitMiddleware(rateLimiter, "verify-email")
-	
+	twoFactorRateLimit := security.RateLimitMiddleware(rateLimiter, "2fa")
+	resetRateLimit := security.RateLimitMiddleware(rateLimiter, "reset-password")
+	authRateLimit := security.RateLimitMiddleware(rateLimiter, "auth")
+	contactRateLimit := security.RateLimitMiddleware(rateLimiter, "contact")
+	publicRateLimit := security.RateLimitMiddleware(rateLimiter, "public")
+	publicSubmitRateLimit := security.RateLimitMiddleware(rateLimiter, "public-submit")
+	publicEventsRateLimit := security.RateLimitMiddleware(rateLimiter, "public-events")
+	apiRateLimit := security.RateLimitMiddleware(rateLimiter, "api")
+
+	// Chaves públicas dos access tokens
+	mux.HandleFunc("GET /.well-known/jwks.json", authRateLimit(h.JWKS))
+
+	// Auth
 	mux.HandleFunc("POST /api/auth/register", registerRateLimit(h.Register))
 	mux.HandleFunc("POST /api/auth/login", loginRateLimit(h.Login))
 	mux.HandleFunc("POST /api/auth/refresh", refreshRateLimit(h.RefreshToken))
-	mux.HandleFunc("POST /api/auth/logout", h.Logout)
+	mux.HandleFunc("POST /api/auth/logout", authRateLimit(h.Logout))
 	// Auth Recovery
 	mux.HandleFunc("POST /api/auth/forgot-password", forgotRateLimit(h.ForgotPassword))
-	mux.HandleFunc("POST /api/auth/reset-password", h.ResetPassword)
+	mux.HandleFunc("POST /api/auth/reset-password", resetRateLimit(h.ResetPassword))
 	mux.HandleFunc("POST /api/auth/verify-email", verifyRateLimit(h.VerifyEmail))
+	// Segundo passo do login (2FA)
+	mux.HandleFunc("POST /api/auth/2fa/verify", twoFactorRateLimit(h.VerifyTwoFactor))
+	mux.HandleFunc("POST /api/auth/2fa/enroll", twoFactorRateLimit(h.EnrollTwoFactor))
+	// Login social (OIDC / OAuth2 com PKCE)
+	mux.HandleFunc("GET /api/auth/oauth/providers", authRateLimit(h.GetOAuthProviders))
+	mux.HandleFunc("GET /api/auth/oauth/{provider}/start", loginRateLimit(h.StartOAuthLogin))
+	mux.HandleFunc("GET /api/auth/oauth/{provider}/callback", authRateLimit(h.OAuthCallback))
+	mux.HandleFunc("POST /api/auth/oauth/exchange", loginRateLimit(h.ExchangeOAuthCode))
+	mux.HandleFunc("POST /api/auth/oauth/link", loginRateLimit(h.ConfirmOAuthLink))
+	// SSO SAML 2.0 (organizações)
+	mux.HandleFunc("GET /api/sso/discover", authRateLimit(h.DiscoverSSO))
+	mux.HandleFunc("GET /api/sso/saml/{orgId}/metadata", authRateLimit(h.SAMLMetadata))
+	mux.HandleFunc("GET /api/sso/saml/{orgId}/login", loginRateLimit(h.StartSAMLLogin))
+	mux.HandleFunc("POST /api/sso/saml/{orgId}/acs", authRateLimit(h.SAMLACS))
 
-	// Protected Routes Helper com blacklist
+	// Protected Routes Helper com blacklist (o rate limit roda após a autenticação, por usuário)
 	protect := func(handler httpNet.HandlerFunc) httpNet.HandlerFunc {
-		return http.AuthMiddleware(cfg.JWTSecret, tokenBlacklist)(handler)
+		return http.AuthMiddleware(svc.JWTKeys, tokenBlacklist)(apiRateLimit(handler))
 	}
 
+	// Two-Factor Authentication
+	mux.HandleFunc("GET /api/auth/2fa", protect(h.GetTwoFactorStatus))
+	mux.HandleFunc("POST /api/auth/2fa/setup", protect(h.SetupTwoFactor))
+	mux.HandleFunc("POST /api/auth/2fa/enable", protect(h.EnableTwoFactor))
+	mux.HandleFunc("POST /api/auth/2fa/disable", protect(h.DisableTwoFactor))
+	mux.HandleFunc("POST /api/auth/2fa/recovery-codes", protect(h.RegenerateRecoveryCodes))
+	mux.HandleFunc("GET /api/admin/2fa-policies", protect(h.GetTwoFactorPolicies))
+	mux.HandleFunc("PUT /api/admin/2fa-policies/{role}", protect(h.SetTwoFactorPolicy))
+
 	// Exams
 	mux.HandleFunc("GET /api/exams", protect(h.GetExams))
 	mux.HandleFunc("GET /api/exams/{id}", protect(h.GetExam))
 	mux.HandleFunc("POST /api/exams", protect(h.CreateExam))
 	mux.HandleFunc("DELETE /api/exams/{id}", protect(h.DeleteExam))
+	mux.HandleFunc("GET /api/exams/{id}/pdf", protect(h.ExportExamPDF))
+	mux.HandleFunc("POST /api/exams/{id}/clone", protect(h.CloneExam))
+	mux.HandleFunc("GET /api/exams/templates", protect(h.GetExamTemplates))
+	mux.HandleFunc("PUT /api/exams/{id}/template", protect(h.SetExamTemplate))
+	mux.HandleFunc("POST /api/exams/{id}/publish", protect(h.PublishExam))
+	mux.HandleFunc("POST /api/exams/{id}/unpublish", protect(h.UnpublishExam))
+	mux.HandleFunc("POST /api/exams/{id}/archive", protect(h.ArchiveExam))
+	mux.HandleFunc("POST /api/exams/{id}/unarchive", protect(h.UnarchiveExam))
+	mux.HandleFunc("POST /api/exams/{id}/restore", protect(h.RestoreExam))
+	mux.HandleFunc("POST /api/exams/{id}/transfer", protect(h.TransferExamOwnership))
+	mux.HandleFunc("GET /api/exams/{id}/shares", protect(h.GetExamShares))
+	mux.HandleFunc("POST /api/exams/{id}/shares", protect(h.ShareExam))
+	mux.HandleFunc("DELETE /api/exams/{id}/shares/{shareId}", protect(h.RevokeExamShare))
+
+	// Groups (compartilhamento)
+	mux.HandleFunc("GET /api/groups", protect(h.GetMyGroups))
+	mux.HandleFunc("POST /api/groups", protect(h.CreateGroup))
+	mux.HandleFunc("DELETE /api/groups/{id}", protect(h.DeleteGroup))
+	mux.HandleFunc("POST /api/groups/{id}/members", protect(h.AddGroupMember))
+	mux.HandleFunc("DELETE /api/groups/{id}/members/{userId}", protect(h.RemoveGroupMember))
+
+	// Turmas e atividades (o criador da turma é o professor)
+	mux.HandleFunc("GET /api/classes", protect(h.GetMyClasses))
+	mux.HandleFunc("POST /api/classes", protect(h.CreateClass))
+	mux.HandleFunc("POST /api/classes/join", protect(h.JoinClass))
+	mux.HandleFunc("GET /api/classes/{id}", protect(h.GetClass))
+	mux.HandleFunc("DELETE /api/classes/{id}", protect(h.DeleteClass))
+	mux.HandleFunc("POST /api/classes/{id}/code", protect(h.RotateClassCode))
+	mux.HandleFunc("GET /api/classes/{id}/members", protect(h.GetClassStudents))
+	mux.HandleFunc("POST /api/classes/{id}/members", protect(h.AddClassStudent))
+	mux.HandleFunc("DELETE /api/classes/{id}/members/{userId}", protect(h.RemoveClassStudent))
+	mux.HandleFunc("GET /api/classes/{id}/assignments", protect(h.GetClassAssignments))
+	mux.HandleFunc("POST /api/classes/{id}/assignments", protect(h.CreateAssignment))
+	mux.HandleFunc("DELETE /api/classes/{id}/assignments/{assignmentId}", protect(h.DeleteAssignment))
+	mux.HandleFunc("GET /api/classes/{id}/gradebook", protect(h.GetGradebook))
+
+	// Adaptive (TRI)
+	mux.HandleFunc("POST /api/exams/{id}/adaptive/start", protect(h.StartAdaptiveExam))
+	mux.HandleFunc("POST /api/adaptive/{sessionId}/answer", protect(h.AnswerAdaptiveExam))
+	mux.HandleFunc("POST /api/admin/irt/calibrate", protect(h.CalibrateIRT))
+
+	// Proctoring
+	mux.HandleFunc("POST /api/exams/{id}/attempts", protect(h.StartExamAttempt))
+	mux.HandleFunc("POST /api/exams/{id}/events", protect(h.RecordExamEvents))
 
 	// Questions
 	mux.HandleFunc("GET /api/questions", protect(h.GetQuestions))
@@ -96,17 +197,39 @@ func main() {
 	// Results
 	mux.HandleFunc("GET /api/results", protect(h.GetMyResults))
 	mux.HandleFunc("POST /api/results", protect(h.SaveResult))
+	mux.HandleFunc("GET /api/results/{id}/certificate", protect(h.GetResultCertificate))
+
+	// Me (dados do usuário autenticado)
+	mux.HandleFunc("GET /api/me/analytics", protect(h.GetMyAnalytics))
+	mux.HandleFunc("GET /api/me/assignments", protect(h.GetMyAssignments))
+	mux.HandleFunc("GET /api/me/assignments/{id}/exam", protect(h.GetMyAssignmentExam))
+	mux.HandleFunc("GET /api/me/review", protect(h.GetMyReviewDeck))
+	mux.HandleFunc("GET /api/me/review/next", protect(h.GetNextReview))
+	mux.HandleFunc("POST /api/me/review/{questionId}/answer", protect(h.AnswerReview))
+	mux.HandleFunc("DELETE /api/me/review/{questionId}", protect(h.DeleteReviewCard))
+	mux.HandleFunc("POST /api/me/practice", protect(h.CreatePracticeExam))
+	mux.HandleFunc("GET /api/me/sessions", protect(h.GetMySessions))
+	mux.HandleFunc("DELETE /api/me/sessions", protect(h.RevokeAllMySessions))
+	mux.HandleFunc("DELETE /api/me/sessions/{id}", protect(h.RevokeMySession))
 
 	// Admin Users
 	mux.HandleFunc("GET /api/users", protect(h.GetUsers))
 	mux.HandleFunc("DELETE /api/users/{id}", protect(h.DeleteUser))
 	mux.HandleFunc("POST /api/users/update", protect(h.UpdateUser))
+	mux.HandleFunc("GET /api/users/{id}/sessions", protect(h.GetUserSessions))
+	mux.HandleFunc("DELETE /api/users/{id}/sessions", protect(h.RevokeAllUserSessions))
+	mux.HandleFunc("DELETE /api/users/{id}/sessions/{sessionId}", protect(h.RevokeUserSession))
+	mux.HandleFunc("GET /api/users/{id}/lockout", protect(h.GetUserLockout))
+	mux.HandleFunc("DELETE /api/users/{id}/lockout", protect(h.UnlockUser))
+
+	// Trilha de auditoria (admin)
+	mux.HandleFunc("GET /api/admin/audit", protect(h.GetAuditEvents))
 
 	// Subjects/Topics
-	mux.HandleFunc("GET /api/subjects", h.GetSubjects)
+	mux.HandleFunc("GET /api/subjects", publicRateLimit(h.GetSubjects))
 	mux.HandleFunc("POST /api/subjects", protect(h.CreateSubject))
 	mux.HandleFunc("DELETE /api/subjects/{id}", protect(h.DeleteSubject))
-	mux.HandleFunc("GET /api/topics", h.GetTopics)
+	mux.HandleFunc("GET /api/topics", publicRateLimit(h.GetTopics))
 	mux.HandleFunc("POST /api/topics", protect(h.CreateTopic))
 	mux.HandleFunc("DELETE /api/topics/{id}", protect(h.DeleteTopic))
 
@@ -115,13 +238,38 @@ func main() {
 	mux.HandleFunc("POST /api/company/links", protect(h.CreateLink))
 	mux.HandleFunc("POST /api/company/invite", protect(h.CompanyInvite))
 	mux.HandleFunc("GET /api/company/results", protect(h.GetCompanyResults))
+	mux.HandleFunc("GET /api/company/results/attempts", protect(h.GetCompanyAttempts))
+	mux.HandleFunc("GET /api/company/results/{id}/events", protect(h.GetResultEvents))
 	
+	// Organizations
+	mux.HandleFunc("GET /api/orgs", protect(h.GetMyOrganizations))
+	mux.HandleFunc("POST /api/orgs", protect(h.CreateOrganization))
+	mux.HandleFunc("GET /api/orgs/{id}", protect(h.GetOrganization))
+	mux.HandleFunc("PUT /api/orgs/{id}", protect(h.UpdateOrganization))
+	mux.HandleFunc("GET /api/orgs/{id}/members", protect(h.GetOrgMembers))
+	mux.HandleFunc("PUT /api/orgs/{id}/members/{userId}", protect(h.UpdateOrgMember))
+	mux.HandleFunc("DELETE /api/orgs/{id}/members/{userId}", protect(h.RemoveOrgMember))
+	mux.HandleFunc("GET /api/orgs/{id}/invitations", protect(h.GetOrgInvitations))
+	mux.HandleFunc("POST /api/orgs/{id}/invitations", protect(h.InviteOrgMember))
+	mux.HandleFunc("DELETE /api/orgs/{id}/invitations/{invitationId}", protect(h.CancelOrgInvitation))
+	mux.HandleFunc("POST /api/orgs/invitations/{token}/accept", protect(h.AcceptOrgInvitation))
+	mux.HandleFunc("GET /api/orgs/{id}/saml", protect(h.GetSAMLConfig))
+	mux.HandleFunc("PUT /api/orgs/{id}/saml", protect(h.SaveSAMLConfig))
+	mux.HandleFunc("DELETE /api/orgs/{id}/saml", protect(h.DeleteSAMLConfig))
+	mux.HandleFunc("POST /api/orgs/{id}/saml/domains/{domain}/verify", protect(h.VerifySAMLDomain))
+	mux.HandleFunc("POST /api/admin/orgs/{id}/saml/domains/{domain}/approve", protect(h.ApproveSAMLDomain))
+
 	// Contact
-	mux.HandleFunc("POST /api/contact/admin", h.ContactAdmin)
+	mux.HandleFunc("POST /api/contact/admin", contactRateLimit(h.ContactAdmin))
 
 	// Public
-	mux.HandleFunc("GET /api/public/exam/{token}", h.PublicGetExam)
-	mux.HandleFunc("POST /api/public/exam/{token}/submit", h.PublicSubmit)
+	mux.HandleFunc("GET /api/public/exam/{token}", publicRateLimit(h.PublicGetExam))
+	mux.HandleFunc("POST /api/public/exam/{token}/submit", publicSubmitRateLimit(h.PublicSubmit))
+	mux.HandleFunc("POST /api/public/exam/{token}/attempts", publicEventsRateLimit(h.PublicStartAttempt))
+	mux.HandleFunc("POST /api/public/exam/{token}/events", publicEventsRateLimit(h.PublicRecordEvents))
+	mux.HandleFunc("POST /api/public/exam/{token}/adaptive/start", publicEventsRateLimit(h.PublicStartAdaptive))
+	mux.HandleFunc("POST /api/public/adaptive/{sessionId}/answer", publicEventsRateLimit(h.PublicAnswerAdaptive))
+	mux.HandleFunc("GET /api/public/certificates/{code}", publicRateLimit(h.PublicVerifyCertificate))
 
 	// Aplicar middlewares de segurança
 	// 1. HTTPS enforcement (em produção)
@@ -134,11 +282,47 @@ func main() {
 		allowedOrigins = []string{"*"}
 	}
 	server = http.CORSMiddleware(allowedOrigins)(server)
+
+	// 3. IP do cliente (cabeçalhos de proxy só valem vindos de TRUSTED_PROXIES)
+	clientIPResolver, err := security.NewClientIPResolver(strings.Split(os.Getenv("TRUSTED_PROXIES"), ","), os.Getenv("CLIENT_IP_HEADER"))
+	if err != nil {
+		logger.Fatal("Invalid proxy configuration:", err)
+	}
+	server = security.ClientIPMiddleware(clientIPResolver)(server)
 	
 	logger.Info("Server running on port %s", cfg.Port)
 	logger.Fatal(httpNet.ListenAndServe(":"+cfg.Port, server))
 }
 
+// newTokenBlacklist escolhe o blacklist de access tokens (TOKEN_BLACKLIST_STORE: postgres ou memory).
+// O Postgres compartilha as revogações entre réplicas; BLACKLIST_CACHE_SECONDS (padrão 5) é o cache
+// local de consultas negativas, ou seja, o atraso máximo para uma revogação chegar às outras réplicas.
+func newTokenBlacklist(repo *postgres.PostgresRepo) security.TokenBlacklist {
+	if os.Getenv("TOKEN_BLACKLIST_STORE") == "memory" {
+		logger.Warn("Token blacklist em memória: revogações não sobrevivem a reinícios nem valem entre réplicas")
+		return security.NewMemoryBlacklist()
+	}
+	cacheSeconds, err := strconv.Atoi(os.Getenv("BLACKLIST_CACHE_SECONDS"))
+	if err != nil || cacheSeconds < 0 {
+		cacheSeconds = 5
+	}
+	return security.NewCachedBlacklist(repo, time.Duration(cacheSeconds)*time.Second)
+}
+
+// newRateLimiter monta o rate limiter com as regras de RATE_LIMITS sobre as padrão.
+// Os baldes ficam no Postgres (compartilhados entre réplicas); RATE_LIMIT_STORE=memory usa apenas memória.
+func newRateLimiter(repo *postgres.PostgresRepo, audit *security.AuditLogger) *security.RateLimiter {
+	limits, err := security.ParseRateLimits(os.Getenv("RATE_LIMITS"))
+	if err != nil {
+		logger.Fatal("Invalid RATE_LIMITS:", err)
+	}
+	if os.Getenv("RATE_LIMIT_STORE") == "memory" {
+		logger.Warn("Rate limit em memória: limites não valem entre réplicas")
+		return security.NewRateLimiter(security.NewMemoryRateLimitStore(), limits, audit)
+	}
+	return security.NewRateLimiter(repo, limits, audit)
+}
+
 func runMigration(db *sql.DB) {
 	// Lê o arquivo schema.sql e executa
 	// Nota: Em um ambiente real, o arquivo estaria em 'migrations/' ou embutido
@@ -3,9 +3,17 @@ module esimulate-backend
 go 1.22
 
 require (
+	github.com/beevik/etree v1.1.0
+	github.com/crewjam/saml v0.4.14
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
+	github.com/russellhaering/goxmldsig v1.3.0
 	golang.org/x/crypto v0.21.0
 )
+
+require (
+	github.com/jonboulle/clockwork v0.2.2 // indirect
+	github.com/mattermost/xml-roundtrip-validator v0.1.0 // indirect
+)
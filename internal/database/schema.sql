@@ -15,7 +15,7 @@ CREATE TABLE IF NOT EXISTS users (
     email TEXT UNIQUE NOT NULL,
     password_hash TEXT NOT NULL,
     role TEXT NOT NULL DEFAULT 'user', -- Valores: 'admin', 'user', 'company', 'specialist'
-    provider TEXT DEFAULT 'email', -- Provedor de autenticação: 'email', 'google', 'github'
+    provider TEXT DEFAULT 'email', -- Provedor de autenticação: 'email', 'google', 'github', 'microsoft', 'saml'
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     profile JSONB DEFAULT '{}', -- Dados adicionais do perfil (CPF, empresa, telefone, endereço)
@@ -156,7 +156,7 @@ CREATE TABLE IF NOT EXISTS tokens (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID REFERENCES users(id) ON DELETE CASCADE,
     token TEXT UNIQUE NOT NULL,
-    type TEXT NOT NULL, -- 'verification' | 'password_reset' | 'refresh_token'
+    type TEXT NOT NULL, -- 'verification' | 'password_reset' | 'refresh_token' | '2fa_challenge' | 'oauth_login'
     expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
     used BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
@@ -254,3 +254,728 @@ COMMENT ON COLUMN results.answers IS 'Array JSONB de objetos com as respostas: [
 COMMENT ON COLUMN results.user_id IS 'ID do usuário autenticado ou NULL para candidatos públicos que acessaram via link';
 COMMENT ON COLUMN public_links.token IS 'Token único e seguro para acesso público ao exame sem necessidade de autenticação';
 COMMENT ON COLUMN public_links.expires_at IS 'Data e hora de expiração do link público (NULL = sem expiração)';
+
+-- ============================================
+-- 13. FATOS DE RESPOSTAS (ANALYTICS)
+-- ============================================
+-- Uma linha por questão respondida, mantida incrementalmente por trigger em results.
+-- Evita expandir results.answers (JSONB) a cada consulta de desempenho.
+CREATE TABLE IF NOT EXISTS answer_facts (
+    result_id UUID NOT NULL REFERENCES results(id) ON DELETE CASCADE,
+    question_id UUID NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- NULL para candidatos públicos
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    subject_id UUID REFERENCES subjects(id) ON DELETE SET NULL,
+    topic_id UUID REFERENCES topics(id) ON DELETE SET NULL,
+    selected_index INT, -- NULL se a questão ficou em branco
+    is_correct BOOLEAN NOT NULL, -- Calculado no banco comparando com questions.correct_index
+    time_seconds DOUBLE PRECISION NOT NULL DEFAULT 0, -- Tempo médio por questão na tentativa
+    answered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (result_id, question_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_answer_facts_user_date ON answer_facts(user_id, answered_at DESC)
+    WHERE user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_answer_facts_user_topic ON answer_facts(user_id, topic_id);
+CREATE INDEX IF NOT EXISTS idx_answer_facts_user_subject ON answer_facts(user_id, subject_id);
+CREATE INDEX IF NOT EXISTS idx_answer_facts_question_id ON answer_facts(question_id);
+
+-- Normaliza results.answers para um array (o frontend pode enviar array ou objeto indexado)
+CREATE OR REPLACE FUNCTION answers_as_array(answers JSONB)
+RETURNS JSONB AS $$
+BEGIN
+    IF jsonb_typeof(answers) = 'array' THEN
+        RETURN answers;
+    ELSIF jsonb_typeof(answers) = 'object' THEN
+        RETURN COALESCE((SELECT jsonb_agg(value) FROM jsonb_each(answers)), '[]'::jsonb);
+    END IF;
+    RETURN '[]'::jsonb;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Popula answer_facts a partir de um resultado recém-inserido
+CREATE OR REPLACE FUNCTION populate_answer_facts()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO answer_facts (result_id, question_id, user_id, exam_id, subject_id, topic_id,
+                              selected_index, is_correct, time_seconds, answered_at)
+    SELECT NEW.id, q.id, NEW.user_id, NEW.exam_id, q.subject_id, q.topic_id,
+           CASE WHEN jsonb_typeof(a->'selectedIndex') = 'number' THEN (a->>'selectedIndex')::numeric::int END,
+           jsonb_typeof(a->'selectedIndex') = 'number' AND (a->>'selectedIndex')::numeric::int = q.correct_index,
+           NEW.time_spent_seconds::DOUBLE PRECISION / GREATEST(NEW.total_questions, 1),
+           NEW.date
+    FROM jsonb_array_elements(answers_as_array(NEW.answers)) a
+    JOIN questions q ON q.id::text = a->>'questionId'
+    -- Apenas questões do exame (respostas a outras questões não viram fatos nem cartões de revisão)
+    JOIN exam_questions eq ON eq.exam_id = NEW.exam_id AND eq.question_id = q.id
+    ON CONFLICT (result_id, question_id) DO NOTHING;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS populate_answer_facts_on_result ON results;
+CREATE TRIGGER populate_answer_facts_on_result AFTER INSERT ON results
+    FOR EACH ROW EXECUTE FUNCTION populate_answer_facts();
+
+COMMENT ON TABLE answer_facts IS 'Respostas individuais por questão, derivadas de results.answers, para analytics de desempenho';
+
+-- ============================================
+-- 14. CADERNO DE ERROS (REVISÃO ESPAÇADA SM-2)
+-- ============================================
+-- Cada questão errada por um usuário vira um cartão de revisão com agendamento SM-2
+CREATE TABLE IF NOT EXISTS review_cards (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question_id UUID NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    ease_factor DOUBLE PRECISION NOT NULL DEFAULT 2.5, -- Fator de facilidade SM-2 (mínimo 1.3)
+    interval_days INT NOT NULL DEFAULT 0, -- Intervalo atual em dias
+    repetitions INT NOT NULL DEFAULT 0, -- Acertos consecutivos na revisão
+    lapses INT NOT NULL DEFAULT 0, -- Quantas vezes a questão foi errada
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(), -- Próxima revisão
+    last_reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, question_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_cards_user_due ON review_cards(user_id, due_at);
+
+-- Adiciona (ou reagenda) o cartão quando uma resposta errada de um usuário autenticado é registrada
+CREATE OR REPLACE FUNCTION enqueue_review_card()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.user_id IS NOT NULL AND NOT NEW.is_correct THEN
+        INSERT INTO review_cards (user_id, question_id, lapses, due_at)
+        VALUES (NEW.user_id, NEW.question_id, 1, NOW())
+        ON CONFLICT (user_id, question_id) DO UPDATE SET
+            repetitions = 0,
+            interval_days = 0,
+            lapses = review_cards.lapses + 1,
+            ease_factor = GREATEST(1.3, review_cards.ease_factor - 0.2),
+            due_at = NOW();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS enqueue_review_card_on_answer ON answer_facts;
+CREATE TRIGGER enqueue_review_card_on_answer AFTER INSERT ON answer_facts
+    FOR EACH ROW EXECUTE FUNCTION enqueue_review_card();
+
+COMMENT ON TABLE review_cards IS 'Caderno de erros: questões erradas pelo usuário com agendamento de revisão espaçada (SM-2)';
+
+-- ============================================
+-- 15. AUTORIA DE QUESTÕES
+-- ============================================
+-- Permite restringir geradores de simulados a questões públicas ou do próprio usuário
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_questions_created_by ON questions(created_by);
+
+COMMENT ON COLUMN questions.created_by IS 'Usuário que criou a questão (NULL para questões legadas)';
+
+-- ============================================
+-- 16. TESTE ADAPTATIVO (TRI / IRT)
+-- ============================================
+-- Configuração do modo adaptativo por exame (NULL = exame tradicional)
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS adaptive_config JSONB;
+-- Estimativa de habilidade (theta, erro padrão, intervalo de confiança) para resultados adaptativos
+ALTER TABLE results ADD COLUMN IF NOT EXISTS ability JSONB;
+
+-- Parâmetros calibrados por questão (modelo logístico de 1 ou 2 parâmetros)
+CREATE TABLE IF NOT EXISTS question_irt_params (
+    question_id UUID PRIMARY KEY REFERENCES questions(id) ON DELETE CASCADE,
+    model TEXT NOT NULL DEFAULT '1PL', -- '1PL' | '2PL'
+    discrimination DOUBLE PRECISION NOT NULL DEFAULT 1, -- Parâmetro a (fixo em 1 no 1PL)
+    difficulty DOUBLE PRECISION NOT NULL DEFAULT 0, -- Parâmetro b
+    responses INT NOT NULL DEFAULT 0, -- Respostas usadas na calibração
+    calibrated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Sessões de teste adaptativo em andamento ou concluídas
+CREATE TABLE IF NOT EXISTS adaptive_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- NULL para candidatos via link público
+    link_id UUID REFERENCES public_links(id) ON DELETE SET NULL,
+    candidate_name TEXT,
+    candidate_email TEXT,
+    theta DOUBLE PRECISION NOT NULL DEFAULT 0, -- Habilidade estimada atual
+    se DOUBLE PRECISION NOT NULL DEFAULT 1, -- Erro padrão da estimativa
+    responses JSONB NOT NULL DEFAULT '[]', -- [{questionId, selectedIndex, isCorrect}]
+    current_question_id UUID REFERENCES questions(id) ON DELETE SET NULL, -- Questão aguardando resposta
+    status TEXT NOT NULL DEFAULT 'active', -- 'active' | 'finished'
+    result_id UUID REFERENCES results(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_adaptive_sessions_exam_id ON adaptive_sessions(exam_id);
+CREATE INDEX IF NOT EXISTS idx_adaptive_sessions_user_id ON adaptive_sessions(user_id) WHERE user_id IS NOT NULL;
+
+DROP TRIGGER IF EXISTS update_adaptive_sessions_updated_at ON adaptive_sessions;
+CREATE TRIGGER update_adaptive_sessions_updated_at BEFORE UPDATE ON adaptive_sessions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE question_irt_params IS 'Parâmetros de TRI (dificuldade/discriminação) calibrados a partir de answer_facts';
+COMMENT ON TABLE adaptive_sessions IS 'Sessões de teste adaptativo: seleção de questões pela habilidade estimada do candidato';
+COMMENT ON COLUMN exams.adaptive_config IS 'Configuração do modo adaptativo: {enabled, model, maxItems, minItems, seThreshold}';
+COMMENT ON COLUMN results.ability IS 'Estimativa de habilidade TRI: {theta, se, ciLower, ciUpper, items}';
+
+-- ============================================
+-- 17. LIMITE DE TENTATIVAS
+-- ============================================
+-- Política de tentativas: {maxAttempts, cooldownMinutes, scoringMode: first|best|last}
+-- A política do link, quando definida, prevalece sobre a do exame
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS attempt_policy JSONB;
+ALTER TABLE public_links ADD COLUMN IF NOT EXISTS attempt_policy JSONB;
+-- Link público usado na tentativa (NULL para tentativas autenticadas)
+ALTER TABLE results ADD COLUMN IF NOT EXISTS link_id UUID REFERENCES public_links(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_results_link_id ON results(link_id) WHERE link_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_results_exam_candidate ON results(exam_id, lower(candidate_email))
+    WHERE candidate_email IS NOT NULL;
+
+COMMENT ON COLUMN exams.attempt_policy IS 'Limite de tentativas do exame: {maxAttempts, cooldownMinutes, scoringMode}';
+COMMENT ON COLUMN public_links.attempt_policy IS 'Limite de tentativas do link (prevalece sobre o do exame)';
+COMMENT ON COLUMN results.link_id IS 'Link público pelo qual a tentativa foi submetida';
+
+-- ============================================
+-- 18. PROCTORING (EVENTOS DE INTEGRIDADE)
+-- ============================================
+-- Identificador da tentativa emitido pelo backend (exam_attempts; em sessões adaptativas, o ID da sessão),
+-- usado para associar os eventos ao resultado submetido
+ALTER TABLE results ADD COLUMN IF NOT EXISTS attempt_id UUID;
+ALTER TABLE results ADD COLUMN IF NOT EXISTS submit_ip TEXT;
+ALTER TABLE results ADD COLUMN IF NOT EXISTS user_agent TEXT;
+-- Quantidade de eventos a partir da qual o candidato é sinalizado (NULL = padrão do sistema)
+ALTER TABLE public_links ADD COLUMN IF NOT EXISTS flag_threshold INT CHECK (flag_threshold > 0);
+
+CREATE TABLE IF NOT EXISTS proctoring_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    attempt_id UUID NOT NULL,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    link_id UUID REFERENCES public_links(id) ON DELETE SET NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    result_id UUID REFERENCES results(id) ON DELETE CASCADE, -- NULL até a tentativa ser submetida
+    type TEXT NOT NULL CHECK (type IN ('tab_hidden', 'window_blur', 'copy', 'paste', 'fullscreen_exit', 'ip_change')),
+    details JSONB,
+    ip TEXT,
+    user_agent TEXT,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL, -- Horário informado pelo cliente
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_proctoring_events_attempt ON proctoring_events(attempt_id, received_at);
+CREATE INDEX IF NOT EXISTS idx_proctoring_events_result ON proctoring_events(result_id) WHERE result_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_results_attempt_id ON results(attempt_id) WHERE attempt_id IS NOT NULL;
+
+COMMENT ON TABLE proctoring_events IS 'Eventos de integridade (aba oculta, perda de foco, copiar/colar, saída de tela cheia, troca de IP) por tentativa';
+COMMENT ON COLUMN results.attempt_id IS 'Tentativa à qual os eventos de proctoring foram associados';
+COMMENT ON COLUMN public_links.flag_threshold IS 'Número de eventos de integridade para sinalizar o candidato';
+
+-- ============================================
+-- 19. CERTIFICADOS DE CONCLUSÃO
+-- ============================================
+-- Emitidos sob demanda (um por resultado). Os dados impressos são copiados no momento
+-- da emissão para que a verificação pública não dependa de alterações posteriores.
+CREATE TABLE IF NOT EXISTS certificates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code TEXT UNIQUE NOT NULL, -- Código de verificação (ex.: 7K2M-9QXD-4HTP)
+    result_id UUID UNIQUE NOT NULL REFERENCES results(id) ON DELETE CASCADE,
+    candidate_name TEXT NOT NULL,
+    exam_title TEXT NOT NULL,
+    score INT NOT NULL,
+    total_questions INT NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    issuer_id UUID REFERENCES users(id) ON DELETE SET NULL, -- Empresa emissora (NULL = eSimulate)
+    issuer_name TEXT NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE certificates IS 'Certificados de conclusão emitidos por resultado, verificáveis publicamente pelo código';
+
+-- ============================================
+-- 20. CLONAGEM E MODELOS DE EXAME
+-- ============================================
+-- Modelos (templates) são exames curados por admins e listados em um catálogo
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS is_template BOOLEAN NOT NULL DEFAULT FALSE;
+-- Exame de origem quando criado por POST /api/exams/{id}/clone
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS cloned_from UUID REFERENCES exams(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_exams_is_template ON exams(is_template) WHERE is_template = TRUE;
+
+COMMENT ON COLUMN exams.is_template IS 'Exame modelo do catálogo (visível a todos os usuários, definido por admins)';
+COMMENT ON COLUMN exams.cloned_from IS 'Exame a partir do qual este foi clonado';
+
+-- ============================================
+-- 21. CICLO DE VIDA DO EXAME
+-- ============================================
+-- draft: em edição (somente o autor vê, sem links públicos)
+-- published: disponível; archived: some das listagens, resultados preservados
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published', 'archived'));
+-- Exclusão lógica: is_active = FALSE + deleted_at; a purga definitiva ocorre no CleanupService
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+UPDATE exams SET is_active = TRUE WHERE is_active IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_exams_status_active ON exams(status, is_active);
+CREATE INDEX IF NOT EXISTS idx_exams_deleted_at ON exams(deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMENT ON COLUMN exams.status IS 'Estado do exame: draft | published | archived';
+COMMENT ON COLUMN exams.deleted_at IS 'Data da exclusão lógica (purga após EXAM_RETENTION_DAYS)';
+
+-- ============================================
+-- 22. COMPARTILHAMENTO E COAUTORIA DE EXAMES
+-- ============================================
+-- Grupos de usuários criados por um dono (ex.: equipe de professores ou recrutadores)
+CREATE TABLE IF NOT EXISTS user_groups (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_group_members (
+    group_id UUID NOT NULL REFERENCES user_groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+-- Concessões de acesso por exame: viewer (leitura), editor (conteúdo), owner (coautor com gestão)
+CREATE TABLE IF NOT EXISTS exam_shares (
+    id UUID PRIMARY KEY,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    group_id UUID REFERENCES user_groups(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((user_id IS NULL) <> (group_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exam_shares_exam_user ON exam_shares(exam_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exam_shares_exam_group ON exam_shares(exam_id, group_id) WHERE group_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_exam_shares_user ON exam_shares(user_id);
+CREATE INDEX IF NOT EXISTS idx_exam_shares_group ON exam_shares(group_id);
+CREATE INDEX IF NOT EXISTS idx_user_group_members_user ON user_group_members(user_id);
+
+COMMENT ON TABLE user_groups IS 'Grupos de usuários usados no compartilhamento de exames';
+COMMENT ON TABLE exam_shares IS 'Acesso a exames concedido a usuários ou grupos (viewer | editor | owner)';
+
+-- ============================================
+-- 23. ORGANIZAÇÕES (CONTAS EMPRESARIAIS MULTIUSUÁRIO)
+-- ============================================
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    commercial_name TEXT NOT NULL,
+    logo TEXT, -- Data URL ou URL da imagem
+    tax_id TEXT, -- CNPJ ou equivalente
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- owner: gerencia perfil e membros; recruiter: cria exames, links e convites; viewer: apenas consulta
+CREATE TABLE IF NOT EXISTS organization_members (
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'recruiter', 'viewer')),
+    joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS organization_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'recruiter', 'viewer')),
+    token TEXT UNIQUE NOT NULL,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Links, resultados (via link) e exames passam a pertencer à organização
+ALTER TABLE public_links ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user ON organization_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_org ON organization_invitations(org_id) WHERE accepted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_public_links_org_id ON public_links(org_id);
+CREATE INDEX IF NOT EXISTS idx_exams_org_id ON exams(org_id) WHERE org_id IS NOT NULL;
+
+-- Contas company sem organização viram organizações de um membro (id da organização = id do usuário)
+INSERT INTO organizations (id, commercial_name, logo, tax_id, created_by, created_at)
+SELECT u.id, COALESCE(NULLIF(u.profile->>'commercialName', ''), u.name), NULLIF(u.profile->>'companyLogo', ''),
+       NULLIF(COALESCE(u.profile->>'taxId', u.profile->>'cnpj'), ''), u.id, u.created_at
+FROM users u
+WHERE u.role = 'company' AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = u.id)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO organization_members (org_id, user_id, role)
+SELECT u.id, u.id, 'owner'
+FROM users u JOIN organizations o ON o.id = u.id
+WHERE u.role = 'company' AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = u.id);
+
+UPDATE public_links SET org_id = company_id
+WHERE org_id IS NULL AND company_id IN (SELECT id FROM organizations);
+
+-- Exames criados pelas empresas passam a ser da organização
+UPDATE exams SET org_id = created_by
+WHERE org_id IS NULL AND created_by IN (SELECT o.id FROM organizations o JOIN users u ON u.id = o.id WHERE u.role = 'company');
+
+COMMENT ON TABLE organizations IS 'Contas empresariais com múltiplos usuários';
+COMMENT ON TABLE organization_members IS 'Membros da organização e seus papéis (owner | recruiter | viewer)';
+COMMENT ON TABLE organization_invitations IS 'Convites pendentes para ingressar na organização';
+COMMENT ON COLUMN public_links.company_id IS 'Usuário que criou o link';
+COMMENT ON COLUMN public_links.org_id IS 'Organização dona do link e dos resultados obtidos por ele';
+COMMENT ON COLUMN exams.org_id IS 'Organização dona do exame (membros recebem acesso conforme o papel)';
+
+-- ============================================
+-- 24. TURMAS E ATIVIDADES
+-- ============================================
+
+-- O criador da turma é o professor; alunos entram pelo código de convite ou são incluídos por email
+CREATE TABLE IF NOT EXISTS classes (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    teacher_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invite_code TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS class_members (
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (class_id, user_id)
+);
+
+-- Atividade: exame atribuído à turma, com janela de abertura e política de tentativas própria
+CREATE TABLE IF NOT EXISTS class_assignments (
+    id UUID PRIMARY KEY,
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    title TEXT,
+    opens_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    closes_at TIMESTAMP WITH TIME ZONE,
+    attempt_policy JSONB,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (closes_at IS NULL OR closes_at > opens_at)
+);
+
+ALTER TABLE results ADD COLUMN IF NOT EXISTS assignment_id UUID REFERENCES class_assignments(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_classes_teacher ON classes(teacher_id);
+CREATE INDEX IF NOT EXISTS idx_class_members_user ON class_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_class_assignments_class ON class_assignments(class_id);
+CREATE INDEX IF NOT EXISTS idx_class_assignments_exam ON class_assignments(exam_id);
+CREATE INDEX IF NOT EXISTS idx_results_assignment ON results(assignment_id) WHERE assignment_id IS NOT NULL;
+
+COMMENT ON TABLE classes IS 'Turmas criadas por professores';
+COMMENT ON TABLE class_members IS 'Alunos matriculados na turma';
+COMMENT ON TABLE class_assignments IS 'Exames atribuídos à turma (janela de abertura e limite de tentativas)';
+COMMENT ON COLUMN results.assignment_id IS 'Atividade à qual a tentativa foi entregue (NULL fora de turmas)';
+
+-- ============================================
+-- 25. JANELAS DE DISPONIBILIDADE E LEMBRETES
+-- ============================================
+
+-- Janela de realização: {"availableFrom", "availableUntil", "timezone", "reminderMinutes"} (ms desde a época)
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS availability JSONB;
+ALTER TABLE public_links ADD COLUMN IF NOT EXISTS availability JSONB;
+
+-- Candidatos convidados por email para um link (destinatários dos lembretes)
+CREATE TABLE IF NOT EXISTS link_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    link_id UUID NOT NULL REFERENCES public_links(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    invited_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    reminded_at TIMESTAMP WITH TIME ZONE -- Lembrete enviado (ou dispensado, se convidado após a abertura)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_invitations_link_email ON link_invitations(link_id, lower(email));
+CREATE INDEX IF NOT EXISTS idx_link_invitations_pending ON link_invitations(link_id) WHERE reminded_at IS NULL;
+
+COMMENT ON COLUMN exams.availability IS 'Janela em que o exame pode ser realizado via links públicos';
+COMMENT ON COLUMN public_links.availability IS 'Janela do link (restringe a janela do exame)';
+COMMENT ON TABLE link_invitations IS 'Convites enviados por email para links públicos';
+
+-- ============================================
+-- 26. AUTENTICAÇÃO EM DOIS FATORES (TOTP)
+-- ============================================
+
+-- Segredo TOTP (base32). Fica pendente (totp_enabled = FALSE) até o primeiro código válido
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT; -- Último passo aceito (impede reutilizar o código)
+
+-- Códigos de recuperação de uso único (hash SHA-256)
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Papéis que exigem 2FA no login
+CREATE TABLE IF NOT EXISTS two_factor_policies (
+    role TEXT PRIMARY KEY,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_id) WHERE used_at IS NULL;
+
+COMMENT ON COLUMN users.totp_secret IS 'Segredo TOTP (RFC 6238); NULL quando o 2FA não está configurado';
+COMMENT ON TABLE recovery_codes IS 'Códigos de recuperação do 2FA (uso único)';
+COMMENT ON TABLE two_factor_policies IS 'Obrigatoriedade do 2FA por papel (definida por admins)';
+
+-- ============================================
+-- 27. LOGIN SOCIAL (OIDC / OAUTH2)
+-- ============================================
+
+-- Identidades externas vinculadas a usuários (sub do provedor)
+CREATE TABLE IF NOT EXISTS oauth_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL, -- 'google' | 'github' | 'microsoft'
+    subject TEXT NOT NULL,
+    email TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+-- Fluxos de autorização em andamento (state, nonce e code_verifier do PKCE)
+CREATE TABLE IF NOT EXISTS oauth_states (
+    state TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Vínculos pendentes: identidade cujo email já pertence a uma conta (aguarda confirmação do usuário)
+CREATE TABLE IF NOT EXISTS oauth_link_requests (
+    token TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_identities_user ON oauth_identities(user_id);
+CREATE INDEX IF NOT EXISTS idx_oauth_states_expires ON oauth_states(expires_at);
+
+COMMENT ON TABLE oauth_identities IS 'Contas de provedores sociais (OIDC/OAuth2) vinculadas a usuários';
+COMMENT ON TABLE oauth_states IS 'State/nonce/PKCE de logins sociais em andamento (uso único)';
+COMMENT ON TABLE oauth_link_requests IS 'Vínculos de login social aguardando confirmação da conta existente';
+
+-- ============================================
+-- 28. SSO SAML 2.0 (ORGANIZAÇÕES)
+-- ============================================
+
+-- Configuração do Service Provider por organização (IdP corporativo: Azure AD, Okta, ...)
+CREATE TABLE IF NOT EXISTS org_saml_configs (
+    org_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    idp_metadata TEXT NOT NULL, -- XML de metadata do IdP (entityID, SSO e certificado de assinatura)
+    idp_entity_id TEXT NOT NULL,
+    attribute_mapping JSONB, -- {"email": "...", "name": "...", "role": "..."}
+    role_mapping JSONB, -- Valor do atributo de papel -> papel na organização
+    default_role TEXT NOT NULL DEFAULT 'recruiter',
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Domínios de email atendidos pelo SSO (um domínio pertence a uma única organização; ver seção 37)
+CREATE TABLE IF NOT EXISTS org_saml_domains (
+    domain TEXT PRIMARY KEY,
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+-- AuthnRequests emitidos (InResponseTo da resposta); o id viaja no RelayState
+CREATE TABLE IF NOT EXISTS saml_requests (
+    relay_state TEXT PRIMARY KEY,
+    request_id TEXT NOT NULL,
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_org_saml_domains_org ON org_saml_domains(org_id);
+CREATE INDEX IF NOT EXISTS idx_saml_requests_expires ON saml_requests(expires_at);
+
+COMMENT ON TABLE org_saml_configs IS 'SSO SAML 2.0 das organizações';
+COMMENT ON TABLE org_saml_domains IS 'Domínios de email que usam o SSO da organização';
+COMMENT ON TABLE saml_requests IS 'AuthnRequests pendentes (uso único)';
+
+-- ============================================
+-- 29. SESSÕES ATIVAS (REFRESH TOKENS)
+-- ============================================
+
+-- Cada login abre uma sessão; a rotação do refresh token mantém a mesma sessão
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip TEXT, -- IP do login
+    user_agent TEXT, -- Navegador/dispositivo do login
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW() -- Último refresh
+);
+
+-- Revogar a sessão exclui os refresh tokens dela
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS session_id UUID REFERENCES user_sessions(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_tokens_session ON tokens(session_id);
+
+COMMENT ON TABLE user_sessions IS 'Sessões de login (dispositivos) dos usuários';
+
+-- ============================================
+-- 30. CHAVES DE ASSINATURA JWT
+-- ============================================
+
+-- Chaves de assinatura dos access tokens (RS256/EdDSA), compartilhadas entre réplicas.
+-- A chave mais recente já ativa assina; as demais continuam publicadas em /.well-known/jwks.json
+-- até que os tokens assinados por elas expirem.
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    kid TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL, -- 'RS256' | 'EdDSA'
+    private_key TEXT NOT NULL, -- PEM PKCS#8 cifrado com AES-256-GCM (JWT_KEY_ENCRYPTION_KEY): "v1:" + base64(nonce || ciphertext)
+    activates_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_activates ON jwt_signing_keys(activates_at);
+
+COMMENT ON TABLE jwt_signing_keys IS 'Chaves de assinatura JWT com rotação agendada';
+
+-- ============================================
+-- 31. BLOQUEIO DE CONTAS (LOGIN)
+-- ============================================
+
+-- Falhas consecutivas de login por conta (atraso progressivo e bloqueio temporário)
+CREATE TABLE IF NOT EXISTS login_failures (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    failed_count INTEGER NOT NULL DEFAULT 0, -- Falhas desde o último login ou bloqueio
+    last_failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP WITH TIME ZONE -- Bloqueio temporário (NULL: não bloqueada)
+);
+
+COMMENT ON TABLE login_failures IS 'Tentativas de login com senha incorreta por conta';
+
+-- ============================================
+-- 32. ACCESS TOKENS REVOGADOS (BLACKLIST)
+-- ============================================
+
+-- Access tokens revogados (logout, sessões encerradas), compartilhados entre réplicas.
+-- token_id é o SHA-256 do token ou "session:<id>" para todos os tokens de uma sessão.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    token_id TEXT PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL -- exp do token + tolerância de clock skew
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON revoked_tokens(expires_at);
+
+COMMENT ON TABLE revoked_tokens IS 'Blacklist persistente de access tokens';
+
+-- ============================================
+-- 33. RATE LIMIT (TOKEN BUCKET)
+-- ============================================
+
+-- Baldes de tokens do rate limit, compartilhados entre réplicas.
+-- key é "<regra>:ip:<ip>" ou "<regra>:user:<id>".
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL, -- Saldo após a última requisição
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    full_at TIMESTAMP WITH TIME ZONE NOT NULL, -- Quando o balde volta a ficar cheio (pode ser removido)
+    allowed BOOLEAN NOT NULL DEFAULT TRUE -- Resultado da última requisição
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full ON rate_limit_buckets(full_at);
+
+COMMENT ON TABLE rate_limit_buckets IS 'Rate limit por IP e usuário (token bucket)';
+
+-- ============================================
+-- 34. TRILHA DE AUDITORIA
+-- ============================================
+
+-- Eventos de segurança (logins, alterações de acesso, exclusões) consultados em /api/admin/audit.
+-- actor_id e target_id não referenciam as tabelas de origem: o registro sobrevive à exclusão do usuário ou recurso.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    action TEXT NOT NULL, -- Ex.: USER_DELETED, ORG_MEMBER_UPDATED, LOGIN_FAILED
+    actor_id TEXT, -- Usuário que executou (NULL: anônimo)
+    target_type TEXT, -- user, exam, org, group, class...
+    target_id TEXT,
+    ip TEXT,
+    user_agent TEXT,
+    details TEXT,
+    changes JSONB, -- {"campo": {"before": ..., "after": ...}} com valores sensíveis mascarados
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created ON audit_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, id);
+
+COMMENT ON TABLE audit_events IS 'Trilha de auditoria de segurança (retenção: AUDIT_RETENTION_DAYS)';
+
+-- ============================================
+-- 35. TENTATIVAS INICIADAS
+-- ============================================
+
+-- Tentativas abertas pelo backend ao iniciar a prova (por usuário ou link). Eventos de proctoring e a
+-- submissão exigem o attemptId do próprio candidato; cada tentativa gera no máximo um resultado.
+CREATE TABLE IF NOT EXISTS exam_attempts (
+    id UUID PRIMARY KEY,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- NULL para candidatos via link público
+    link_id UUID REFERENCES public_links(id) ON DELETE CASCADE, -- NULL para usuários autenticados
+    result_id UUID REFERENCES results(id) ON DELETE SET NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    submitted_at TIMESTAMP WITH TIME ZONE -- Preenchido uma única vez, na submissão
+);
+
+CREATE INDEX IF NOT EXISTS idx_exam_attempts_exam_id ON exam_attempts(exam_id);
+CREATE INDEX IF NOT EXISTS idx_exam_attempts_pending ON exam_attempts(started_at) WHERE submitted_at IS NULL;
+
+COMMENT ON TABLE exam_attempts IS 'Tentativas iniciadas: attemptId emitido pelo backend para proctoring e submissão';
+
+-- ============================================
+-- 36. FALHAS NO DESAFIO DE 2FA
+-- ============================================
+
+-- Códigos incorretos por desafio de login: o desafio é invalidado ao atingir o limite
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS failed_attempts INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN tokens.failed_attempts IS 'Códigos incorretos no desafio de 2FA (2fa_challenge)';
+
+-- ============================================
+-- 37. VERIFICAÇÃO DE DOMÍNIOS DO SSO
+-- ============================================
+
+-- Domínios do SSO só valem após a verificação por registro DNS TXT ou aprovação de um admin.
+-- Domínios já cadastrados ficam pendentes, com um token novo.
+ALTER TABLE org_saml_domains ADD COLUMN IF NOT EXISTS verification_token TEXT;
+ALTER TABLE org_saml_domains ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE org_saml_domains ADD COLUMN IF NOT EXISTS verified_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE org_saml_domains SET verification_token = md5(random()::text || domain || clock_timestamp()::text)
+WHERE verification_token IS NULL;
+
+COMMENT ON COLUMN org_saml_domains.verification_token IS 'Valor do registro TXT _esimulate-verification.<domínio>';
+COMMENT ON COLUMN org_saml_domains.verified_at IS 'Verificação do domínio (NULL: pendente, ignorado no login)';
+COMMENT ON COLUMN org_saml_domains.verified_by IS 'Admin que aprovou o domínio (NULL: verificado por DNS)';
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"net/http"
+)
+
+// --- Adaptive (Teste Adaptativo / TRI) ---
+
+// adaptiveError traduz os erros do service para status HTTP
+func (h *Handler) adaptiveError(w http.ResponseWriter, err error) {
+	if h.attemptError(w, err) || h.availabilityError(w, err) {
+		return
+	}
+	switch err.Error() {
+	case "link inválido", "prova não encontrada", "sessão não encontrada":
+		h.Error(w, 404, err.Error())
+	case "link inativo", "link expirado", "prova não disponível", "exame não está no modo adaptativo", "exame sem questões",
+		"questão não corresponde à questão atual":
+		h.Error(w, 400, err.Error())
+	case "sessão já finalizada", "questão já respondida":
+		h.Error(w, 409, err.Error())
+	default:
+		h.Error(w, 500, "Erro na sessão adaptativa")
+	}
+}
+
+// decodeAdaptiveAnswer lê {questionId, selectedIndex} do corpo da requisição
+func decodeAdaptiveAnswer(r *http.Request) (string, int, bool) {
+	var req struct {
+		QuestionID    string `json:"questionId"`
+		SelectedIndex *int   `json:"selectedIndex"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.QuestionID == "" || req.SelectedIndex == nil {
+		return "", 0, false
+	}
+	return req.QuestionID, *req.SelectedIndex, true
+}
+
+// StartAdaptiveExam inicia uma sessão adaptativa para o usuário autenticado
+func (h *Handler) StartAdaptiveExam(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	exam, err := h.Service.Repo.GetExamByID(r.PathValue("id"))
+	if err != nil || exam.DeletedAt > 0 {
+		h.Error(w, 404, "Exam not found")
+		return
+	}
+	// Apenas exames publicados recebem tentativas, como em SaveResult
+	if !h.Service.IsExamOpenForLinks(exam) {
+		h.Error(w, 400, "Prova não disponível")
+		return
+	}
+	// Mesmo controle de acesso de GetExam
+	if !h.Service.CanViewExam(exam, userID) {
+		h.Error(w, 403, "Access denied")
+		return
+	}
+	if err := h.Service.CheckAttemptAllowed(exam, domain.PublicLink{}, userID, ""); err != nil {
+		h.adaptiveError(w, err)
+		return
+	}
+	step, err := h.Service.StartAdaptiveSession(exam, domain.AdaptiveSession{UserID: userID})
+	if err != nil {
+		h.adaptiveError(w, err)
+		return
+	}
+	h.JSON(w, 201, step)
+}
+
+// AnswerAdaptiveExam responde a questão atual de uma sessão do usuário autenticado
+func (h *Handler) AnswerAdaptiveExam(w http.ResponseWriter, r *http.Request) {
+	questionID, selectedIndex, ok := decodeAdaptiveAnswer(r)
+	if !ok {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	userID := r.Context().Value("userID").(string)
+	step, err := h.Service.AnswerAdaptiveSession(r.PathValue("sessionId"), userID, questionID, selectedIndex)
+	if err != nil {
+		h.adaptiveError(w, err)
+		return
+	}
+	h.JSON(w, 200, step)
+}
+
+// PublicStartAdaptive inicia uma sessão adaptativa para um candidato via link público
+func (h *Handler) PublicStartAdaptive(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CandidateName  string `json:"candidateName"`
+		CandidateEmail string `json:"candidateEmail"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	step, err := h.Service.StartPublicAdaptiveSession(r.PathValue("token"), req.CandidateName, req.CandidateEmail)
+	if err != nil {
+		h.adaptiveError(w, err)
+		return
+	}
+	h.JSON(w, 201, step)
+}
+
+// PublicAnswerAdaptive responde a questão atual de uma sessão de link público
+// Candidatos públicos só recebem a confirmação ao final; a habilidade fica visível para a empresa
+func (h *Handler) PublicAnswerAdaptive(w http.ResponseWriter, r *http.Request) {
+	questionID, selectedIndex, ok := decodeAdaptiveAnswer(r)
+	if !ok {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	step, err := h.Service.AnswerAdaptiveSession(r.PathValue("sessionId"), "", questionID, selectedIndex)
+	if err != nil {
+		h.adaptiveError(w, err)
+		return
+	}
+	if step.Finished {
+		h.JSON(w, 200, map[string]interface{}{
+			"sessionId": step.SessionID,
+			"finished":  true,
+			"status":    "success",
+			"message":   "Prova recebida.",
+		})
+		return
+	}
+	h.JSON(w, 200, step)
+}
+
+// CalibrateIRT recalibra os parâmetros de TRI do banco de questões (apenas admin)
+// Query param opcional: ?model=1PL|2PL
+func (h *Handler) CalibrateIRT(w http.ResponseWriter, r *http.Request) {
+	if role, _ := r.Context().Value("role").(string); role != "admin" {
+		h.Error(w, 403, "Acesso restrito a administradores")
+		return
+	}
+	count, err := h.Service.CalibrateQuestionBank(r.URL.Query().Get("model"))
+	if err != nil {
+		if err.Error() == "modelo inválido (use 1PL ou 2PL)" {
+			h.Error(w, 400, err.Error())
+			return
+		}
+		h.Error(w, 500, "Erro na calibração")
+		return
+	}
+	h.JSON(w, 200, map[string]interface{}{"calibrated": count})
+}
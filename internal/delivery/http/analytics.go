@@ -0,0 +1,51 @@
+package http
+
+import (
+	"esimulate-backend/internal/domain"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// --- Analytics ---
+
+// GetMyAnalytics retorna o desempenho do usuário autenticado por matéria e tópico
+// Query params: ?from=<ms>&to=<ms>&examId=<id>[,<id>]&interval=day|week|month
+func (h *Handler) GetMyAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	q := r.URL.Query()
+
+	var f domain.AnalyticsFilter
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.From, err = strconv.ParseInt(v, 10, 64); err != nil {
+			h.Error(w, 400, "Parâmetro from inválido")
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = strconv.ParseInt(v, 10, 64); err != nil {
+			h.Error(w, 400, "Parâmetro to inválido")
+			return
+		}
+	}
+	for _, v := range q["examId"] {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				f.ExamIDs = append(f.ExamIDs, id)
+			}
+		}
+	}
+	f.Interval = q.Get("interval")
+
+	analytics, err := h.Service.GetUserAnalytics(userID, f)
+	if err != nil {
+		if err.Error() == "intervalo inválido (use day, week ou month)" || err.Error() == "período inválido" {
+			h.Error(w, 400, err.Error())
+			return
+		}
+		h.Error(w, 500, "Erro ao calcular desempenho")
+		return
+	}
+	h.JSON(w, 200, analytics)
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/service"
+	"net/http"
+	"strconv"
+)
+
+// --- Attempts (Limites de Tentativas) ---
+
+// attemptError responde a uma tentativa bloqueada pela política.
+// Retorna false quando err não é um *service.AttemptError (o chamador trata o erro).
+func (h *Handler) attemptError(w http.ResponseWriter, err error) bool {
+	var ae *service.AttemptError
+	if !errors.As(err, &ae) {
+		return false
+	}
+	status := 403
+	switch ae.Code {
+	case service.AttemptCooldown:
+		status = 429
+		w.Header().Set("Retry-After", strconv.Itoa(ae.RetryAfter))
+	case service.AttemptEmailRequired:
+		status = 400
+	}
+	h.JSON(w, status, ae)
+	return true
+}
+
+// GetCompanyAttempts lista todas as tentativas dos candidatos da empresa, agrupadas por exame e email
+func (h *Handler) GetCompanyAttempts(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.companyOrg(w, r, domain.OrgViewer)
+	if !ok {
+		return
+	}
+	groups, err := h.Service.GetCompanyAttempts(org.ID)
+	if err != nil {
+		h.Error(w, 500, "Erro ao buscar tentativas")
+		return
+	}
+	h.JSON(w, 200, groups)
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"esimulate-backend/internal/domain"
+	"net/http"
+	"strconv"
+)
+
+// --- Trilha de auditoria ---
+
+// audit registra uma alteração de segurança feita pelo usuário autenticado sobre resource ("exam:<id>", "user:<id>"...).
+// before/after geram o diff gravado (nil em criações e exclusões).
+func (h *Handler) audit(r *http.Request, action, resource string, before, after any) {
+	actorID, _ := r.Context().Value("userID").(string)
+	h.AuditLogger.LogChange(actorID, getClientIP(r), r.UserAgent(), action, resource, before, after)
+}
+
+// GetAuditEvents consulta a trilha de auditoria (apenas admin), do evento mais recente para o mais antigo.
+// Query: ?actor=&action=&targetType=&targetId=&from=&to= (ms) &limit= (padrão 50, máx. 200) &cursor= (nextCursor da página anterior)
+func (h *Handler) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "view-audit") {
+		return
+	}
+	q := r.URL.Query()
+	f := domain.AuditFilter{
+		ActorID:    q.Get("actor"),
+		Action:     q.Get("action"),
+		TargetType: q.Get("targetType"),
+		TargetID:   q.Get("targetId"),
+	}
+	for _, p := range []struct {
+		name string
+		dest *int64
+	}{{"from", &f.From}, {"to", &f.To}, {"cursor", &f.Cursor}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				h.Error(w, 400, "Parâmetro "+p.name+" inválido")
+				return
+			}
+			*p.dest = n
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			h.Error(w, 400, "Parâmetro limit inválido")
+			return
+		}
+		f.Limit = n
+	}
+
+	page, err := h.Service.ListAuditEvents(f)
+	if err != nil {
+		h.Error(w, 500, "Erro ao buscar eventos de auditoria")
+		return
+	}
+	h.JSON(w, 200, page)
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"errors"
+	"esimulate-backend/internal/service"
+	"net/http"
+)
+
+// --- Availability (Janelas de Disponibilidade) ---
+
+// availabilityError responde a um acesso fora da janela: 403 antes da abertura (com a contagem
+// regressiva) e 410 após o encerramento. Retorna false quando err não é um *service.AvailabilityError.
+func (h *Handler) availabilityError(w http.ResponseWriter, err error) bool {
+	var ae *service.AvailabilityError
+	if !errors.As(err, &ae) {
+		return false
+	}
+	status := 403
+	if ae.Code == service.ExamWindowClosed {
+		status = 410
+	}
+	h.JSON(w, status, ae)
+	return true
+}
+
+// publicCountdown responde com a contagem regressiva quando o candidato chega antes da abertura
+func (h *Handler) publicCountdown(w http.ResponseWriter, err error) bool {
+	var ae *service.AvailabilityError
+	if !errors.As(err, &ae) || ae.Countdown == nil {
+		return false
+	}
+	h.JSON(w, 200, map[string]interface{}{"countdown": ae.Countdown})
+	return true
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"esimulate-backend/internal/service"
+	"net/http"
+)
+
+// --- Certificates ---
+
+// GetResultCertificate gera o PDF do certificado de conclusão de um resultado
+func (h *Handler) GetResultCertificate(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	cert, logo, err := h.Service.GetResultCertificate(r.PathValue("id"), userID, role)
+	if err != nil {
+		switch err.Error() {
+		case "resultado não encontrado":
+			h.Error(w, 404, err.Error())
+		case "acesso negado":
+			h.Error(w, 403, err.Error())
+		case "pontuação não verificada":
+			h.Error(w, 409, "A pontuação do resultado não confere com o gabarito da prova")
+		default:
+			h.Error(w, 500, "Erro ao emitir certificado")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="certificado-`+cert.Code+`.pdf"`)
+	w.Header().Set("X-Certificate-Code", cert.Code)
+	w.WriteHeader(200)
+	w.Write(service.RenderCertificatePDF(cert, logo))
+}
+
+// PublicVerifyCertificate confirma a autenticidade de um certificado pelo código de verificação
+func (h *Handler) PublicVerifyCertificate(w http.ResponseWriter, r *http.Request) {
+	cert, err := h.Service.VerifyCertificate(r.PathValue("code"))
+	if err != nil {
+		h.JSON(w, 404, map[string]interface{}{"valid": false, "error": err.Error()})
+		return
+	}
+	h.JSON(w, 200, map[string]interface{}{"valid": true, "certificate": cert})
+}
@@ -0,0 +1,241 @@
+package http
+
+import (
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"net/http"
+	"strings"
+)
+
+// --- Classes ---
+
+// classError mapeia erros de turmas e atividades para status HTTP
+func (h *Handler) classError(w http.ResponseWriter, r *http.Request, action, resource string, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "acesso negado":
+		h.denyAccess(w, r, action, resource)
+	case msg == "prova não encontrada":
+		h.Error(w, 404, "Exam not found")
+	case msg == "turma não encontrada", msg == "aluno não encontrado", msg == "atividade não encontrada",
+		msg == "usuário não encontrado", msg == "código de convite inválido":
+		h.Error(w, 404, msg)
+	case strings.HasPrefix(msg, "política de tentativas"), msg == "nome da turma é obrigatório",
+		msg == "o professor não pode se matricular na própria turma", msg == "apenas exames publicados podem ser atribuídos",
+		msg == "exames adaptativos não podem ser atribuídos", msg == "prazo de encerramento inválido",
+		msg == "atividade não corresponde à prova":
+		h.Error(w, 400, msg)
+	default:
+		h.Error(w, 500, msg)
+	}
+}
+
+// GetMyClasses lista as turmas do usuário (como professor ou aluno)
+func (h *Handler) GetMyClasses(w http.ResponseWriter, r *http.Request) {
+	classes, err := h.Service.GetMyClasses(r.Context().Value("userID").(string))
+	if err != nil {
+		h.Error(w, 500, err.Error())
+		return
+	}
+	h.JSON(w, 200, classes)
+}
+
+// CreateClass cria uma turma. Body: {"name": "...", "description": "..."}
+func (h *Handler) CreateClass(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	class, err := h.Service.CreateClass(r.Context().Value("userID").(string), req.Name, req.Description)
+	if err != nil {
+		h.classError(w, r, "create-class", "class", err)
+		return
+	}
+	h.JSON(w, 201, class)
+}
+
+// GetClass retorna a turma (professor, alunos e admins)
+func (h *Handler) GetClass(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	class, err := h.Service.GetClass(r.PathValue("id"), userID, role)
+	if err != nil {
+		h.classError(w, r, "view-class", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 200, class)
+}
+
+// DeleteClass remove a turma
+func (h *Handler) DeleteClass(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.DeleteClass(r.PathValue("id"), userID, role); err != nil {
+		h.classError(w, r, "delete-class", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "CLASS_DELETED", "class:"+r.PathValue("id"), nil, nil)
+	w.WriteHeader(204)
+}
+
+// JoinClass matricula o usuário pelo código de convite. Body: {"code": "..."}
+func (h *Handler) JoinClass(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	class, err := h.Service.JoinClass(r.Context().Value("userID").(string), req.Code)
+	if err != nil {
+		h.classError(w, r, "join-class", "class", err)
+		return
+	}
+	h.JSON(w, 200, class)
+}
+
+// RotateClassCode gera um novo código de convite para a turma
+func (h *Handler) RotateClassCode(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	class, err := h.Service.RotateClassCode(r.PathValue("id"), userID, role)
+	if err != nil {
+		h.classError(w, r, "rotate-class-code", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "CLASS_CODE_ROTATED", "class:"+r.PathValue("id"), nil, nil)
+	h.JSON(w, 200, class)
+}
+
+// GetClassStudents lista os alunos da turma
+func (h *Handler) GetClassStudents(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	students, err := h.Service.GetClassStudents(r.PathValue("id"), userID, role)
+	if err != nil {
+		h.classError(w, r, "list-class-members", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 200, students)
+}
+
+// AddClassStudent matricula um usuário por email. Body: {"email": "..."}
+func (h *Handler) AddClassStudent(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	students, err := h.Service.AddClassStudent(r.PathValue("id"), userID, role, req.Email)
+	if err != nil {
+		h.classError(w, r, "add-class-member", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "CLASS_MEMBER_ADDED", "class:"+r.PathValue("id"), nil, map[string]string{"email": req.Email})
+	h.JSON(w, 200, students)
+}
+
+// RemoveClassStudent remove um aluno (ou o próprio aluno sai da turma)
+func (h *Handler) RemoveClassStudent(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.RemoveClassStudent(r.PathValue("id"), r.PathValue("userId"), userID, role); err != nil {
+		h.classError(w, r, "remove-class-member", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "CLASS_MEMBER_REMOVED", "class:"+r.PathValue("id"), map[string]string{"userId": r.PathValue("userId")}, nil)
+	w.WriteHeader(204)
+}
+
+// --- Assignments ---
+
+// GetClassAssignments lista as atividades da turma
+func (h *Handler) GetClassAssignments(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	assignments, err := h.Service.GetClassAssignments(r.PathValue("id"), userID, role)
+	if err != nil {
+		h.classError(w, r, "list-assignments", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 200, assignments)
+}
+
+// CreateAssignment atribui um exame à turma
+// Body: {"examId": "...", "title": "...", "opensAt": ms, "closesAt": ms, "attemptPolicy": {...}}
+func (h *Handler) CreateAssignment(w http.ResponseWriter, r *http.Request) {
+	var req domain.Assignment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ExamID == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	assignment, err := h.Service.CreateAssignment(r.PathValue("id"), userID, role, req)
+	if err != nil {
+		h.classError(w, r, "create-assignment", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 201, assignment)
+}
+
+// DeleteAssignment remove uma atividade da turma
+func (h *Handler) DeleteAssignment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.DeleteAssignment(r.PathValue("id"), r.PathValue("assignmentId"), userID, role); err != nil {
+		h.classError(w, r, "delete-assignment", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "ASSIGNMENT_DELETED", "class:"+r.PathValue("id"), map[string]string{"assignmentId": r.PathValue("assignmentId")}, nil)
+	w.WriteHeader(204)
+}
+
+// GetGradebook retorna o quadro de notas da turma (apenas o professor ou admin)
+func (h *Handler) GetGradebook(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	gradebook, err := h.Service.GetGradebook(r.PathValue("id"), userID, role)
+	if err != nil {
+		h.classError(w, r, "view-gradebook", "class:"+r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 200, gradebook)
+}
+
+// GetMyAssignments lista as atividades pendentes do aluno (?all=true inclui todas)
+func (h *Handler) GetMyAssignments(w http.ResponseWriter, r *http.Request) {
+	all := r.URL.Query().Get("all") == "true"
+	assignments, err := h.Service.GetMyAssignments(r.Context().Value("userID").(string), all)
+	if err != nil {
+		h.Error(w, 500, err.Error())
+		return
+	}
+	h.JSON(w, 200, assignments)
+}
+
+// GetMyAssignmentExam retorna ao aluno o exame da atividade, sem gabarito, enquanto a atividade estiver aberta
+func (h *Handler) GetMyAssignmentExam(w http.ResponseWriter, r *http.Request) {
+	exam, err := h.Service.GetAssignmentExam(r.PathValue("id"), r.Context().Value("userID").(string))
+	if err != nil {
+		if h.attemptError(w, err) {
+			return
+		}
+		if err.Error() == "prova não disponível" {
+			h.Error(w, 400, "Prova não disponível")
+			return
+		}
+		h.classError(w, r, "view-assignment-exam", "assignment:"+r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 200, exam)
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"net/http"
+)
+
+// --- Exam Cloning & Templates ---
+
+// CloneExam cria uma cópia do exame para o usuário autenticado
+// Body opcional: {"title": "...", "deepCopy": true}
+func (h *Handler) CloneExam(w http.ResponseWriter, r *http.Request) {
+	var opts domain.CloneOptions
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			h.Error(w, 400, "Invalid JSON")
+			return
+		}
+	}
+	exam, err := h.Service.CloneExam(r.PathValue("id"), r.Context().Value("userID").(string), opts)
+	if err != nil {
+		switch err.Error() {
+		case "prova não encontrada":
+			h.Error(w, 404, "Exam not found")
+		case "acesso negado":
+			h.Error(w, 403, "Access denied")
+		default:
+			h.Error(w, 500, "Erro ao clonar exame")
+		}
+		return
+	}
+	h.JSON(w, 201, exam)
+}
+
+// GetExamTemplates lista o catálogo de modelos
+func (h *Handler) GetExamTemplates(w http.ResponseWriter, r *http.Request) {
+	exams, err := h.Service.Repo.GetTemplateExams()
+	if err != nil {
+		h.Error(w, 500, err.Error())
+		return
+	}
+	for i := range exams {
+		exams[i].IsVerified = calculateExamIsVerified(exams[i])
+	}
+	h.JSON(w, 200, exams)
+}
+
+// SetExamTemplate adiciona ou remove um exame do catálogo de modelos (apenas admin)
+func (h *Handler) SetExamTemplate(w http.ResponseWriter, r *http.Request) {
+	if role, _ := r.Context().Value("role").(string); role != "admin" {
+		userID, _ := r.Context().Value("userID").(string)
+		h.AuditLogger.LogAccessDenied(userID, getClientIP(r), r.Header.Get("User-Agent"), "template", "exam:"+r.PathValue("id"))
+		h.Error(w, 403, "Acesso restrito a administradores")
+		return
+	}
+	var req struct {
+		IsTemplate *bool `json:"isTemplate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IsTemplate == nil {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	if err := h.Service.Repo.SetExamTemplate(r.PathValue("id"), *req.IsTemplate); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.Error(w, 404, "Exam not found")
+			return
+		}
+		h.Error(w, 500, err.Error())
+		return
+	}
+	h.audit(r, "EXAM_TEMPLATE_UPDATED", "exam:"+r.PathValue("id"), nil, map[string]bool{"isTemplate": *req.IsTemplate})
+	w.WriteHeader(204)
+}
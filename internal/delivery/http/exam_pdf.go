@@ -0,0 +1,65 @@
+package http
+
+import (
+	"esimulate-backend/internal/service"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// --- Exam PDF Export ---
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// pdfFilename gera um nome de arquivo seguro a partir do título do exame
+func pdfFilename(title, suffix string) string {
+	name := strings.Trim(unsafeFilenameChars.ReplaceAllString(title, "-"), "-")
+	if name == "" {
+		name = "simulado"
+	}
+	if len(name) > 60 {
+		name = name[:60]
+	}
+	return name + suffix + ".pdf"
+}
+
+// ExportExamPDF gera o PDF de um exame para impressão.
+// Query params: type=booklet (padrão) | answer-key; answerSheet=true inclui a folha de respostas no caderno.
+// O gabarito é restrito aos autores do exame (criador, editores e coautores), admin e specialist.
+func (h *Handler) ExportExamPDF(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	exam, err := h.Service.Repo.GetExamByID(r.PathValue("id"))
+	if err != nil {
+		h.Error(w, 404, "Exam not found")
+		return
+	}
+	// Mesmo controle de acesso de GetExam
+	if !h.Service.CanViewExam(exam, userID) {
+		h.Error(w, 403, "Access denied")
+		return
+	}
+
+	var data []byte
+	var filename string
+	switch r.URL.Query().Get("type") {
+	case "", "booklet":
+		data = service.RenderExamBookletPDF(exam, r.URL.Query().Get("answerSheet") == "true")
+		filename = pdfFilename(exam.Title, "")
+	case "answer-key":
+		if role != "specialist" && !h.Service.CanEditExam(exam, userID, role) {
+			h.Error(w, 403, "Apenas os autores do exame, admin ou specialist podem baixar o gabarito")
+			return
+		}
+		data = service.RenderAnswerKeyPDF(exam)
+		filename = pdfFilename(exam.Title, "-gabarito")
+	default:
+		h.Error(w, 400, "type inválido (use booklet ou answer-key)")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(200)
+	w.Write(data)
+}
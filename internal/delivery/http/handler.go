@@ -1,11 +1,14 @@
 package http
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"esimulate-backend/internal/domain"
 	"esimulate-backend/internal/security"
 	"esimulate-backend/internal/service"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,10 +19,10 @@ type Handler struct {
 	Service     *service.Service
 	RateLimiter *security.RateLimiter
 	AuditLogger *security.AuditLogger
-	Blacklist   *security.TokenBlacklist
+	Blacklist   security.TokenBlacklist
 }
 
-func NewHandler(svc *service.Service, rl *security.RateLimiter, al *security.AuditLogger, bl *security.TokenBlacklist) *Handler {
+func NewHandler(svc *service.Service, rl *security.RateLimiter, al *security.AuditLogger, bl security.TokenBlacklist) *Handler {
 	return &Handler{
 		Service:     svc,
 		RateLimiter: rl,
@@ -59,6 +62,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	h.AuditLogger.LogChange(created.ID, getClientIP(r), r.UserAgent(), "USER_REGISTERED", "user:"+created.ID, nil, created)
 	h.JSON(w, 201, created)
 }
 
@@ -72,8 +76,12 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	ip := getClientIP(r)
 	userAgent := r.UserAgent()
 	
-	loginResp, refreshToken, err := h.Service.LoginUser(creds.Email, creds.Password)
+	loginResp, refreshToken, err := h.Service.LoginUser(creds.Email, creds.Password, clientInfo(r))
 	if err != nil {
+		// Senha correta, mas o login continua no segundo fator
+		if h.twoFactorChallenge(w, err) {
+			return
+		}
 		// Log de tentativa falha
 		h.AuditLogger.LogLogin("", ip, userAgent, false)
 		
@@ -90,7 +98,14 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// Log de login bem-sucedido
 	h.AuditLogger.LogLogin(loginResp.User.ID, ip, userAgent, true)
 	
-	// Setar cookie refresh_token conforme contrato v2.4.0
+	setRefreshCookie(w, r, refreshToken)
+	
+	// Retornar resposta conforme contrato: { "user": {...}, "token": "..." }
+	h.JSON(w, 200, loginResp)
+}
+
+// setRefreshCookie grava o cookie refresh_token conforme contrato v2.4.0
+func setRefreshCookie(w http.ResponseWriter, r *http.Request, refreshToken string) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "refresh_token",
 		Value:    refreshToken,
@@ -100,22 +115,16 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		Path:     "/api/auth/refresh",
 		MaxAge:   604800, // 7 dias em segundos
 	})
-	
-	// Retornar resposta conforme contrato: { "user": {...}, "token": "..." }
-	h.JSON(w, 200, loginResp)
 }
 
-// getClientIP extrai o IP real do cliente
+// clientInfo identifica o dispositivo da requisição para a sessão aberta no login
+func clientInfo(r *http.Request) service.ClientInfo {
+	return service.ClientInfo{IP: getClientIP(r), UserAgent: r.UserAgent()}
+}
+
+// getClientIP retorna o IP do cliente resolvido pelo security.ClientIPMiddleware (proxies confiáveis)
 func getClientIP(r *http.Request) string {
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		return forwarded
-	}
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
-	}
-	return r.RemoteAddr
+	return security.ClientIP(r)
 }
 
 func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
@@ -161,8 +170,15 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	userAgent := r.UserAgent()
 	userID := ""
 	
-	// Obter tokenID do context (se disponível)
-	tokenID, _ := r.Context().Value("tokenID").(string)
+	// A rota é pública: o access token (se enviado) é validado aqui para entrar no blacklist
+	var tokenID string
+	var blockUntil time.Time
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+		if _, exp, err := parseAccessToken(h.Service.JWTKeys, tokenStr); err == nil {
+			tokenID, blockUntil = accessTokenID(tokenStr), exp
+		}
+	}
 	
 	// Ler refresh token do cookie
 	cookie, err := r.Cookie("refresh_token")
@@ -170,14 +186,14 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 		// Buscar userID antes de invalidar
 		userID, _, _ = h.Service.Repo.GetRefreshToken(cookie.Value)
 		
-		// Invalidar refresh token no banco
+		// Encerrar a sessão e invalidar refresh token no banco
+		h.Service.Repo.DeleteSessionByRefreshToken(cookie.Value)
 		h.Service.Repo.InvalidateRefreshToken(cookie.Value)
 	}
 
-	// Adicionar access token ao blacklist (se disponível)
+	// Adicionar access token ao blacklist (se disponível) até o exp dele
 	if tokenID != "" {
-		// Access token expira em 15 minutos, então manter no blacklist por 20 minutos
-		h.Blacklist.Add(tokenID, time.Now().Add(20*time.Minute))
+		h.Blacklist.Add(tokenID, blockUntil)
 	}
 
 	// Log de logout
@@ -217,6 +233,7 @@ func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 		// Enviar email (não bloquear se falhar)
 		go h.Service.EmailService.SendPasswordResetEmail(user.Email, user.Name, token)
 	}
+	h.AuditLogger.LogChange("", getClientIP(r), r.UserAgent(), "PASSWORD_RESET_REQUESTED", "user:"+user.ID, nil, nil)
 	
 	h.JSON(w, 200, map[string]string{"message": "Email enviado"})
 }
@@ -269,6 +286,10 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	
 	// Marcar token como usado
 	h.Service.Repo.MarkTokenAsUsed(req.Token)
+
+	// Nova senha remove o bloqueio por tentativas de login
+	h.Service.Repo.ClearLoginFailures(userID)
+	h.AuditLogger.LogPasswordReset(userID, getClientIP(r), r.UserAgent())
 	
 	h.JSON(w, 200, map[string]string{"message": "Senha alterada"})
 }
@@ -340,10 +361,26 @@ func (h *Handler) GetExams(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("userID").(string)
 	
 	// Query params: ?public=true ou ?owner=me
+	// ?status=draft|published|archived|deleted lista apenas os exames do usuário naquele estado
+	// ?orgId= restringe aos exames da organização (membros)
 	publicOnly := r.URL.Query().Get("public") == "true"
 	ownerOnly := r.URL.Query().Get("owner") == "me"
+	status := r.URL.Query().Get("status")
+	orgID := r.URL.Query().Get("orgId")
+	if orgID != "" {
+		if _, err := h.Service.OrgMembership(orgID, userID, domain.OrgViewer); err != nil {
+			h.orgError(w, r, "list-org-exams", orgID, err)
+			return
+		}
+	}
+	switch status {
+	case "", domain.ExamDraft, domain.ExamPublished, domain.ExamArchived, "deleted":
+	default:
+		h.Error(w, 400, "status inválido")
+		return
+	}
 	
-	exams, err := h.Service.Repo.GetExamsByUser(userID, publicOnly, ownerOnly)
+	exams, err := h.Service.Repo.GetExamsByUser(userID, publicOnly, ownerOnly, status, orgID)
 	if err != nil { h.Error(w, 500, err.Error()); return }
 	
 	// Calcular isVerified para cada exame baseado nas questões
@@ -363,14 +400,15 @@ func (h *Handler) GetExam(w http.ResponseWriter, r *http.Request) {
 		return 
 	}
 	
-	// Verificar acesso: se não é público, só o criador pode ver
-	if !exam.IsPublic && exam.CreatedBy != userID {
+	// Verificar acesso: se não é público nem modelo do catálogo, só o criador pode ver
+	if !h.Service.CanViewExam(exam, userID) {
 		h.Error(w, 403, "Access denied")
 		return
 	}
 	
 	// Calcular isVerified baseado nas questões
 	exam.IsVerified = calculateExamIsVerified(exam)
+	exam.AccessRole = h.Service.ExamAccessRole(exam, userID)
 	
 	h.JSON(w, 200, exam)
 }
@@ -382,16 +420,53 @@ func (h *Handler) CreateExam(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("userID").(string)
 	userRole := r.Context().Value("role").(string)
 	e.CreatedBy = userID
+	e.ClonedFrom = "" // Definido apenas por POST /api/exams/{id}/clone
 	
 	// Verificar se é update (ID existe) ou create (ID vazio)
 	isUpdate := e.ID != ""
+	var before map[string]any // Estado e visibilidade anteriores (auditoria)
 	if !isUpdate {
 		e.ID = uuid.New().String()
 		e.CreatedAt = time.Now().UnixMilli()
+		// Novos exames podem nascer como rascunho; o estado só muda pelos endpoints de transição
+		if e.Status != "" && e.Status != domain.ExamDraft && e.Status != domain.ExamPublished {
+			h.Error(w, 400, "status inválido")
+			return
+		}
+		if !h.checkExamOrg(w, r, e.OrgID) {
+			return
+		}
 	} else {
-		// Se for update, buscar exame existente para validar regras
-		existingExam, err := h.Service.Repo.GetExamByID(e.ID)
-		if err == nil {
+		// Se for update, apenas autores (owner/editor) ou admin podem alterar o exame existente
+		existingExam, exists, err := h.Service.AuthorizeExamUpdate(e.ID, userID, userRole)
+		if err != nil {
+			switch err.Error() {
+			case "acesso negado":
+			case "prova não encontrada":
+				h.Error(w, 404, "Exam not found")
+				return
+			default:
+				h.Error(w, 500, "Failed to fetch exam")
+				return
+			}
+			h.AuditLogger.LogAccessDenied(userID, getClientIP(r), r.Header.Get("User-Agent"), "update", "exam:"+e.ID)
+			h.Error(w, 403, "Access denied")
+			return
+		}
+		if !exists && !h.checkExamOrg(w, r, e.OrgID) {
+			return
+		}
+		if exists {
+			before = map[string]any{"status": existingExam.Status, "isPublic": existingExam.IsPublic}
+			// A posse só muda por POST /api/exams/{id}/transfer; a organização é definida na criação
+			e.CreatedBy = existingExam.CreatedBy
+			e.OrgID = existingExam.OrgID
+			// Editores alteram o conteúdo; a visibilidade fica com owners e admin
+			if existingExam.IsPublic != e.IsPublic && !h.Service.CanModifyExam(existingExam, userID, userRole) {
+				h.AuditLogger.LogAccessDenied(userID, getClientIP(r), r.Header.Get("User-Agent"), "visibility", "exam:"+e.ID)
+				h.Error(w, 403, "Apenas owners podem alterar a visibilidade da prova")
+				return
+			}
 			// Regra: Se isPublic estava true e está sendo alterado para false, só admin/specialist pode
 			if existingExam.IsPublic && !e.IsPublic && userRole != "admin" && userRole != "specialist" {
 				h.Error(w, 403, "Apenas admin ou specialist podem tornar provas públicas em privadas")
@@ -400,6 +475,15 @@ func (h *Handler) CreateExam(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
+	if err := service.ValidateAttemptPolicy(e.AttemptPolicy); err != nil {
+		h.Error(w, 400, err.Error())
+		return
+	}
+	if err := service.ValidateAvailability(e.Availability); err != nil {
+		h.Error(w, 400, err.Error())
+		return
+	}
+
 	// isVerified não é mais armazenado - será calculado baseado nas questões
 	// Remover isVerified do payload se foi enviado (frontend não deve enviar)
 	e.IsVerified = false // Será calculado depois
@@ -411,7 +495,18 @@ func (h *Handler) CreateExam(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
-	if err := h.Service.Repo.CreateExam(e); err != nil { h.Error(w, 500, err.Error()); return }
+	if err := h.Service.Repo.CreateExam(e, userID, userRole == "admin"); err != nil {
+		if err.Error() == "questão pertence a outro autor" {
+			h.Error(w, 403, err.Error())
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			h.Error(w, 404, "Exam not found") // Excluído durante a edição
+			return
+		}
+		h.Error(w, 500, err.Error())
+		return
+	}
 	
 	// Retornar exame atualizado
 	exam, err := h.Service.Repo.GetExamByID(e.ID)
@@ -419,6 +514,13 @@ func (h *Handler) CreateExam(w http.ResponseWriter, r *http.Request) {
 	
 	// Calcular isVerified baseado nas questões
 	exam.IsVerified = calculateExamIsVerified(exam)
+
+	after := map[string]any{"status": exam.Status, "isPublic": exam.IsPublic}
+	if before != nil {
+		h.audit(r, "EXAM_UPDATED", "exam:"+exam.ID, before, after)
+	} else {
+		h.audit(r, "EXAM_CREATED", "exam:"+exam.ID, nil, after)
+	}
 	
 	// Retornar 200 se for update, 201 se for create
 	if isUpdate {
@@ -428,10 +530,20 @@ func (h *Handler) CreateExam(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// DeleteExam exclui logicamente o exame (restaurável até a purga)
 func (h *Handler) DeleteExam(w http.ResponseWriter, r *http.Request) {
-	if err := h.Service.Repo.DeleteExam(r.PathValue("id")); err != nil {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.DeleteExam(r.PathValue("id"), userID, role); err != nil {
+		switch err.Error() {
+		case "prova não encontrada":
+			h.Error(w, 404, "Exam not found"); return
+		case "acesso negado":
+			h.denyExamAccess(w, r, "delete"); return
+		}
 		h.Error(w, 500, err.Error()); return
 	}
+	h.audit(r, "EXAM_DELETED", "exam:"+r.PathValue("id"), nil, nil)
 	w.WriteHeader(204)
 }
 
@@ -441,12 +553,24 @@ func (h *Handler) GetQuestions(w http.ResponseWriter, r *http.Request) {
 	if err != nil { h.Error(w, 500, err.Error()); return }
 	h.JSON(w, 200, qs)
 }
+// CreateQuestion cria a questão ou atualiza uma existente (apenas autor ou admin)
 func (h *Handler) CreateQuestion(w http.ResponseWriter, r *http.Request) {
 	var q domain.Question
 	json.NewDecoder(r.Body).Decode(&q)
-	if err := h.Service.Repo.CreateQuestion(q); err != nil { h.Error(w, 500, err.Error()); return }
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.SaveQuestion(q, r.Context().Value("userID").(string), role); err != nil {
+		if err.Error() == "acesso negado" {
+			h.denyAccess(w, r, "update", "question:"+q.ID)
+			return
+		}
+		h.Error(w, 500, err.Error())
+		return
+	}
+	q.CreatedBy = r.Context().Value("userID").(string)
 	h.JSON(w, 201, q)
 }
+
+// BatchQuestions grava um lote de questões. Questões de outros autores são ignoradas (e registradas como acesso negado).
 func (h *Handler) BatchQuestions(w http.ResponseWriter, r *http.Request) {
 	var qs []domain.Question
 	if err := json.NewDecoder(r.Body).Decode(&qs); err != nil {
@@ -454,29 +578,108 @@ func (h *Handler) BatchQuestions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	count := 0
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	count, denied := 0, 0
 	for _, q := range qs {
-		if err := h.Service.Repo.CreateQuestion(q); err == nil {
+		err := h.Service.SaveQuestion(q, userID, role)
+		switch {
+		case err == nil:
 			count++
+		case err.Error() == "acesso negado":
+			denied++
+			h.logAccessDenied(r, "update", "question:"+q.ID)
 		}
 	}
 	
 	h.JSON(w, 200, map[string]interface{}{
 		"status": "success",
 		"count":  count,
+		"denied": denied,
 	})
 }
+
+// DeleteQuestion exclui a questão (apenas autor ou admin)
 func (h *Handler) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
-	h.Service.Repo.DeleteQuestion(r.PathValue("id"))
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.DeleteQuestion(r.PathValue("id"), r.Context().Value("userID").(string), role); err != nil {
+		switch err.Error() {
+		case "acesso negado":
+			h.denyAccess(w, r, "delete", "question:"+r.PathValue("id"))
+		case "questão não encontrada":
+			h.Error(w, 404, "Question not found")
+		default:
+			h.Error(w, 500, err.Error())
+		}
+		return
+	}
+	h.audit(r, "QUESTION_DELETED", "question:"+r.PathValue("id"), nil, nil)
 	w.WriteHeader(204)
 }
 
 // --- Results ---
+
+// submitError traduz os erros da gravação de uma tentativa para status HTTP
+func (h *Handler) submitError(w http.ResponseWriter, err error) {
+	if h.attemptError(w, err) {
+		return
+	}
+	switch err.Error() {
+	case "attemptId inválido", "tentativa inválida":
+		h.Error(w, 400, err.Error())
+	default:
+		h.Error(w, 500, "Erro ao salvar resultado")
+	}
+}
+
+// SaveResult registra a tentativa do usuário autenticado. Nota, total e data são calculados no backend a partir do exame salvo.
 func (h *Handler) SaveResult(w http.ResponseWriter, r *http.Request) {
 	var res domain.ExamResult
 	json.NewDecoder(r.Body).Decode(&res)
 	res.UserID = r.Context().Value("userID").(string)
-	if err := h.Service.Repo.CreateResult(res); err != nil { h.Error(w, 500, err.Error()); return }
+	res.LinkID = "" // Tentativas autenticadas não passam por link público
+	res.Ability = nil // A habilidade (TRI) só é calculada pela sessão adaptativa
+	res.SubmitIP = getClientIP(r)
+	res.UserAgent = r.UserAgent()
+	exam, err := h.Service.Repo.GetExamByID(res.ExamID)
+	if err != nil || exam.DeletedAt > 0 {
+		h.Error(w, 404, "Exam not found")
+		return
+	}
+	// Apenas exames publicados recebem tentativas (rascunhos e arquivados, não)
+	if !h.Service.IsExamOpenForLinks(exam) {
+		h.Error(w, 400, "Prova não disponível")
+		return
+	}
+	// Mesmo controle de acesso de GetExam. Entregas de atividade são validadas pela matrícula e pela janela
+	// da atividade (CheckAssignmentAttempt), já que a turma não concede leitura do exame
+	if res.AssignmentID == "" && !h.Service.CanViewExam(exam, res.UserID) {
+		h.Error(w, 403, "Access denied")
+		return
+	}
+	if exam.Adaptive != nil && exam.Adaptive.Enabled {
+		h.Error(w, 400, "Exame adaptativo: utilize a sessão adaptativa")
+		return
+	}
+	// Entregas de atividades seguem a janela e a política da atividade
+	if res.AssignmentID != "" {
+		err = h.Service.CheckAssignmentAttempt(res.AssignmentID, exam, res.UserID)
+	} else {
+		err = h.Service.CheckAttemptAllowed(exam, domain.PublicLink{}, res.UserID, "")
+	}
+	if err != nil {
+		if !h.attemptError(w, err) { h.classError(w, r, "submit-assignment", "assignment:"+res.AssignmentID, err) }
+		return
+	}
+	answers := service.ExamAnswers(exam, res.Answers)
+	res.Answers = answers
+	res.Score, res.TotalQuestions = h.Service.CalculateScore(exam, answers)
+	res.Date = time.Now().UnixMilli()
+	res.ID = uuid.New().String() // O ID é sempre gerado no servidor
+	if err := h.Service.SubmitResult(res, exam, domain.PublicLink{}); err != nil {
+		h.submitError(w, err)
+		return
+	}
 	h.JSON(w, 201, res)
 }
 func (h *Handler) GetMyResults(w http.ResponseWriter, r *http.Request) {
@@ -492,7 +695,10 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	h.JSON(w, 200, users)
 }
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	h.Service.Repo.DeleteUser(r.PathValue("id"))
+	before, _ := h.Service.Repo.GetUserByID(r.PathValue("id"))
+	if err := h.Service.Repo.DeleteUser(r.PathValue("id")); err == nil {
+		h.audit(r, "USER_DELETED", "user:"+r.PathValue("id"), before, nil)
+	}
 	w.WriteHeader(204)
 }
 func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
@@ -524,6 +730,7 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		updates["onboardingCompleted"] = *req.OnboardingCompleted
 	}
 	
+	before, _ := h.Service.Repo.GetUserByID(req.ID)
 	if err := h.Service.Repo.UpdateUser(req.ID, updates); err != nil {
 		h.Error(w, 500, err.Error())
 		return
@@ -535,6 +742,7 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		h.Error(w, 500, "Failed to fetch updated user")
 		return
 	}
+	h.audit(r, "USER_UPDATED", "user:"+req.ID, before, user)
 	h.JSON(w, 200, user)
 }
 
@@ -550,7 +758,9 @@ func (h *Handler) CreateSubject(w http.ResponseWriter, r *http.Request) {
 	h.JSON(w, 201, sub)
 }
 func (h *Handler) DeleteSubject(w http.ResponseWriter, r *http.Request) {
-	h.Service.Repo.DeleteSubject(r.PathValue("id"))
+	if err := h.Service.Repo.DeleteSubject(r.PathValue("id")); err == nil {
+		h.audit(r, "SUBJECT_DELETED", "subject:"+r.PathValue("id"), nil, nil)
+	}
 	w.WriteHeader(204)
 }
 func (h *Handler) GetTopics(w http.ResponseWriter, r *http.Request) {
@@ -564,33 +774,75 @@ func (h *Handler) CreateTopic(w http.ResponseWriter, r *http.Request) {
 	h.JSON(w, 201, top)
 }
 func (h *Handler) DeleteTopic(w http.ResponseWriter, r *http.Request) {
-	h.Service.Repo.DeleteTopic(r.PathValue("id"))
+	if err := h.Service.Repo.DeleteTopic(r.PathValue("id")); err == nil {
+		h.audit(r, "TOPIC_DELETED", "topic:"+r.PathValue("id"), nil, nil)
+	}
 	w.WriteHeader(204)
 }
 
 // --- Company B2B ---
 func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
-	var req struct { ExamID, Label string }
+	var req struct {
+		ExamID, Label string
+		AttemptPolicy *domain.AttemptPolicy `json:"attemptPolicy"`
+		FlagThreshold int `json:"flagThreshold"`
+		Availability *domain.Availability `json:"availability"`
+	}
 	json.NewDecoder(r.Body).Decode(&req)
+	if err := service.ValidateAttemptPolicy(req.AttemptPolicy); err != nil {
+		h.Error(w, 400, err.Error())
+		return
+	}
+	if err := service.ValidateAvailability(req.Availability); err != nil {
+		h.Error(w, 400, err.Error())
+		return
+	}
+	if req.FlagThreshold < 0 {
+		h.Error(w, 400, "flagThreshold inválido")
+		return
+	}
+	org, ok := h.companyOrg(w, r, domain.OrgRecruiter)
+	if !ok {
+		return
+	}
+	userID := r.Context().Value("userID").(string)
+	if err := h.Service.CanLinkExam(req.ExamID, userID); err != nil {
+		if err.Error() == "acesso negado" {
+			h.denyAccess(w, r, "link", "exam:"+req.ExamID)
+			return
+		}
+		h.Error(w, 400, err.Error())
+		return
+	}
 	link := domain.PublicLink{
-		ID: uuid.New().String(), ExamID: req.ExamID, CompanyID: r.Context().Value("userID").(string),
+		ID: uuid.New().String(), ExamID: req.ExamID, CompanyID: userID, OrgID: org.ID,
 		Token: uuid.New().String()[:8], Label: req.Label, Active: true, CreatedAt: time.Now().UnixMilli(),
+		AttemptPolicy: req.AttemptPolicy, FlagThreshold: req.FlagThreshold, Availability: req.Availability,
+	}
+	if err := h.Service.Repo.CreateLink(link); err == nil {
+		h.audit(r, "LINK_CREATED", "exam:"+req.ExamID, nil, link)
 	}
-	h.Service.Repo.CreateLink(link)
 	h.JSON(w, 201, link)
 }
 func (h *Handler) GetCompanyLinks(w http.ResponseWriter, r *http.Request) {
-	l, _ := h.Service.Repo.GetLinks(r.Context().Value("userID").(string))
+	org, ok := h.companyOrg(w, r, domain.OrgViewer)
+	if !ok { return }
+	l, _ := h.Service.Repo.GetLinks(org.ID)
 	h.JSON(w, 200, l)
 }
 func (h *Handler) GetCompanyResults(w http.ResponseWriter, r *http.Request) {
-	res, _ := h.Service.Repo.GetCompanyResults(r.Context().Value("userID").(string))
+	org, ok := h.companyOrg(w, r, domain.OrgViewer)
+	if !ok { return }
+	res, err := h.Service.GetCompanyResults(org.ID)
+	if err != nil { h.Error(w, 500, err.Error()); return }
 	h.JSON(w, 200, res)
 }
 
 // --- Public Access ---
 func (h *Handler) PublicGetExam(w http.ResponseWriter, r *http.Request) {
 	exam, link, err := h.Service.GetSanitizedExam(r.PathValue("token"))
+	// Antes da abertura o candidato recebe a contagem regressiva no lugar do exame
+	if h.publicCountdown(w, err) || h.availabilityError(w, err) { return }
 	if err != nil { h.Error(w, 404, err.Error()); return }
 	h.JSON(w, 200, map[string]interface{}{"exam": exam, "link": link})
 }
@@ -612,45 +864,50 @@ func (h *Handler) PublicSubmit(w http.ResponseWriter, r *http.Request) {
 	// Obter exame original com gabarito
 	exam, err := h.Service.Repo.GetExamByID(link.ExamID)
 	if err != nil { h.Error(w, 404, "Exam not found"); return }
+	if !h.Service.IsExamOpenForLinks(exam) {
+		h.Error(w, 400, "Prova não disponível")
+		return
+	}
+	if exam.Adaptive != nil && exam.Adaptive.Enabled {
+		h.Error(w, 400, "Exame adaptativo: utilize a sessão adaptativa")
+		return
+	}
+	if err := h.Service.CheckLinkAvailability(exam, link); err != nil {
+		h.availabilityError(w, err)
+		return
+	}
 
 	var sub domain.ExamResult
 	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
 		h.Error(w, 400, "Invalid JSON")
 		return
 	}
+
+	// Limite de tentativas e cooldown (candidato identificado pelo email)
+	if err := h.Service.CheckAttemptAllowed(exam, link, "", sub.CandidateEmail); err != nil {
+		if !h.attemptError(w, err) { h.Error(w, 500, err.Error()) }
+		return
+	}
 	
 	// Calcular nota no backend (segurança: evitar fraude)
 	// O frontend envia apenas as respostas selecionadas, não o score
-	var answers []map[string]interface{}
-	if sub.Answers != nil {
-		// Converter Answers para formato esperado
-		if answersMap, ok := sub.Answers.(map[string]interface{}); ok {
-			// Se for um mapa, converter para array
-			for _, v := range answersMap {
-				if answerMap, ok := v.(map[string]interface{}); ok {
-					answers = append(answers, answerMap)
-				}
-			}
-		} else if answersArray, ok := sub.Answers.([]interface{}); ok {
-			// Se já for array
-			for _, v := range answersArray {
-				if answerMap, ok := v.(map[string]interface{}); ok {
-					answers = append(answers, answerMap)
-				}
-			}
-		}
-	}
-	
+	answers := service.ExamAnswers(exam, sub.Answers)
+	sub.Answers = answers
 	correctCount, totalQuestions := h.Service.CalculateScore(exam, answers)
 	sub.Score = correctCount
 	sub.TotalQuestions = totalQuestions
+	sub.Ability = nil // A habilidade (TRI) só é calculada pela sessão adaptativa
+	sub.UserID = ""
 	sub.ID = uuid.New().String()
 	sub.ExamID = link.ExamID
+	sub.LinkID = link.ID
+	sub.SubmitIP = getClientIP(r)
+	sub.UserAgent = r.UserAgent()
 	sub.Date = time.Now().UnixMilli()
 	
-	if err := h.Service.Repo.CreateResult(sub); err != nil { 
-		h.Error(w, 500, err.Error())
-		return 
+	if err := h.Service.SubmitResult(sub, exam, link); err != nil {
+		h.submitError(w, err)
+		return
 	}
 		h.JSON(w, 200, map[string]string{
 		"status":  "success",
@@ -666,6 +923,11 @@ func (h *Handler) CompanyInvite(w http.ResponseWriter, r *http.Request) {
 		h.Error(w, 403, "Apenas empresas podem enviar convites")
 		return
 	}
+	// Convites são enviados em nome da organização (recruiter ou owner)
+	org, ok := h.companyOrg(w, r, domain.OrgRecruiter)
+	if !ok {
+		return
+	}
 	
 	var req struct {
 		Email     string `json:"email"`
@@ -675,46 +937,31 @@ func (h *Handler) CompanyInvite(w http.ResponseWriter, r *http.Request) {
 		h.Error(w, 400, "Invalid JSON")
 		return
 	}
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" {
+		h.Error(w, 400, "Email é obrigatório")
+		return
+	}
 	
-	// Validar link
+	// Validar link (precisa ser da organização)
 	link, err := h.Service.Repo.GetLinkByToken(req.LinkToken)
-	if err != nil || !link.Active {
+	if err != nil || !link.Active || link.OrgID != org.ID {
 		h.Error(w, 404, "Link inválido ou inativo")
 		return
 	}
 	
-	// Buscar perfil da empresa
-	companyID := r.Context().Value("userID").(string)
-	company, err := h.Service.Repo.GetUserByID(companyID)
-	if err != nil {
-		h.Error(w, 500, "Erro ao buscar dados da empresa")
+	// Convidados recebem lembrete antes da abertura quando a janela do link ou do exame pede
+	if err := h.Service.Repo.RecordLinkInvitation(link.ID, req.Email); err != nil {
+		h.Error(w, 500, "Erro ao registrar convite")
 		return
 	}
-	
-	// Extrair commercialName e companyLogo do profile
-	commercialName := "eSimulate Recruiter"
-	companyLogo := ""
-	if company.Profile != nil {
-		if profileMap, ok := company.Profile.(map[string]interface{}); ok {
-			if name, exists := profileMap["commercialName"]; exists {
-				if nameStr, ok := name.(string); ok && nameStr != "" {
-					commercialName = nameStr
-				}
-			}
-			if logo, exists := profileMap["companyLogo"]; exists {
-				if logoStr, ok := logo.(string); ok {
-					companyLogo = logoStr
-				}
-			}
-		}
-	}
-	
-	// Enviar email de convite
+
+	// Enviar email de convite com o nome comercial e o logo da organização
 	go h.Service.EmailService.SendCompanyInviteEmail(
 		req.Email,
 		"", // candidateName - pode ser extraído se necessário
-		commercialName,
-		companyLogo,
+		org.CommercialName,
+		org.Logo,
 		req.LinkToken,
 	)
 	
@@ -0,0 +1,10 @@
+package http
+
+import "net/http"
+
+// JWKS publica as chaves públicas dos access tokens para verificação por outros serviços (pelo kid)
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	// A próxima chave é publicada com antecedência, então um cache curto não atrasa a rotação
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	h.JSON(w, 200, h.Service.JWTKeys.JWKS())
+}
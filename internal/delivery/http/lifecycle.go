@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+)
+
+// --- Exam Lifecycle ---
+
+// transitionExam aplica a ação do ciclo de vida e responde com o exame atualizado
+func (h *Handler) transitionExam(w http.ResponseWriter, r *http.Request, action string) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	exam, err := h.Service.TransitionExam(r.PathValue("id"), userID, role, action)
+	if err != nil {
+		h.lifecycleError(w, r, action, err)
+		return
+	}
+	h.audit(r, "EXAM_"+strings.ToUpper(action), "exam:"+r.PathValue("id"), nil, map[string]string{"status": exam.Status})
+	h.JSON(w, 200, exam)
+}
+
+// lifecycleError mapeia erros do ciclo de vida para status HTTP
+func (h *Handler) lifecycleError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch err.Error() {
+	case "prova não encontrada":
+		h.Error(w, 404, "Exam not found")
+	case "acesso negado":
+		h.denyExamAccess(w, r, action)
+	case "transição de estado inválida":
+		h.Error(w, 409, err.Error())
+	case "ação inválida":
+		h.Error(w, 400, err.Error())
+	default:
+		h.Error(w, 500, err.Error())
+	}
+}
+
+// PublishExam: draft -> published
+func (h *Handler) PublishExam(w http.ResponseWriter, r *http.Request) {
+	h.transitionExam(w, r, "publish")
+}
+
+// UnpublishExam: published -> draft
+func (h *Handler) UnpublishExam(w http.ResponseWriter, r *http.Request) {
+	h.transitionExam(w, r, "unpublish")
+}
+
+// ArchiveExam: draft|published -> archived (resultados são mantidos)
+func (h *Handler) ArchiveExam(w http.ResponseWriter, r *http.Request) {
+	h.transitionExam(w, r, "archive")
+}
+
+// UnarchiveExam: archived -> published
+func (h *Handler) UnarchiveExam(w http.ResponseWriter, r *http.Request) {
+	h.transitionExam(w, r, "unarchive")
+}
+
+// RestoreExam desfaz a exclusão lógica de um exame
+func (h *Handler) RestoreExam(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	exam, err := h.Service.RestoreExam(r.PathValue("id"), userID, role)
+	if err != nil {
+		h.lifecycleError(w, r, "restore", err)
+		return
+	}
+	h.audit(r, "EXAM_RESTORED", "exam:"+r.PathValue("id"), nil, nil)
+	h.JSON(w, 200, exam)
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"esimulate-backend/internal/domain"
+	"net/http"
+)
+
+// --- Bloqueio de contas (admin) ---
+
+// GetUserLockout retorna as falhas de login e o bloqueio em vigor de um usuário (apenas admin)
+func (h *Handler) GetUserLockout(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "view-lockout") {
+		return
+	}
+	lockout, err := h.Service.GetLoginLockout(r.PathValue("id"))
+	if err != nil {
+		h.accountError(w, err)
+		return
+	}
+	h.JSON(w, 200, lockout)
+}
+
+// UnlockUser remove o bloqueio por tentativas de login de um usuário (apenas admin)
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "unlock-account") {
+		return
+	}
+	before, err := h.Service.GetLoginLockout(r.PathValue("id"))
+	if err == nil {
+		err = h.Service.UnlockAccount(r.PathValue("id"))
+	}
+	if err != nil {
+		h.accountError(w, err)
+		return
+	}
+	h.audit(r, "ACCOUNT_UNLOCKED", "user:"+r.PathValue("id"), before, domain.LoginLockout{})
+	w.WriteHeader(204)
+}
@@ -74,8 +74,11 @@ func HTTPSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// AuthMiddleware com validação explícita de exp e blacklist
-func AuthMiddleware(secret string, blacklist *security.TokenBlacklist) func(http.HandlerFunc) http.HandlerFunc {
+// Tolerância de 5 minutos para clock skew na validação do exp
+const clockSkewTolerance = 5 * time.Minute
+
+// AuthMiddleware com validação explícita de exp e blacklist; a assinatura é verificada pela chave do kid
+func AuthMiddleware(keys *security.JWTKeySet, blacklist security.TokenBlacklist) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -85,43 +88,22 @@ func AuthMiddleware(secret string, blacklist *security.TokenBlacklist) func(http
 			}
 
 			tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-			
-			// Gerar hash do token para blacklist
-			hash := sha256.Sum256([]byte(tokenStr))
-			tokenID := hex.EncodeToString(hash[:])
-			
-			// Verificar blacklist
-			if blacklist != nil && blacklist.IsBlacklisted(tokenID) {
+			claims, blockUntil, err := parseAccessToken(keys, tokenStr)
+			if err != nil {
 				http.Error(w, "Credenciais inválidas", 401)
 				return
 			}
-			
-			claims := jwt.MapClaims{}
-			token, err := jwt.ParseWithClaims(tokenStr, &claims, func(token *jwt.Token) (interface{}, error) {
-				// Validar algoritmo
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
-				}
-				return []byte(secret), nil
-			})
 
-			if err != nil || !token.Valid {
-				http.Error(w, "Credenciais inválidas", 401)
-				return
-			}
-			
-			// Validação explícita de exp
-			exp, ok := claims["exp"].(float64)
-			if !ok {
+			// Verificar blacklist (apenas tokens com assinatura válida chegam ao armazenamento compartilhado)
+			tokenID := accessTokenID(tokenStr)
+			if blacklist != nil && blacklist.IsBlacklisted(tokenID) {
 				http.Error(w, "Credenciais inválidas", 401)
 				return
 			}
-			
-			expTime := time.Unix(int64(exp), 0)
-			now := time.Now()
-			
-			// Tolerância de 5 minutos para clock skew
-			if now.After(expTime.Add(5 * time.Minute)) {
+
+			// Sessão revogada (em /api/me/sessions ou por um admin)
+			sessionID, _ := claims["sid"].(string)
+			if sessionID != "" && blacklist != nil && blacklist.IsBlacklisted(sessionBlacklistKey(sessionID)) {
 				http.Error(w, "Credenciais inválidas", 401)
 				return
 			}
@@ -130,13 +112,49 @@ func AuthMiddleware(secret string, blacklist *security.TokenBlacklist) func(http
 			if role, ok := claims["role"].(string); ok {
 				ctx = context.WithValue(ctx, "role", role)
 			}
-			// Adicionar tokenID ao context para uso no logout
+			// Adicionar tokenID e validade ao context para uso no logout
 			ctx = context.WithValue(ctx, "tokenID", tokenID)
-			
+			ctx = context.WithValue(ctx, "tokenBlockUntil", blockUntil)
+			ctx = context.WithValue(ctx, "sessionID", sessionID)
+
 			next(w, r.WithContext(ctx))
 		}
 	}
 }
 
+// parseAccessToken valida assinatura e exp do access token. Retorna as claims e até quando
+// o token precisa ficar no blacklist se for revogado (exp + tolerância de clock skew).
+func parseAccessToken(keys *security.JWTKeySet, tokenStr string) (jwt.MapClaims, time.Time, error) {
+	claims := jwt.MapClaims{}
+	// Apenas algoritmos assimétricos (o algoritmo também precisa ser o da chave do kid)
+	token, err := jwt.ParseWithClaims(tokenStr, &claims, keys.Keyfunc,
+		jwt.WithValidMethods(security.ValidJWTMethods()), jwt.WithLeeway(clockSkewTolerance))
+	if err != nil || !token.Valid {
+		return nil, time.Time{}, jwt.ErrTokenInvalidClaims
+	}
+
+	// Validação explícita de exp
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, time.Time{}, jwt.ErrTokenInvalidClaims
+	}
+	blockUntil := time.Unix(int64(exp), 0).Add(clockSkewTolerance)
+	if time.Now().After(blockUntil) {
+		return nil, time.Time{}, jwt.ErrTokenExpired
+	}
+	return claims, blockUntil, nil
+}
+
+// accessTokenID é o hash do token usado como chave no blacklist
+func accessTokenID(tokenStr string) string {
+	hash := sha256.Sum256([]byte(tokenStr))
+	return hex.EncodeToString(hash[:])
+}
+
+// sessionBlacklistKey é a chave do blacklist que bloqueia os access tokens de uma sessão revogada
+func sessionBlacklistKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
 // CSRFMiddleware - Removido: SameSite=Strict nos cookies já fornece proteção adequada
 // Se necessário no futuro, pode ser implementado com tokens CSRF
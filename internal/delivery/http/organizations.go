@@ -0,0 +1,206 @@
+package http
+
+import (
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"net/http"
+	"strings"
+)
+
+// --- Organizations ---
+
+// orgError mapeia erros de organização para status HTTP
+func (h *Handler) orgError(w http.ResponseWriter, r *http.Request, action, orgID string, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "acesso negado":
+		h.denyAccess(w, r, action, "org:"+orgID)
+	case msg == "usuário não pertence a uma organização", msg == "convite enviado para outro email":
+		h.Error(w, 403, msg)
+	case msg == "organização não encontrada", msg == "membro não encontrado", msg == "convite não encontrado":
+		h.Error(w, 404, msg)
+	case msg == "usuário já é membro da organização":
+		h.Error(w, 409, msg)
+	case msg == "convite expirado":
+		h.Error(w, 410, msg)
+	case strings.HasPrefix(msg, "papel inválido"), msg == "nome comercial é obrigatório", msg == "email é obrigatório",
+		msg == "a organização precisa de ao menos um owner":
+		h.Error(w, 400, msg)
+	default:
+		h.Error(w, 500, msg)
+	}
+}
+
+// companyOrg resolve a organização das rotas /api/company: header X-Org-ID (ou ?orgId=);
+// sem escolha explícita, usa a primeira organização do usuário
+func (h *Handler) companyOrg(w http.ResponseWriter, r *http.Request, minRole string) (domain.Organization, bool) {
+	orgID := r.Header.Get("X-Org-ID")
+	if orgID == "" {
+		orgID = r.URL.Query().Get("orgId")
+	}
+	org, err := h.Service.ResolveOrg(r.Context().Value("userID").(string), orgID, minRole)
+	if err != nil {
+		h.orgError(w, r, "company:"+minRole, orgID, err)
+		return domain.Organization{}, false
+	}
+	return org, true
+}
+
+// GetMyOrganizations lista as organizações do usuário com o papel dele
+func (h *Handler) GetMyOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.Service.Repo.GetUserOrganizations(r.Context().Value("userID").(string))
+	if err != nil {
+		h.Error(w, 500, err.Error())
+		return
+	}
+	h.JSON(w, 200, orgs)
+}
+
+// CreateOrganization cria uma organização. Body: {"commercialName", "logo", "taxId"}
+func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var req domain.Organization
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	org, err := h.Service.CreateOrganization(r.Context().Value("userID").(string), req)
+	if err != nil {
+		h.orgError(w, r, "create-org", "", err)
+		return
+	}
+	h.audit(r, "ORG_CREATED", "org:"+org.ID, nil, org)
+	h.JSON(w, 201, org)
+}
+
+// GetOrganization retorna o perfil da organização (membros)
+func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	org, err := h.Service.OrgMembership(r.PathValue("id"), r.Context().Value("userID").(string), domain.OrgViewer)
+	if err != nil {
+		h.orgError(w, r, "view-org", r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 200, org)
+}
+
+// UpdateOrganization atualiza o perfil da organização (owner)
+func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	var req domain.Organization
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	before, _ := h.Service.Repo.GetOrganization(r.PathValue("id"))
+	org, err := h.Service.UpdateOrganization(r.PathValue("id"), r.Context().Value("userID").(string), req)
+	if err != nil {
+		h.orgError(w, r, "update-org", r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "ORG_UPDATED", "org:"+org.ID, before, org)
+	h.JSON(w, 200, org)
+}
+
+// GetOrgMembers lista os membros da organização
+func (h *Handler) GetOrgMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := h.Service.GetOrgMembers(r.PathValue("id"), r.Context().Value("userID").(string))
+	if err != nil {
+		h.orgError(w, r, "list-org-members", r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 200, members)
+}
+
+// UpdateOrgMember altera o papel de um membro. Body: {"role": "owner|recruiter|viewer"}
+func (h *Handler) UpdateOrgMember(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	orgID := r.PathValue("id")
+	before, _ := h.Service.Repo.GetOrgMemberRole(orgID, r.PathValue("userId"))
+	if err := h.Service.UpdateOrgMemberRole(orgID, r.Context().Value("userID").(string), r.PathValue("userId"), req.Role); err != nil {
+		h.orgError(w, r, "update-org-member", orgID, err)
+		return
+	}
+	h.audit(r, "ORG_MEMBER_ROLE_CHANGED", "org:"+orgID,
+		map[string]string{"userId": r.PathValue("userId"), "role": before}, map[string]string{"userId": r.PathValue("userId"), "role": req.Role})
+	w.WriteHeader(204)
+}
+
+// RemoveOrgMember remove um membro (ou o próprio usuário sai da organização)
+func (h *Handler) RemoveOrgMember(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("id")
+	before, _ := h.Service.Repo.GetOrgMemberRole(orgID, r.PathValue("userId"))
+	if err := h.Service.RemoveOrgMember(orgID, r.Context().Value("userID").(string), r.PathValue("userId")); err != nil {
+		h.orgError(w, r, "remove-org-member", orgID, err)
+		return
+	}
+	h.audit(r, "ORG_MEMBER_REMOVED", "org:"+orgID, map[string]string{"userId": r.PathValue("userId"), "role": before}, nil)
+	w.WriteHeader(204)
+}
+
+// GetOrgInvitations lista os convites pendentes (owner)
+func (h *Handler) GetOrgInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.Service.GetOrgInvitations(r.PathValue("id"), r.Context().Value("userID").(string))
+	if err != nil {
+		h.orgError(w, r, "list-org-invitations", r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 200, invitations)
+}
+
+// InviteOrgMember envia convite por email. Body: {"email", "role"}
+func (h *Handler) InviteOrgMember(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	orgID := r.PathValue("id")
+	inv, err := h.Service.InviteOrgMember(orgID, r.Context().Value("userID").(string), req.Email, req.Role)
+	if err != nil {
+		h.orgError(w, r, "invite-org-member", orgID, err)
+		return
+	}
+	h.audit(r, "ORG_MEMBER_INVITED", "org:"+orgID, nil, map[string]string{"email": req.Email, "role": req.Role})
+	h.JSON(w, 201, inv)
+}
+
+// CancelOrgInvitation cancela um convite pendente (owner)
+func (h *Handler) CancelOrgInvitation(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("id")
+	if err := h.Service.CancelOrgInvitation(orgID, r.Context().Value("userID").(string), r.PathValue("invitationId")); err != nil {
+		h.orgError(w, r, "cancel-org-invitation", orgID, err)
+		return
+	}
+	h.audit(r, "ORG_INVITATION_CANCELED", "org:"+orgID, map[string]string{"invitationId": r.PathValue("invitationId")}, nil)
+	w.WriteHeader(204)
+}
+
+// AcceptOrgInvitation aceita o convite recebido por email (usuário autenticado com o email convidado)
+func (h *Handler) AcceptOrgInvitation(w http.ResponseWriter, r *http.Request) {
+	org, err := h.Service.AcceptOrgInvitation(r.PathValue("token"), r.Context().Value("userID").(string))
+	if err != nil {
+		h.orgError(w, r, "accept-org-invitation", "", err)
+		return
+	}
+	h.audit(r, "ORG_INVITATION_ACCEPTED", "org:"+org.ID, nil, nil)
+	h.JSON(w, 200, org)
+}
+
+// checkExamOrg valida a criação de um exame da organização (recruiter ou owner)
+func (h *Handler) checkExamOrg(w http.ResponseWriter, r *http.Request, orgID string) bool {
+	if orgID == "" {
+		return true
+	}
+	if _, err := h.Service.OrgMembership(orgID, r.Context().Value("userID").(string), domain.OrgRecruiter); err != nil {
+		h.orgError(w, r, "create-org-exam", orgID, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// --- Exam Ownership ---
+
+// denyAccess registra a tentativa negada no AuditLogger e responde 403
+func (h *Handler) denyAccess(w http.ResponseWriter, r *http.Request, action, resource string) {
+	h.logAccessDenied(r, action, resource)
+	h.Error(w, 403, "Access denied")
+}
+
+// logAccessDenied registra a tentativa negada no AuditLogger sem responder (operações em lote)
+func (h *Handler) logAccessDenied(r *http.Request, action, resource string) {
+	userID, _ := r.Context().Value("userID").(string)
+	h.AuditLogger.LogAccessDenied(userID, getClientIP(r), r.Header.Get("User-Agent"), action, resource)
+}
+
+// denyExamAccess nega uma operação sobre o exame {id} da rota
+func (h *Handler) denyExamAccess(w http.ResponseWriter, r *http.Request, action string) {
+	h.denyAccess(w, r, action, "exam:"+r.PathValue("id"))
+}
+
+// TransferExamOwnership transfere o exame para outro usuário (dono atual ou admin)
+// Body: {"email": "novo.dono@exemplo.com"}
+func (h *Handler) TransferExamOwnership(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	before, _ := h.Service.Exams.GetExamByID(r.PathValue("id"))
+	exam, err := h.Service.TransferExamOwnership(r.PathValue("id"), userID, role, req.Email)
+	if err != nil {
+		switch err.Error() {
+		case "prova não encontrada":
+			h.Error(w, 404, "Exam not found")
+		case "acesso negado":
+			h.denyExamAccess(w, r, "transfer")
+		case "transferência não permitida":
+			h.Error(w, 400, "Não foi possível transferir a prova para este usuário")
+		default:
+			h.Error(w, 500, "Erro ao transferir a prova")
+		}
+		return
+	}
+	h.audit(r, "EXAM_OWNERSHIP_TRANSFERRED", "exam:"+r.PathValue("id"), map[string]string{"createdBy": before.CreatedBy}, map[string]string{"createdBy": exam.CreatedBy})
+	h.JSON(w, 200, exam)
+}
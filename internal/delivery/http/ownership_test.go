@@ -0,0 +1,206 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/security"
+	"esimulate-backend/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeExamRepo guarda exames e papéis em memória e registra as alterações pedidas pelo serviço
+type fakeExamRepo struct {
+	exams   map[string]domain.Exam
+	shares  map[string]string // examID + ":" + userID -> papel
+	users   map[string]domain.User
+	changes []string // Alterações aplicadas ("delete:exam-1", "status:exam-1:archived"...)
+}
+
+func (f *fakeExamRepo) GetExamByID(id string) (domain.Exam, error) {
+	exam, ok := f.exams[id]
+	if !ok {
+		return domain.Exam{}, sql.ErrNoRows
+	}
+	return exam, nil
+}
+
+func (f *fakeExamRepo) GetExamShareRole(examID, userID string) (string, error) {
+	return f.shares[examID+":"+userID], nil
+}
+
+func (f *fakeExamRepo) GetUserByEmail(email string) (domain.User, error) {
+	u, ok := f.users[email]
+	if !ok {
+		return domain.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (f *fakeExamRepo) UpdateExamStatus(id, status string) error {
+	f.changes = append(f.changes, "status:"+id+":"+status)
+	return nil
+}
+
+func (f *fakeExamRepo) SoftDeleteExam(id string) error {
+	f.changes = append(f.changes, "delete:"+id)
+	return nil
+}
+
+func (f *fakeExamRepo) RestoreExam(id string) error {
+	f.changes = append(f.changes, "restore:"+id)
+	return nil
+}
+
+func (f *fakeExamRepo) TransferExamOwnership(id, currentOwner, newOwner string) error {
+	f.changes = append(f.changes, "transfer:"+id+":"+newOwner)
+	return nil
+}
+
+// fakeAuditStore guarda os eventos gravados pelo AuditLogger
+type fakeAuditStore struct {
+	events []domain.AuditEvent
+}
+
+func (f *fakeAuditStore) CreateAuditEvent(e domain.AuditEvent) error {
+	f.events = append(f.events, e)
+	return nil
+}
+
+func (f *fakeAuditStore) actions() []string {
+	actions := []string{}
+	for _, e := range f.events {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+func newOwnershipTestHandler() (*Handler, *fakeExamRepo, *fakeAuditStore) {
+	repo := &fakeExamRepo{
+		exams: map[string]domain.Exam{
+			"exam-1":   {ID: "exam-1", CreatedBy: "creator", Status: domain.ExamPublished},
+			"exam-del": {ID: "exam-del", CreatedBy: "creator", Status: domain.ExamPublished, DeletedAt: 1700000000000},
+		},
+		shares: map[string]string{
+			"exam-1:viewer":     domain.ShareViewer,
+			"exam-1:editor":     domain.ShareEditor,
+			"exam-1:coauthor":   domain.ShareOwner,
+			"exam-del:viewer":   domain.ShareViewer,
+			"exam-del:editor":   domain.ShareEditor,
+			"exam-del:coauthor": domain.ShareOwner,
+		},
+		users: map[string]domain.User{
+			"novo@exemplo.com": {ID: "new-owner", Email: "novo@exemplo.com"},
+		},
+	}
+	store := &fakeAuditStore{}
+	h := &Handler{Service: &service.Service{Exams: repo}, AuditLogger: security.NewAuditLogger(store)}
+	return h, repo, store
+}
+
+// ownershipRoles são os usuários testados em cada operação de gestão (nome, id e papel global)
+var ownershipRoles = []struct {
+	name   string
+	userID string
+	role   string
+}{
+	{name: "criador", userID: "creator", role: "user"},
+	{name: "owner compartilhado", userID: "coauthor", role: "user"},
+	{name: "editor", userID: "editor", role: "user"},
+	{name: "viewer", userID: "viewer", role: "user"},
+	{name: "estranho", userID: "stranger", role: "user"},
+	{name: "estranho specialist", userID: "stranger", role: "specialist"},
+	{name: "admin", userID: "stranger", role: "admin"},
+}
+
+func TestExamManagementOwnership(t *testing.T) {
+	operations := []struct {
+		name       string
+		method     string
+		examID     string
+		body       string
+		handle     func(h *Handler) http.HandlerFunc
+		okStatus   int
+		wantChange string
+		wantAudit  string
+		allowed    map[string]bool
+	}{
+		{
+			name: "excluir", method: "DELETE", examID: "exam-1",
+			handle:   func(h *Handler) http.HandlerFunc { return h.DeleteExam },
+			okStatus: 204, wantChange: "delete:exam-1", wantAudit: "EXAM_DELETED",
+			allowed: map[string]bool{"criador": true, "owner compartilhado": true, "admin": true},
+		},
+		{
+			name: "restaurar", method: "POST", examID: "exam-del",
+			handle:   func(h *Handler) http.HandlerFunc { return h.RestoreExam },
+			okStatus: 200, wantChange: "restore:exam-del", wantAudit: "EXAM_RESTORED",
+			allowed: map[string]bool{"criador": true, "owner compartilhado": true, "admin": true},
+		},
+		{
+			name: "arquivar", method: "POST", examID: "exam-1",
+			handle:   func(h *Handler) http.HandlerFunc { return h.ArchiveExam },
+			okStatus: 200, wantChange: "status:exam-1:" + domain.ExamArchived, wantAudit: "EXAM_ARCHIVE",
+			allowed: map[string]bool{"criador": true, "owner compartilhado": true, "admin": true},
+		},
+		{
+			// Coautores compartilhados não transferem: apenas o dono atual (createdBy) ou admin
+			name: "transferir", method: "POST", examID: "exam-1", body: `{"email": "novo@exemplo.com"}`,
+			handle:   func(h *Handler) http.HandlerFunc { return h.TransferExamOwnership },
+			okStatus: 200, wantChange: "transfer:exam-1:new-owner", wantAudit: "EXAM_OWNERSHIP_TRANSFERRED",
+			allowed: map[string]bool{"criador": true, "admin": true},
+		},
+	}
+	for _, op := range operations {
+		for _, rl := range ownershipRoles {
+			t.Run(op.name+"/"+rl.name, func(t *testing.T) {
+				h, repo, store := newOwnershipTestHandler()
+				r := httptest.NewRequest(op.method, "/api/exams/"+op.examID, strings.NewReader(op.body))
+				r.SetPathValue("id", op.examID)
+				ctx := context.WithValue(r.Context(), "userID", rl.userID)
+				ctx = context.WithValue(ctx, "role", rl.role)
+				w := httptest.NewRecorder()
+				op.handle(h)(w, r.WithContext(ctx))
+
+				wantStatus, wantChanges, wantActions := 403, []string{}, []string{"ACCESS_DENIED"}
+				if op.allowed[rl.name] {
+					wantStatus, wantChanges, wantActions = op.okStatus, []string{op.wantChange}, []string{op.wantAudit}
+				}
+				if w.Code != wantStatus {
+					t.Errorf("status = %d, esperado %d (%s)", w.Code, wantStatus, w.Body.String())
+				}
+				if got := strings.Join(repo.changes, ","); got != strings.Join(wantChanges, ",") {
+					t.Errorf("alterações = %q, esperado %q", got, strings.Join(wantChanges, ","))
+				}
+				if got := strings.Join(store.actions(), ","); got != strings.Join(wantActions, ",") {
+					t.Errorf("auditoria = %q, esperado %q", got, strings.Join(wantActions, ","))
+				}
+				if !op.allowed[rl.name] && len(store.events) > 0 {
+					e := store.events[0]
+					if e.ActorID != rl.userID || e.TargetType != "exam" || e.TargetID != op.examID {
+						t.Errorf("evento = %+v, esperado ator %s no exam:%s", e, rl.userID, op.examID)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestExamManagementNotFound(t *testing.T) {
+	h, repo, store := newOwnershipTestHandler()
+	r := httptest.NewRequest("DELETE", "/api/exams/exam-2", nil)
+	r.SetPathValue("id", "exam-2")
+	ctx := context.WithValue(r.Context(), "userID", "creator")
+	ctx = context.WithValue(ctx, "role", "user")
+	w := httptest.NewRecorder()
+	h.DeleteExam(w, r.WithContext(ctx))
+	if w.Code != 404 {
+		t.Errorf("status = %d, esperado 404", w.Code)
+	}
+	if len(repo.changes) != 0 || len(store.events) != 0 {
+		t.Errorf("alterações = %v, auditoria = %v, esperado nenhuma", repo.changes, store.actions())
+	}
+}
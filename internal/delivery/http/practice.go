@@ -0,0 +1,32 @@
+package http
+
+import (
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"net/http"
+)
+
+// --- Practice (Simulados de Prática) ---
+
+// CreatePracticeExam gera um simulado privado a partir do banco de questões
+func (h *Handler) CreatePracticeExam(w http.ResponseWriter, r *http.Request) {
+	var opts domain.PracticeOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+
+	exam, err := h.Service.GeneratePracticeExam(r.Context().Value("userID").(string), opts)
+	if err != nil {
+		switch err.Error() {
+		case "quantidade de questões inválida", "quantidade de tentativas inválida", "estratégia inválida":
+			h.Error(w, 400, err.Error())
+		case "nenhuma questão disponível para os critérios informados":
+			h.Error(w, 422, err.Error())
+		default:
+			h.Error(w, 500, "Erro ao gerar simulado")
+		}
+		return
+	}
+	h.JSON(w, 201, exam)
+}
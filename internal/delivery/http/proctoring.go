@@ -0,0 +1,173 @@
+package http
+
+import (
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/service"
+	"net/http"
+	"time"
+)
+
+// --- Proctoring (Eventos de Integridade) ---
+
+// proctoringRequest é o lote de eventos enviado pelo frontend durante a tentativa
+type proctoringRequest struct {
+	AttemptID string                   `json:"attemptId"`
+	Events    []domain.ProctoringEvent `json:"events"`
+}
+
+// recordProctoring grava o lote e traduz os erros do service para status HTTP
+func (h *Handler) recordProctoring(w http.ResponseWriter, r *http.Request, ctx service.ProctoringContext) {
+	var req proctoringRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	ctx.AttemptID = req.AttemptID
+	ctx.IP = getClientIP(r)
+	ctx.UserAgent = r.UserAgent()
+
+	recorded, err := h.Service.RecordProctoringEvents(ctx, req.Events)
+	if err != nil {
+		switch err.Error() {
+		case "attemptId inválido", "quantidade de eventos inválida", "tipo de evento inválido":
+			h.Error(w, 400, err.Error())
+		case "tentativa não encontrada":
+			h.Error(w, 404, err.Error())
+		default:
+			h.Error(w, 500, "Erro ao registrar eventos")
+		}
+		return
+	}
+	h.JSON(w, 201, map[string]int{"recorded": recorded})
+}
+
+// StartExamAttempt emite o attemptId de uma nova tentativa do usuário autenticado,
+// exigido nos eventos de proctoring e em POST /api/results.
+// Alunos informam ?assignmentId= para iniciar a tentativa de uma atividade aberta da turma.
+func (h *Handler) StartExamAttempt(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	exam, err := h.Service.Repo.GetExamByID(r.PathValue("id"))
+	if err != nil || exam.DeletedAt > 0 {
+		h.Error(w, 404, "Exam not found")
+		return
+	}
+	if !h.Service.IsExamOpenForLinks(exam) {
+		h.Error(w, 400, "Prova não disponível")
+		return
+	}
+	if assignmentID := r.URL.Query().Get("assignmentId"); assignmentID != "" {
+		if _, err := h.Service.CheckAssignmentAccess(assignmentID, exam, userID); err != nil {
+			if !h.attemptError(w, err) {
+				h.classError(w, r, "start-assignment-attempt", "assignment:"+assignmentID, err)
+			}
+			return
+		}
+	} else if !h.Service.CanViewExam(exam, userID) {
+		h.Error(w, 403, "Access denied")
+		return
+	}
+	if exam.Adaptive != nil && exam.Adaptive.Enabled {
+		h.Error(w, 400, "Exame adaptativo: utilize a sessão adaptativa")
+		return
+	}
+	attempt, err := h.Service.StartExamAttempt(exam.ID, userID, "")
+	if err != nil {
+		h.Error(w, 500, "Erro ao iniciar tentativa")
+		return
+	}
+	h.JSON(w, 201, attempt)
+}
+
+// PublicStartAttempt emite o attemptId de uma nova tentativa via link público,
+// exigido nos eventos de proctoring e na submissão
+func (h *Handler) PublicStartAttempt(w http.ResponseWriter, r *http.Request) {
+	link, err := h.Service.Repo.GetLinkByToken(r.PathValue("token"))
+	if err != nil {
+		h.Error(w, 404, "Invalid link")
+		return
+	}
+	if !link.Active {
+		h.Error(w, 400, "Link inativo")
+		return
+	}
+	if link.ExpiresAt > 0 && time.Now().UnixMilli() > link.ExpiresAt {
+		h.Error(w, 400, "Link expirado")
+		return
+	}
+	exam, err := h.Service.Repo.GetExamByID(link.ExamID)
+	if err != nil {
+		h.Error(w, 404, "Exam not found")
+		return
+	}
+	if !h.Service.IsExamOpenForLinks(exam) {
+		h.Error(w, 400, "Prova não disponível")
+		return
+	}
+	if exam.Adaptive != nil && exam.Adaptive.Enabled {
+		h.Error(w, 400, "Exame adaptativo: utilize a sessão adaptativa")
+		return
+	}
+	if err := h.Service.CheckLinkAvailability(exam, link); err != nil {
+		h.availabilityError(w, err)
+		return
+	}
+	attempt, err := h.Service.StartExamAttempt(exam.ID, "", link.ID)
+	if err != nil {
+		h.Error(w, 500, "Erro ao iniciar tentativa")
+		return
+	}
+	h.JSON(w, 201, attempt)
+}
+
+// RecordExamEvents registra eventos de integridade de uma tentativa do usuário autenticado
+func (h *Handler) RecordExamEvents(w http.ResponseWriter, r *http.Request) {
+	exam, err := h.Service.Repo.GetExamByID(r.PathValue("id"))
+	if err != nil {
+		h.Error(w, 404, "Exam not found")
+		return
+	}
+	h.recordProctoring(w, r, service.ProctoringContext{
+		ExamID: exam.ID,
+		UserID: r.Context().Value("userID").(string),
+	})
+}
+
+// PublicRecordEvents registra eventos de integridade de um candidato via link público
+func (h *Handler) PublicRecordEvents(w http.ResponseWriter, r *http.Request) {
+	link, err := h.Service.Repo.GetLinkByToken(r.PathValue("token"))
+	if err != nil {
+		h.Error(w, 404, "Invalid link")
+		return
+	}
+	if !link.Active {
+		h.Error(w, 400, "Link inativo")
+		return
+	}
+	if link.ExpiresAt > 0 && time.Now().UnixMilli() > link.ExpiresAt {
+		h.Error(w, 400, "Link expirado")
+		return
+	}
+	h.recordProctoring(w, r, service.ProctoringContext{
+		ExamID: link.ExamID,
+		LinkID: link.ID,
+	})
+}
+
+// GetResultEvents lista os eventos de integridade de um resultado da empresa
+func (h *Handler) GetResultEvents(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.companyOrg(w, r, domain.OrgViewer)
+	if !ok {
+		return
+	}
+	events, err := h.Service.GetResultProctoringEvents(org.ID, r.PathValue("id"))
+	if err != nil {
+		if err.Error() == "resultado não encontrado" {
+			h.Error(w, 404, err.Error())
+			return
+		}
+		h.Error(w, 500, "Erro ao buscar eventos")
+		return
+	}
+	h.JSON(w, 200, events)
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"esimulate-backend/internal/logger"
+	"net/http"
+	"strconv"
+)
+
+// --- Review (Caderno de Erros) ---
+
+// GetMyReviewDeck lista todos os cartões do caderno de erros (sem gabarito)
+func (h *Handler) GetMyReviewDeck(w http.ResponseWriter, r *http.Request) {
+	cards, err := h.Service.Repo.GetReviewCards(r.Context().Value("userID").(string))
+	if err != nil { h.Error(w, 500, "Erro ao buscar caderno de erros"); return }
+	for i := range cards {
+		if cards[i].Question != nil {
+			cards[i].Question.CorrectIndex = -1
+			cards[i].Question.Explanation = ""
+		}
+	}
+	h.JSON(w, 200, cards)
+}
+
+// GetNextReview serve os cartões vencidos. Query param opcional: ?limit=10
+func (h *Handler) GetNextReview(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			h.Error(w, 400, "Parâmetro limit inválido")
+			return
+		}
+		limit = n
+	}
+	session, err := h.Service.GetNextReview(r.Context().Value("userID").(string), limit)
+	if err != nil { h.Error(w, 500, "Erro ao buscar revisões"); return }
+	h.JSON(w, 200, session)
+}
+
+// AnswerReview registra a resposta de um cartão e retorna a correção com o novo agendamento
+func (h *Handler) AnswerReview(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SelectedIndex *int `json:"selectedIndex"`
+		Quality       *int `json:"quality,omitempty"` // Autoavaliação SM-2 (0-5), opcional
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SelectedIndex == nil {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+	result, err := h.Service.AnswerReview(userID, r.PathValue("questionId"), *req.SelectedIndex, req.Quality)
+	if err != nil {
+		switch err.Error() {
+		case "cartão não encontrado":
+			h.Error(w, 404, err.Error())
+		case "qualidade deve estar entre 0 e 5":
+			h.Error(w, 400, err.Error())
+		default:
+			h.Error(w, 500, "Erro ao registrar revisão")
+		}
+		return
+	}
+	h.JSON(w, 200, result)
+}
+
+// DeleteReviewCard remove uma questão do caderno de erros
+func (h *Handler) DeleteReviewCard(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	if err := h.Service.Repo.DeleteReviewCard(userID, r.PathValue("questionId")); err != nil {
+		logger.Error("Erro ao remover cartão do caderno de erros: %v", err)
+		h.Error(w, 500, "Erro ao remover cartão do caderno de erros"); return
+	}
+	w.WriteHeader(204)
+}
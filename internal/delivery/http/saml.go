@@ -0,0 +1,165 @@
+package http
+
+import (
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/service"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// --- SAML SSO ---
+
+// samlError mapeia erros da configuração de SSO para status HTTP (demais erros seguem orgError)
+func (h *Handler) samlError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "SSO não configurado":
+		h.Error(w, 404, msg)
+	case msg == "domínio não encontrado":
+		h.Error(w, 404, msg)
+	case strings.HasPrefix(msg, "metadata do IdP inválida"), strings.HasPrefix(msg, "domínio de email inválido"),
+		msg == "informe ao menos um domínio de email", msg == "registro TXT de verificação não encontrado":
+		h.Error(w, 400, msg)
+	case strings.HasPrefix(msg, "domínio já utilizado por outra organização"):
+		h.Error(w, 409, msg)
+	default:
+		h.orgError(w, r, action, r.PathValue("id"), err)
+	}
+}
+
+// GetSAMLConfig retorna a configuração de SSO e os dados do SP para cadastro no IdP (apenas owner)
+func (h *Handler) GetSAMLConfig(w http.ResponseWriter, r *http.Request) {
+	c, err := h.Service.GetSAMLConfig(r.PathValue("id"), r.Context().Value("userID").(string))
+	if err != nil {
+		h.samlError(w, r, "view-saml", err)
+		return
+	}
+	h.JSON(w, 200, c)
+}
+
+// SaveSAMLConfig grava a configuração de SSO (apenas owner).
+// Body: {"enabled": true, "idpMetadata": "<EntityDescriptor ...>", "emailDomains": ["empresa.com"],
+// "attributeMapping": {"email": "...", "name": "...", "role": "..."}, "roleMapping": {"RH": "recruiter"}, "defaultRole": "recruiter"}
+func (h *Handler) SaveSAMLConfig(w http.ResponseWriter, r *http.Request) {
+	var c domain.SAMLConfig
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	var before any
+	if current, err := h.Service.Repo.GetSAMLConfig(r.PathValue("id")); err == nil {
+		before = current
+	}
+	saved, err := h.Service.SaveSAMLConfig(r.PathValue("id"), r.Context().Value("userID").(string), c)
+	if err != nil {
+		h.samlError(w, r, "configure-saml", err)
+		return
+	}
+	h.audit(r, "SAML_CONFIG_UPDATED", "org:"+r.PathValue("id"), before, saved)
+	h.JSON(w, 200, saved)
+}
+
+// DeleteSAMLConfig remove o SSO da organização (apenas owner)
+func (h *Handler) DeleteSAMLConfig(w http.ResponseWriter, r *http.Request) {
+	if err := h.Service.DeleteSAMLConfig(r.PathValue("id"), r.Context().Value("userID").(string)); err != nil {
+		h.samlError(w, r, "configure-saml", err)
+		return
+	}
+	h.audit(r, "SAML_CONFIG_DELETED", "org:"+r.PathValue("id"), nil, nil)
+	w.WriteHeader(204)
+}
+
+// VerifySAMLDomain confirma a posse do domínio {domain} pelo registro DNS TXT indicado em domains[].txtName (apenas owner)
+func (h *Handler) VerifySAMLDomain(w http.ResponseWriter, r *http.Request) {
+	c, err := h.Service.VerifySAMLDomain(r.PathValue("id"), r.Context().Value("userID").(string), r.PathValue("domain"))
+	if err != nil {
+		h.samlError(w, r, "verify-saml-domain", err)
+		return
+	}
+	h.audit(r, "SAML_DOMAIN_VERIFIED", "org:"+r.PathValue("id"), nil, map[string]string{"domain": r.PathValue("domain")})
+	h.JSON(w, 200, c)
+}
+
+// ApproveSAMLDomain aprova manualmente um domínio pendente do SSO da organização (apenas admin)
+func (h *Handler) ApproveSAMLDomain(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "approve-saml-domain") {
+		return
+	}
+	if err := h.Service.ApproveSAMLDomain(r.PathValue("id"), r.PathValue("domain"), r.Context().Value("userID").(string)); err != nil {
+		h.samlError(w, r, "approve-saml-domain", err)
+		return
+	}
+	h.audit(r, "SAML_DOMAIN_APPROVED", "org:"+r.PathValue("id"), nil, map[string]string{"domain": r.PathValue("domain")})
+	w.WriteHeader(204)
+}
+
+// SAMLMetadata publica o XML de metadata do SP da organização
+func (h *Handler) SAMLMetadata(w http.ResponseWriter, r *http.Request) {
+	md, err := h.Service.SAMLMetadata(r.PathValue("orgId"))
+	if err != nil {
+		h.samlError(w, r, "view-saml", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.WriteHeader(200)
+	w.Write(md)
+}
+
+// DiscoverSSO indica se o domínio do email usa SSO. Query: ?email=...
+func (h *Handler) DiscoverSSO(w http.ResponseWriter, r *http.Request) {
+	loginURL, err := h.Service.DiscoverSSO(r.URL.Query().Get("email"))
+	if err != nil {
+		h.Error(w, 500, err.Error())
+		return
+	}
+	h.JSON(w, 200, map[string]interface{}{"sso": loginURL != "", "loginUrl": loginURL})
+}
+
+// StartSAMLLogin redireciona o navegador para o IdP da organização
+func (h *Handler) StartSAMLLogin(w http.ResponseWriter, r *http.Request) {
+	idpURL, err := h.Service.StartSAMLLogin(r.PathValue("orgId"))
+	if err != nil {
+		if err.Error() == "SSO não configurado" {
+			h.Error(w, 404, err.Error())
+			return
+		}
+		h.Error(w, 500, err.Error())
+		return
+	}
+	http.Redirect(w, r, idpURL, http.StatusFound)
+}
+
+// SAMLACS recebe a resposta do IdP (binding HTTP-POST) e redireciona para o frontend
+// (#/oauth/callback?provider=saml) com code, trocado em /api/auth/oauth/exchange, ou error
+func (h *Handler) SAMLACS(w http.ResponseWriter, r *http.Request) {
+	q := url.Values{}
+	q.Set("provider", "saml")
+	if err := r.ParseForm(); err != nil {
+		q.Set("error", "invalid_response")
+		http.Redirect(w, r, service.OAuthFrontendURL(q), http.StatusSeeOther)
+		return
+	}
+
+	result, err := h.Service.HandleSAMLResponse(r.PathValue("orgId"), r.PostForm.Get("SAMLResponse"), r.PostForm.Get("RelayState"))
+	if err != nil {
+		h.AuditLogger.LogLogin("", getClientIP(r), r.UserAgent(), false)
+		switch err.Error() {
+		case "requisição SAML inválida ou expirada", "resposta SAML inválida", "asserção SAML sem email":
+			q.Set("error", "invalid_response")
+		case "SSO não configurado":
+			q.Set("error", "sso_disabled")
+		case "email fora dos domínios da organização":
+			q.Set("error", "domain_not_allowed")
+		case "conta existente não pertence à organização":
+			q.Set("error", "not_a_member")
+		default:
+			q.Set("error", "server_error")
+		}
+	} else {
+		q.Set("code", result.LoginCode)
+	}
+	// 303: o navegador segue com GET após o POST do IdP
+	http.Redirect(w, r, service.OAuthFrontendURL(q), http.StatusSeeOther)
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"net/http"
+	"time"
+)
+
+// --- Sessões ativas ---
+
+// Os access tokens da sessão revogada continuam válidos por até 15 minutos (mais tolerância de clock skew)
+const revokedSessionTTL = 15*time.Minute + clockSkewTolerance
+
+// GetMySessions lista as sessões ativas (dispositivos) do usuário autenticado
+func (h *Handler) GetMySessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	sessionID, _ := r.Context().Value("sessionID").(string)
+	sessions, err := h.Service.GetSessions(userID, sessionID)
+	if err != nil {
+		h.accountError(w, err)
+		return
+	}
+	h.JSON(w, 200, sessions)
+}
+
+// RevokeMySession encerra uma sessão do usuário autenticado
+func (h *Handler) RevokeMySession(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	h.revokeSession(w, r, userID, r.PathValue("id"))
+}
+
+// RevokeAllMySessions encerra todas as sessões do usuário autenticado, inclusive a atual ("sair de todos os dispositivos")
+func (h *Handler) RevokeAllMySessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	if !h.revokeAllSessions(w, r, userID) {
+		return
+	}
+	if tokenID, _ := r.Context().Value("tokenID").(string); tokenID != "" {
+		blockUntil, _ := r.Context().Value("tokenBlockUntil").(time.Time)
+		h.Blacklist.Add(tokenID, blockUntil)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		HttpOnly: true,
+		Path:     "/api/auth/refresh",
+		MaxAge:   0,
+	})
+	w.WriteHeader(204)
+}
+
+// GetUserSessions lista as sessões ativas de qualquer usuário (apenas admin)
+func (h *Handler) GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "view-sessions") {
+		return
+	}
+	sessions, err := h.Service.GetSessions(r.PathValue("id"), "")
+	if err != nil {
+		h.accountError(w, err)
+		return
+	}
+	h.JSON(w, 200, sessions)
+}
+
+// RevokeUserSession encerra uma sessão de qualquer usuário (apenas admin)
+func (h *Handler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "revoke-sessions") {
+		return
+	}
+	h.revokeSession(w, r, r.PathValue("id"), r.PathValue("sessionId"))
+}
+
+// RevokeAllUserSessions encerra todas as sessões de qualquer usuário (apenas admin)
+func (h *Handler) RevokeAllUserSessions(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "revoke-sessions") {
+		return
+	}
+	if h.revokeAllSessions(w, r, r.PathValue("id")) {
+		w.WriteHeader(204)
+	}
+}
+
+// requireAdmin responde 403 (com auditoria) quando o usuário não é admin
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request, action string) bool {
+	if role, _ := r.Context().Value("role").(string); role != "admin" {
+		userID, _ := r.Context().Value("userID").(string)
+		h.AuditLogger.LogAccessDenied(userID, getClientIP(r), r.UserAgent(), action, "user:"+r.PathValue("id"))
+		h.Error(w, 403, "Acesso restrito a administradores")
+		return false
+	}
+	return true
+}
+
+// revokeSession encerra a sessão e bloqueia os access tokens emitidos para ela
+func (h *Handler) revokeSession(w http.ResponseWriter, r *http.Request, userID, sessionID string) {
+	if err := h.Service.RevokeSession(userID, sessionID); err != nil {
+		h.accountError(w, err)
+		return
+	}
+	h.Blacklist.Add(sessionBlacklistKey(sessionID), time.Now().Add(revokedSessionTTL))
+	actorID, _ := r.Context().Value("userID").(string)
+	h.AuditLogger.LogSessionRevoked(actorID, getClientIP(r), r.UserAgent(), userID, sessionID)
+	w.WriteHeader(204)
+}
+
+// revokeAllSessions encerra todas as sessões do usuário e bloqueia os access tokens delas
+func (h *Handler) revokeAllSessions(w http.ResponseWriter, r *http.Request, userID string) bool {
+	sessionIDs, err := h.Service.RevokeAllSessions(userID)
+	if err != nil {
+		h.accountError(w, err)
+		return false
+	}
+	expiresAt := time.Now().Add(revokedSessionTTL)
+	for _, id := range sessionIDs {
+		h.Blacklist.Add(sessionBlacklistKey(id), expiresAt)
+	}
+	actorID, _ := r.Context().Value("userID").(string)
+	h.AuditLogger.LogSessionRevoked(actorID, getClientIP(r), r.UserAgent(), userID, "")
+	return true
+}
+
+// accountError mapeia erros de sessões e bloqueio da conta para status HTTP
+func (h *Handler) accountError(w http.ResponseWriter, err error) {
+	switch msg := err.Error(); msg {
+	case "usuário não encontrado", "sessão não encontrada":
+		h.Error(w, 404, msg)
+	default:
+		h.Error(w, 500, msg)
+	}
+}
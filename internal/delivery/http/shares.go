@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// --- Exam Sharing ---
+
+// shareError mapeia erros de compartilhamento e grupos para status HTTP
+func (h *Handler) shareError(w http.ResponseWriter, r *http.Request, action, resource string, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "acesso negado":
+		h.denyAccess(w, r, action, resource)
+	case msg == "prova não encontrada":
+		h.Error(w, 404, "Exam not found")
+	case msg == "compartilhamento não encontrado", msg == "grupo não encontrado", msg == "membro não encontrado":
+		h.Error(w, 404, msg)
+	case msg == "compartilhamento não permitido":
+		h.Error(w, 400, "Não foi possível compartilhar a prova com este usuário")
+	case msg == "inclusão no grupo não permitida":
+		h.Error(w, 400, "Não foi possível adicionar os usuários informados ao grupo")
+	case strings.HasPrefix(msg, "papel inválido"), msg == "informe email ou groupId",
+		msg == "nome do grupo é obrigatório", msg == "o dono não pode sair do grupo":
+		h.Error(w, 400, msg)
+	default:
+		h.Error(w, 500, msg)
+	}
+}
+
+// GetExamShares lista quem tem acesso ao exame
+func (h *Handler) GetExamShares(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	shares, err := h.Service.GetExamShares(r.PathValue("id"), userID, role)
+	if err != nil {
+		h.shareError(w, r, "list-shares", "exam:"+r.PathValue("id"), err)
+		return
+	}
+	h.JSON(w, 200, shares)
+}
+
+// ShareExam concede acesso ao exame
+// Body: {"email": "..."} ou {"groupId": "..."}, com "role": viewer | editor | owner
+func (h *Handler) ShareExam(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email   string `json:"email"`
+		GroupID string `json:"groupId"`
+		Role    string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	share, err := h.Service.ShareExam(r.PathValue("id"), userID, role, req.Email, req.GroupID, req.Role)
+	if err != nil {
+		h.shareError(w, r, "share", "exam:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "EXAM_SHARED", "exam:"+r.PathValue("id"), nil, share)
+	h.JSON(w, 201, share)
+}
+
+// RevokeExamShare revoga uma concessão de acesso
+func (h *Handler) RevokeExamShare(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.RevokeExamShare(r.PathValue("id"), r.PathValue("shareId"), userID, role); err != nil {
+		h.shareError(w, r, "revoke-share", "exam:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "EXAM_SHARE_REVOKED", "exam:"+r.PathValue("id"), map[string]string{"shareId": r.PathValue("shareId")}, nil)
+	w.WriteHeader(204)
+}
+
+// --- User Groups ---
+
+// GetMyGroups lista os grupos do usuário (como dono ou membro)
+func (h *Handler) GetMyGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.Service.Repo.GetUserGroups(r.Context().Value("userID").(string))
+	if err != nil {
+		h.Error(w, 500, err.Error())
+		return
+	}
+	h.JSON(w, 200, groups)
+}
+
+// CreateGroup cria um grupo. Body: {"name": "...", "emails": ["..."]}
+func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string   `json:"name"`
+		Emails []string `json:"emails"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, 400, "Invalid JSON")
+		return
+	}
+	group, err := h.Service.CreateGroup(r.Context().Value("userID").(string), req.Name, req.Emails)
+	if err != nil {
+		h.shareError(w, r, "create-group", "group", err)
+		return
+	}
+	h.JSON(w, 201, group)
+}
+
+// AddGroupMember inclui um membro. Body: {"email": "..."}
+func (h *Handler) AddGroupMember(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	group, err := h.Service.AddGroupMember(r.PathValue("id"), userID, role, req.Email)
+	if err != nil {
+		h.shareError(w, r, "add-group-member", "group:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "GROUP_MEMBER_ADDED", "group:"+r.PathValue("id"), nil, map[string]string{"email": req.Email})
+	h.JSON(w, 200, group)
+}
+
+// RemoveGroupMember remove um membro (ou o próprio usuário sai do grupo)
+func (h *Handler) RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.RemoveGroupMember(r.PathValue("id"), r.PathValue("userId"), userID, role); err != nil {
+		h.shareError(w, r, "remove-group-member", "group:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "GROUP_MEMBER_REMOVED", "group:"+r.PathValue("id"), map[string]string{"userId": r.PathValue("userId")}, nil)
+	w.WriteHeader(204)
+}
+
+// DeleteGroup remove o grupo
+func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.DeleteGroup(r.PathValue("id"), userID, role); err != nil {
+		h.shareError(w, r, "delete-group", "group:"+r.PathValue("id"), err)
+		return
+	}
+	h.audit(r, "GROUP_DELETED", "group:"+r.PathValue("id"), nil, nil)
+	w.WriteHeader(204)
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"encoding/json"
+	"esimulate-backend/internal/service"
+	"net/http"
+	"net/url"
+)
+
+// --- Social Login (OIDC / OAuth2) ---
+
+// GetOAuthProviders lista os provedores de login social configurados
+func (h *Handler) GetOAuthProviders(w http.ResponseWriter, r *http.Request) {
+	h.JSON(w, 200, map[string][]string{"providers": h.Service.OAuthProviderNames()})
+}
+
+// StartOAuthLogin redireciona o navegador para a tela de autorização do provedor
+func (h *Handler) StartOAuthLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.Service.StartOAuthLogin(r.PathValue("provider"))
+	if err != nil {
+		if err.Error() == "provedor não suportado" {
+			h.Error(w, 404, err.Error())
+			return
+		}
+		h.Error(w, 502, "Provedor indisponível")
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OAuthCallback recebe o retorno do provedor e redireciona para o frontend (#/oauth/callback) com:
+// code (trocado em /api/auth/oauth/exchange), linkToken + email (confirmação de vínculo) ou error.
+func (h *Handler) OAuthCallback(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+	q := url.Values{}
+	q.Set("provider", provider)
+
+	if r.URL.Query().Get("error") != "" {
+		// Usuário negou a autorização (ou o provedor recusou a requisição)
+		q.Set("error", "access_denied")
+		http.Redirect(w, r, service.OAuthFrontendURL(q), http.StatusFound)
+		return
+	}
+
+	result, err := h.Service.HandleOAuthCallback(provider, r.URL.Query().Get("code"), r.URL.Query().Get("state"))
+	switch {
+	case err != nil:
+		h.AuditLogger.LogLogin("", getClientIP(r), r.UserAgent(), false)
+		switch err.Error() {
+		case "provedor não suportado":
+			q.Set("error", "unsupported_provider")
+		case "state inválido ou expirado":
+			q.Set("error", "invalid_state")
+		case "email não verificado pelo provedor":
+			q.Set("error", "email_not_verified")
+		case "falha na autenticação com o provedor":
+			q.Set("error", "provider_error")
+		default:
+			q.Set("error", "server_error")
+		}
+	case result.LinkToken != "":
+		q.Set("linkToken", result.LinkToken)
+		q.Set("email", result.Email)
+	default:
+		q.Set("code", result.LoginCode)
+	}
+	http.Redirect(w, r, service.OAuthFrontendURL(q), http.StatusFound)
+}
+
+// ExchangeOAuthCode troca o código do callback pelos tokens. Body: {"code": "..."}
+// Responde como o login: {user, token} + cookie refresh_token, ou o desafio de 2FA.
+func (h *Handler) ExchangeOAuthCode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	loginResp, refreshToken, err := h.Service.ExchangeOAuthLoginCode(req.Code, clientInfo(r))
+	h.finishSocialLogin(w, r, loginResp, refreshToken, err)
+}
+
+// ConfirmOAuthLink vincula a conta social à conta existente com o mesmo email.
+// Body: {"linkToken": "...", "password": "..."} (senha da conta existente)
+func (h *Handler) ConfirmOAuthLink(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		LinkToken string `json:"linkToken"`
+		Password  string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.LinkToken == "" || req.Password == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	loginResp, refreshToken, err := h.Service.ConfirmOAuthLink(req.LinkToken, req.Password, clientInfo(r))
+	if err == nil {
+		h.AuditLogger.LogChange(loginResp.User.ID, getClientIP(r), r.UserAgent(), "OAUTH_LINKED", "user:"+loginResp.User.ID, nil, nil)
+	}
+	h.finishSocialLogin(w, r, loginResp, refreshToken, err)
+}
+
+// finishSocialLogin responde ao último passo do login social (mesmo contrato de /api/auth/login)
+func (h *Handler) finishSocialLogin(w http.ResponseWriter, r *http.Request, loginResp service.LoginResponse, refreshToken string, err error) {
+	if err != nil {
+		if h.twoFactorChallenge(w, err) {
+			return
+		}
+		h.AuditLogger.LogLogin("", getClientIP(r), r.UserAgent(), false)
+		switch msg := err.Error(); msg {
+		case "código inválido ou expirado", "vínculo inválido ou expirado", "senha incorreta":
+			h.Error(w, 401, msg)
+		case "esta conta já possui outro vínculo com o provedor":
+			h.Error(w, 409, msg)
+		default:
+			h.Error(w, 500, msg)
+		}
+		return
+	}
+	h.AuditLogger.LogLogin(loginResp.User.ID, getClientIP(r), r.UserAgent(), true)
+	setRefreshCookie(w, r, refreshToken)
+	h.JSON(w, 200, loginResp)
+}
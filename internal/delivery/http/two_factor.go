@@ -0,0 +1,207 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/service"
+	"net/http"
+)
+
+// --- Two-Factor Authentication (TOTP) ---
+
+// twoFactorChallenge responde ao primeiro passo do login com o desafio de 2FA (200, sem access token).
+// Retorna false quando err não é um *service.TwoFactorChallenge.
+func (h *Handler) twoFactorChallenge(w http.ResponseWriter, err error) bool {
+	var challenge *service.TwoFactorChallenge
+	if !errors.As(err, &challenge) {
+		return false
+	}
+	h.JSON(w, 200, challenge)
+	return true
+}
+
+// twoFactorError mapeia erros de 2FA para status HTTP
+func (h *Handler) twoFactorError(w http.ResponseWriter, err error) {
+	switch msg := err.Error(); msg {
+	case "desafio inválido ou expirado", "código inválido", "senha incorreta":
+		h.Error(w, 401, msg)
+	case "2FA é obrigatório para este perfil":
+		h.Error(w, 403, msg)
+	case "usuário não encontrado":
+		h.Error(w, 404, msg)
+	case "2FA já está ativo", "2FA não está ativo", "configure o autenticador antes de continuar":
+		h.Error(w, 409, msg)
+	case "papel inválido":
+		h.Error(w, 400, msg)
+	default:
+		h.Error(w, 500, msg)
+	}
+}
+
+// VerifyTwoFactor conclui o login com o código do autenticador ou um código de recuperação.
+// Body: {"challengeToken": "...", "code": "123456"}
+func (h *Handler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeToken string `json:"challengeToken"`
+		Code           string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+
+	ip := getClientIP(r)
+	userAgent := r.UserAgent()
+
+	resp, refreshToken, err := h.Service.CompleteTwoFactorLogin(req.ChallengeToken, req.Code, clientInfo(r))
+	if err != nil {
+		h.AuditLogger.LogLogin("", ip, userAgent, false)
+		h.twoFactorError(w, err)
+		return
+	}
+
+	h.AuditLogger.LogLogin(resp.User.ID, ip, userAgent, true)
+	setRefreshCookie(w, r, refreshToken)
+	h.JSON(w, 200, resp)
+}
+
+// EnrollTwoFactor inicia o cadastro do autenticador durante o login quando o papel exige 2FA.
+// Body: {"challengeToken": "..."}. O código é confirmado em /api/auth/2fa/verify.
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeToken string `json:"challengeToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	setup, err := h.Service.StartTwoFactorEnrollment(req.ChallengeToken)
+	if err != nil {
+		h.twoFactorError(w, err)
+		return
+	}
+	h.JSON(w, 200, setup)
+}
+
+// GetTwoFactorStatus retorna o estado do 2FA do usuário autenticado
+func (h *Handler) GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	role, _ := r.Context().Value("role").(string)
+	status, err := h.Service.GetTwoFactorStatus(r.Context().Value("userID").(string), role)
+	if err != nil {
+		h.twoFactorError(w, err)
+		return
+	}
+	h.JSON(w, 200, status)
+}
+
+// SetupTwoFactor gera o segredo e o URI de provisionamento (QR code)
+func (h *Handler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	setup, err := h.Service.SetupTwoFactor(r.Context().Value("userID").(string))
+	if err != nil {
+		h.twoFactorError(w, err)
+		return
+	}
+	h.JSON(w, 200, setup)
+}
+
+// EnableTwoFactor confirma o cadastro. Body: {"code": "123456"}. Retorna os códigos de recuperação (exibidos uma única vez).
+func (h *Handler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	codes, err := h.Service.EnableTwoFactor(r.Context().Value("userID").(string), req.Code)
+	if err != nil {
+		h.twoFactorError(w, err)
+		return
+	}
+	h.audit(r, "2FA_ENABLED", "user:"+r.Context().Value("userID").(string), nil, nil)
+	h.JSON(w, 200, map[string]interface{}{"enabled": true, "recoveryCodes": codes})
+}
+
+// DisableTwoFactor desativa o 2FA. Body: {"password": "...", "code": "123456"}
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" || req.Code == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	role, _ := r.Context().Value("role").(string)
+	if err := h.Service.DisableTwoFactor(r.Context().Value("userID").(string), role, req.Password, req.Code); err != nil {
+		h.twoFactorError(w, err)
+		return
+	}
+	h.audit(r, "2FA_DISABLED", "user:"+r.Context().Value("userID").(string), nil, nil)
+	h.JSON(w, 200, map[string]bool{"enabled": false})
+}
+
+// RegenerateRecoveryCodes gera novos códigos de recuperação. Body: {"code": "123456"}
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	codes, err := h.Service.RegenerateRecoveryCodes(r.Context().Value("userID").(string), req.Code)
+	if err != nil {
+		h.twoFactorError(w, err)
+		return
+	}
+	h.audit(r, "2FA_RECOVERY_CODES_REGENERATED", "user:"+r.Context().Value("userID").(string), nil, nil)
+	h.JSON(w, 200, map[string]interface{}{"recoveryCodes": codes})
+}
+
+// GetTwoFactorPolicies lista os papéis que exigem 2FA (apenas admin)
+func (h *Handler) GetTwoFactorPolicies(w http.ResponseWriter, r *http.Request) {
+	if role, _ := r.Context().Value("role").(string); role != "admin" {
+		h.Error(w, 403, "Acesso restrito a administradores")
+		return
+	}
+	policies, err := h.Service.Repo.GetTwoFactorPolicies()
+	if err != nil {
+		h.Error(w, 500, err.Error())
+		return
+	}
+	h.JSON(w, 200, policies)
+}
+
+// SetTwoFactorPolicy define se o papel exige 2FA (apenas admin). Body: {"required": true}
+func (h *Handler) SetTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("userID").(string)
+	if role, _ := r.Context().Value("role").(string); role != "admin" {
+		h.AuditLogger.LogAccessDenied(userID, getClientIP(r), r.Header.Get("User-Agent"), "2fa-policy", "role:"+r.PathValue("role"))
+		h.Error(w, 403, "Acesso restrito a administradores")
+		return
+	}
+	var req struct {
+		Required *bool `json:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Required == nil {
+		h.Error(w, 400, "Requisição inválida")
+		return
+	}
+	before := domain.TwoFactorPolicy{Role: r.PathValue("role")}
+	if policies, err := h.Service.Repo.GetTwoFactorPolicies(); err == nil {
+		for _, p := range policies {
+			if p.Role == before.Role {
+				before = p
+			}
+		}
+	}
+	policy, err := h.Service.SetTwoFactorPolicy(r.PathValue("role"), *req.Required, userID)
+	if err != nil {
+		h.twoFactorError(w, err)
+		return
+	}
+	h.audit(r, "2FA_POLICY_UPDATED", "role:"+policy.Role, map[string]bool{"required": before.Required}, map[string]bool{"required": policy.Required})
+	h.JSON(w, 200, policy)
+}
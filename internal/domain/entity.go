@@ -28,18 +28,34 @@ type User struct {
 
 // Exam representa um simulado
 type Exam struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Questions   []Question `json:"questions"`
-	Subjects    []string   `json:"subjects"`
-	TimeLimit   int        `json:"timeLimit,omitempty"` // Tempo limite em minutos (opcional)
-	IsPublic    bool       `json:"isPublic,omitempty"`   // Indica se o exame é público
-	IsVerified  bool       `json:"isVerified,omitempty"` // Indica se o exame foi verificado (admin/specialist podem definir)
-	CreatedBy   string     `json:"createdBy,omitempty"`
-	CreatedAt   int64      `json:"createdAt"`
+	ID            string          `json:"id"`
+	Title         string          `json:"title"`
+	Description   string          `json:"description"`
+	Questions     []Question      `json:"questions"`
+	Subjects      []string        `json:"subjects"`
+	TimeLimit     int             `json:"timeLimit,omitempty"`  // Tempo limite em minutos (opcional)
+	IsPublic      bool            `json:"isPublic,omitempty"`   // Indica se o exame é público
+	IsVerified    bool            `json:"isVerified,omitempty"` // Indica se o exame foi verificado (admin/specialist podem definir)
+	CreatedBy     string          `json:"createdBy,omitempty"`
+	CreatedAt     int64           `json:"createdAt"`
+	Adaptive      *AdaptiveConfig `json:"adaptive,omitempty"`      // Configuração do modo adaptativo (nil = exame tradicional)
+	AttemptPolicy *AttemptPolicy  `json:"attemptPolicy,omitempty"` // Limite de tentativas (nil = ilimitado)
+	IsTemplate    bool            `json:"isTemplate,omitempty"`    // Modelo do catálogo (curado por admins)
+	ClonedFrom    string          `json:"clonedFrom,omitempty"`    // Exame de origem, quando clonado
+	Status        string          `json:"status,omitempty"`        // draft | published | archived
+	DeletedAt     int64           `json:"deletedAt,omitempty"`     // Exclusão lógica (0 = ativo)
+	AccessRole    string          `json:"accessRole,omitempty"`    // Papel do usuário autenticado (owner | editor | viewer)
+	OrgID         string          `json:"orgId,omitempty"`         // Organização dona do exame (definida na criação)
+	Availability  *Availability   `json:"availability,omitempty"`  // Janela de realização via links públicos (nil = sempre)
 }
 
+// Estados do ciclo de vida de um exame
+const (
+	ExamDraft     = "draft"     // Em edição: visível apenas ao autor, sem links públicos
+	ExamPublished = "published" // Disponível conforme isPublic e links
+	ExamArchived  = "archived"  // Encerrado: some das listagens, resultados preservados
+)
+
 // Question representa uma questão
 type Question struct {
 	ID           string   `json:"id"`
@@ -47,10 +63,11 @@ type Question struct {
 	Options      []string `json:"options"`
 	CorrectIndex int      `json:"correctIndex"`          // -1 se for resposta pública sanitizada
 	Explanation  string   `json:"explanation,omitempty"` // Vazio se for resposta pública sanitizada
-	SubjectID    string   `json:"subjectId,omitempty"`    // FK para subjects (UUID)
-	TopicID      string   `json:"topicId,omitempty"`      // FK para topics (UUID)
-	IsPublic     bool     `json:"isPublic,omitempty"`     // Indica se a questão é pública
+	SubjectID    string   `json:"subjectId,omitempty"`   // FK para subjects (UUID)
+	TopicID      string   `json:"topicId,omitempty"`     // FK para topics (UUID)
+	IsPublic     bool     `json:"isPublic,omitempty"`    // Indica se a questão é pública
 	IsVerified   bool     `json:"isVerified,omitempty"`  // Indica se a questão foi verificada por admin/specialist
+	CreatedBy    string   `json:"createdBy,omitempty"`   // Autor da questão (NULL para questões legadas)
 	// Campos legados para compatibilidade (opcional, podem ser removidos depois)
 	Subject string `json:"subject,omitempty"` // @deprecated - usar subjectId
 	Topic   string `json:"topic,omitempty"`   // @deprecated - usar topicId
@@ -58,30 +75,41 @@ type Question struct {
 
 // ExamResult representa o resultado de uma prova
 type ExamResult struct {
-	ID               string `json:"id"`
-	ExamID           string `json:"examId"`
-	UserID           string `json:"userId,omitempty"`
-	CandidateName    string `json:"candidateName,omitempty"`
-	CandidateEmail   string `json:"candidateEmail,omitempty"`
-	Score            int    `json:"score"`
-	TotalQuestions   int    `json:"totalQuestions"`
-	Answers          any    `json:"answers"` // JSONB
-	TimeSpentSeconds int    `json:"timeSpentSeconds"`
-	Date             int64  `json:"date"`
-	ExamTitle        string `json:"examTitle,omitempty"`
+	ID               string            `json:"id"`
+	ExamID           string            `json:"examId"`
+	UserID           string            `json:"userId,omitempty"`
+	CandidateName    string            `json:"candidateName,omitempty"`
+	CandidateEmail   string            `json:"candidateEmail,omitempty"`
+	Score            int               `json:"score"`
+	TotalQuestions   int               `json:"totalQuestions"`
+	Answers          any               `json:"answers"` // JSONB
+	TimeSpentSeconds int               `json:"timeSpentSeconds"`
+	Date             int64             `json:"date"`
+	ExamTitle        string            `json:"examTitle,omitempty"`
+	Ability          *AbilityEstimate  `json:"ability,omitempty"`   // Apenas para resultados de exames adaptativos
+	LinkID           string            `json:"linkId,omitempty"`    // Link público usado na tentativa
+	AttemptID        string            `json:"attemptId,omitempty"` // Identificador da tentativa (eventos de proctoring)
+	SubmitIP         string            `json:"submitIp,omitempty"`
+	UserAgent        string            `json:"userAgent,omitempty"`
+	Integrity        *IntegritySummary `json:"integrity,omitempty"`    // Apenas nas visões da empresa
+	AssignmentID     string            `json:"assignmentId,omitempty"` // Atividade de turma à qual a tentativa foi entregue
 }
 
 // PublicLink é o link gerado por empresas
 type PublicLink struct {
-	ID        string `json:"id"`
-	ExamID    string `json:"examId"`
-	CompanyID string `json:"companyId"`
-	Token     string `json:"token"`
-	Label     string `json:"label"`
-	Active    bool   `json:"active"`
-	ExpiresAt int64  `json:"expiresAt,omitempty"` // Timestamp em milissegundos (0 se não expira)
-	CreatedAt int64  `json:"createdAt"`
-	ExamTitle string `json:"examTitle,omitempty"`
+	ID            string         `json:"id"`
+	ExamID        string         `json:"examId"`
+	CompanyID     string         `json:"companyId"`
+	Token         string         `json:"token"`
+	Label         string         `json:"label"`
+	Active        bool           `json:"active"`
+	ExpiresAt     int64          `json:"expiresAt,omitempty"` // Timestamp em milissegundos (0 se não expira)
+	CreatedAt     int64          `json:"createdAt"`
+	ExamTitle     string         `json:"examTitle,omitempty"`
+	AttemptPolicy *AttemptPolicy `json:"attemptPolicy,omitempty"` // Prevalece sobre a política do exame
+	FlagThreshold int            `json:"flagThreshold,omitempty"` // Eventos de proctoring para sinalizar o candidato (0 = padrão)
+	OrgID         string         `json:"orgId,omitempty"`         // Organização dona do link (CompanyID é o usuário que o criou)
+	Availability  *Availability  `json:"availability,omitempty"`  // Restringe a janela do exame
 }
 
 type Subject struct {
@@ -94,3 +122,541 @@ type Topic struct {
 	SubjectID string `json:"subjectId"`
 	Name      string `json:"name"`
 }
+
+// AnalyticsFilter restringe o período e os exames considerados nas métricas de desempenho
+type AnalyticsFilter struct {
+	From     int64    // Timestamp em milissegundos (0 = sem limite inferior)
+	To       int64    // Timestamp em milissegundos (0 = sem limite superior)
+	ExamIDs  []string // Vazio = todos os exames
+	Interval string   // Agrupamento da evolução: "day", "week" ou "month"
+}
+
+// PerformanceStat agrega as respostas de um usuário em uma matéria ou tópico
+type PerformanceStat struct {
+	SubjectID          string  `json:"subjectId,omitempty"`
+	SubjectName        string  `json:"subjectName,omitempty"`
+	TopicID            string  `json:"topicId,omitempty"`
+	TopicName          string  `json:"topicName,omitempty"`
+	Answered           int     `json:"answered"`
+	Correct            int     `json:"correct"`
+	Accuracy           float64 `json:"accuracy"`           // Percentual de acertos (0-100)
+	AvgTimePerQuestion float64 `json:"avgTimePerQuestion"` // Em segundos
+}
+
+// TrendPoint representa o desempenho em um período da evolução temporal
+type TrendPoint struct {
+	Period   int64   `json:"period"` // Início do período em milissegundos
+	Answered int     `json:"answered"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// UserAnalytics é a resposta de GET /api/me/analytics
+type UserAnalytics struct {
+	Overall       PerformanceStat   `json:"overall"`
+	Subjects      []PerformanceStat `json:"subjects"`
+	Topics        []PerformanceStat `json:"topics"`
+	Trend         []TrendPoint      `json:"trend"`
+	WeakestTopics []PerformanceStat `json:"weakestTopics"`
+}
+
+// ReviewCard é uma questão do caderno de erros com agendamento de revisão espaçada (SM-2)
+type ReviewCard struct {
+	QuestionID     string    `json:"questionId"`
+	Question       *Question `json:"question,omitempty"`
+	EaseFactor     float64   `json:"easeFactor"`
+	IntervalDays   int       `json:"intervalDays"`
+	Repetitions    int       `json:"repetitions"`
+	Lapses         int       `json:"lapses"`
+	DueAt          int64     `json:"dueAt"`
+	LastReviewedAt int64     `json:"lastReviewedAt,omitempty"`
+	CreatedAt      int64     `json:"createdAt"`
+}
+
+// Estratégias de sorteio do gerador de simulados de prática
+const (
+	PracticeRandom  = "random"  // Sorteio aleatório
+	PracticeWeakest = "weakest" // Prioriza tópicos com menor percentual de acerto
+	PracticeUnseen  = "unseen"  // Apenas questões nunca respondidas
+	PracticeWrong   = "wrong"   // Apenas questões já erradas
+)
+
+// PracticeOptions define os critérios de sorteio de POST /api/me/practice
+type PracticeOptions struct {
+	Title      string   `json:"title,omitempty"`
+	SubjectIDs []string `json:"subjectIds,omitempty"`
+	TopicIDs   []string `json:"topicIds,omitempty"`
+	Count      int      `json:"count"`
+	Strategy   string   `json:"strategy,omitempty"`
+	AvoidLastN int      `json:"avoidLastAttempts,omitempty"` // Não repetir questões das últimas N tentativas
+	TimeLimit  int      `json:"timeLimit,omitempty"`
+}
+
+// Modelos de TRI suportados na calibração
+const (
+	IRTModel1PL = "1PL" // Rasch: apenas dificuldade
+	IRTModel2PL = "2PL" // Dificuldade e discriminação
+)
+
+// AdaptiveConfig habilita o modo adaptativo em um exame
+type AdaptiveConfig struct {
+	Enabled     bool    `json:"enabled"`
+	MaxItems    int     `json:"maxItems,omitempty"`    // Máximo de questões aplicadas (padrão 20)
+	MinItems    int     `json:"minItems,omitempty"`    // Mínimo antes de avaliar o critério de parada (padrão 5)
+	SEThreshold float64 `json:"seThreshold,omitempty"` // Para quando o erro padrão ficar abaixo deste valor (padrão 0.3)
+}
+
+// ItemParams são os parâmetros de TRI calibrados de uma questão
+type ItemParams struct {
+	QuestionID     string  `json:"questionId"`
+	Model          string  `json:"model"`
+	Discrimination float64 `json:"discrimination"` // a
+	Difficulty     float64 `json:"difficulty"`     // b
+	Responses      int     `json:"responses"`
+	CalibratedAt   int64   `json:"calibratedAt,omitempty"`
+}
+
+// ItemResponse é uma resposta dicotômica usada na calibração
+type ItemResponse struct {
+	PersonID   string // result_id (cada tentativa é tratada como um respondente)
+	QuestionID string
+	Correct    bool
+}
+
+// AbilityEstimate é a habilidade estimada (escala theta) com intervalo de confiança de 95%
+type AbilityEstimate struct {
+	Theta   float64 `json:"theta"`
+	SE      float64 `json:"se"`
+	CILower float64 `json:"ciLower"`
+	CIUpper float64 `json:"ciUpper"`
+	Items   int     `json:"items"` // Questões aplicadas
+}
+
+// AdaptiveAnswer é uma resposta registrada em uma sessão adaptativa
+type AdaptiveAnswer struct {
+	QuestionID    string `json:"questionId"`
+	SelectedIndex int    `json:"selectedIndex"`
+	IsCorrect     bool   `json:"isCorrect"`
+}
+
+// AdaptiveSession é uma aplicação de exame adaptativo
+type AdaptiveSession struct {
+	ID                string           `json:"id"`
+	ExamID            string           `json:"examId"`
+	UserID            string           `json:"userId,omitempty"`
+	LinkID            string           `json:"linkId,omitempty"`
+	CandidateName     string           `json:"candidateName,omitempty"`
+	CandidateEmail    string           `json:"candidateEmail,omitempty"`
+	Theta             float64          `json:"theta"`
+	SE                float64          `json:"se"`
+	Responses         []AdaptiveAnswer `json:"responses"`
+	CurrentQuestionID string           `json:"currentQuestionId,omitempty"`
+	Status            string           `json:"status"` // "active" | "finished"
+	ResultID          string           `json:"resultId,omitempty"`
+	CreatedAt         int64            `json:"createdAt"`
+	FinishedAt        int64            `json:"finishedAt,omitempty"`
+}
+
+// Qual tentativa conta como resultado oficial do candidato
+const (
+	ScoringFirst = "first"
+	ScoringBest  = "best"
+	ScoringLast  = "last"
+)
+
+// Availability define a janela em que o exame pode ser realizado. Os instantes são absolutos (ms);
+// o fuso horário é usado para exibir a janela ao candidato e nos lembretes.
+type Availability struct {
+	AvailableFrom   int64  `json:"availableFrom,omitempty"`   // 0 = sem horário de abertura
+	AvailableUntil  int64  `json:"availableUntil,omitempty"`  // 0 = sem horário de encerramento
+	Timezone        string `json:"timezone,omitempty"`        // IANA, ex.: America/Sao_Paulo
+	ReminderMinutes int    `json:"reminderMinutes,omitempty"` // Lembrete aos convidados antes da abertura (0 = sem lembrete)
+}
+
+// LinkReminder é um convite de link com janela agendada, pendente de lembrete
+type LinkReminder struct {
+	InvitationID     string
+	Email            string
+	LinkToken        string
+	ExamTitle        string
+	OrgName          string
+	OrgLogo          string
+	LinkAvailability *Availability
+	ExamAvailability *Availability
+}
+
+// AttemptPolicy limita as tentativas por candidato (email) ou usuário
+type AttemptPolicy struct {
+	MaxAttempts     int    `json:"maxAttempts,omitempty"`     // 0 = ilimitado
+	CooldownMinutes int    `json:"cooldownMinutes,omitempty"` // Intervalo mínimo entre tentativas
+	ScoringMode     string `json:"scoringMode,omitempty"`     // first | best | last (padrão: best)
+}
+
+// AttemptScope identifica as tentativas contadas por uma política: as da atividade, as do link ou todas as do exame,
+// do usuário autenticado (UserID) ou do candidato do link (Email)
+type AttemptScope struct {
+	ExamID       string
+	LinkID       string
+	AssignmentID string
+	UserID       string
+	Email        string
+}
+
+// CandidateAttempts agrupa as tentativas de um candidato em um exame
+type CandidateAttempts struct {
+	ExamID          string       `json:"examId"`
+	ExamTitle       string       `json:"examTitle,omitempty"`
+	CandidateName   string       `json:"candidateName,omitempty"`
+	CandidateEmail  string       `json:"candidateEmail"`
+	ScoringMode     string       `json:"scoringMode"`
+	CountedResultID string       `json:"countedResultId"`
+	CountedScore    int          `json:"countedScore"`
+	TotalQuestions  int          `json:"totalQuestions"`
+	Attempts        []ExamResult `json:"attempts"` // Ordem cronológica
+}
+
+// Tipos de eventos de integridade (proctoring) enviados durante uma tentativa
+const (
+	ProctorTabHidden      = "tab_hidden"
+	ProctorWindowBlur     = "window_blur"
+	ProctorCopy           = "copy"
+	ProctorPaste          = "paste"
+	ProctorFullscreenExit = "fullscreen_exit"
+	ProctorIPChange       = "ip_change" // Gerado pelo backend ao detectar IP diferente na mesma tentativa
+)
+
+// ExamAttempt é uma tentativa iniciada pelo backend (POST /api/exams/{id}/attempts ou pelo link público).
+// O ID é exigido nos eventos de proctoring e na submissão.
+type ExamAttempt struct {
+	ID          string `json:"attemptId"`
+	ExamID      string `json:"examId"`
+	UserID      string `json:"-"`
+	LinkID      string `json:"-"`
+	StartedAt   int64  `json:"startedAt"`
+	SubmittedAt int64  `json:"-"`
+}
+
+// ProctoringEvent é um evento de integridade registrado durante uma tentativa
+type ProctoringEvent struct {
+	ID         string                 `json:"id"`
+	AttemptID  string                 `json:"attemptId"`
+	ExamID     string                 `json:"examId"`
+	LinkID     string                 `json:"linkId,omitempty"`
+	UserID     string                 `json:"userId,omitempty"`
+	ResultID   string                 `json:"resultId,omitempty"` // Preenchido quando a tentativa é submetida
+	Type       string                 `json:"type"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+	IP         string                 `json:"ip,omitempty"`
+	UserAgent  string                 `json:"userAgent,omitempty"`
+	OccurredAt int64                  `json:"occurredAt"` // Horário informado pelo cliente (ms)
+	ReceivedAt int64                  `json:"receivedAt"`
+}
+
+// IntegritySummary resume os eventos de proctoring de uma tentativa
+type IntegritySummary struct {
+	TotalEvents int            `json:"totalEvents"`
+	Counts      map[string]int `json:"counts"` // Por tipo de evento
+	Threshold   int            `json:"threshold"`
+	Flagged     bool           `json:"flagged"` // totalEvents >= threshold
+}
+
+// Certificate é o certificado de conclusão emitido para um resultado
+type Certificate struct {
+	ID             string `json:"-"`
+	Code           string `json:"code"`
+	ResultID       string `json:"-"`
+	CandidateName  string `json:"candidateName"`
+	ExamTitle      string `json:"examTitle"`
+	Score          int    `json:"score"`
+	TotalQuestions int    `json:"totalQuestions"`
+	CompletedAt    int64  `json:"completedAt"`
+	IssuerID       string `json:"-"`
+	IssuerName     string `json:"issuerName"`
+	IssuedAt       int64  `json:"issuedAt"`
+}
+
+// CloneOptions são as opções de POST /api/exams/{id}/clone
+type CloneOptions struct {
+	Title    string `json:"title"`    // Padrão: "<título original> (cópia)"
+	DeepCopy bool   `json:"deepCopy"` // true: duplica as questões; false: compartilha via exam_questions
+}
+
+// Papéis de acesso a um exame compartilhado (em ordem crescente de permissão)
+const (
+	ShareViewer = "viewer" // Visualiza e clona
+	ShareEditor = "editor" // Edita o conteúdo
+	ShareOwner  = "owner"  // Coautor: também exclui, muda o estado e gerencia o compartilhamento
+)
+
+// ExamShare é uma concessão de acesso a um exame para um usuário ou grupo
+type ExamShare struct {
+	ID        string `json:"id"`
+	ExamID    string `json:"examId"`
+	UserID    string `json:"userId,omitempty"`
+	UserName  string `json:"userName,omitempty"`
+	UserEmail string `json:"userEmail,omitempty"`
+	GroupID   string `json:"groupId,omitempty"`
+	GroupName string `json:"groupName,omitempty"`
+	Role      string `json:"role"`
+	GrantedBy string `json:"grantedBy,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// UserGroup é um grupo de usuários usado no compartilhamento de exames
+type UserGroup struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	OwnerID   string        `json:"ownerId"`
+	Members   []GroupMember `json:"members"`
+	CreatedAt int64         `json:"createdAt"`
+}
+
+// GroupMember é um membro de um grupo
+type GroupMember struct {
+	UserID  string `json:"userId"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	AddedAt int64  `json:"addedAt"`
+}
+
+// Papéis de um membro na organização
+const (
+	OrgOwner     = "owner"     // Gerencia perfil, membros e convites
+	OrgRecruiter = "recruiter" // Cria exames, links e convites para candidatos
+	OrgViewer    = "viewer"    // Consulta links e resultados
+)
+
+// Organization é uma conta empresarial com vários usuários
+type Organization struct {
+	ID             string `json:"id"`
+	CommercialName string `json:"commercialName"`
+	Logo           string `json:"logo,omitempty"`
+	TaxID          string `json:"taxId,omitempty"`
+	CreatedBy      string `json:"createdBy,omitempty"`
+	CreatedAt      int64  `json:"createdAt"`
+	UpdatedAt      int64  `json:"updatedAt,omitempty"`
+	Role           string `json:"role,omitempty"` // Papel do usuário autenticado na organização
+}
+
+// OrgMember é um membro da organização
+type OrgMember struct {
+	UserID   string `json:"userId"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	JoinedAt int64  `json:"joinedAt"`
+}
+
+// OrgInvitation é um convite para ingressar na organização
+type OrgInvitation struct {
+	ID         string `json:"id"`
+	OrgID      string `json:"orgId"`
+	OrgName    string `json:"orgName,omitempty"`
+	Email      string `json:"email"`
+	Role       string `json:"role"`
+	Token      string `json:"-"`
+	InvitedBy  string `json:"invitedBy,omitempty"`
+	CreatedAt  int64  `json:"createdAt"`
+	ExpiresAt  int64  `json:"expiresAt"`
+	AcceptedAt int64  `json:"acceptedAt,omitempty"`
+}
+
+// Papéis do usuário em uma turma
+const (
+	ClassTeacher = "teacher"
+	ClassStudent = "student"
+)
+
+// Situação de uma atividade para o aluno
+const (
+	AssignmentUpcoming  = "upcoming"  // Ainda não abriu
+	AssignmentPending   = "pending"   // Aberta e sem entrega
+	AssignmentSubmitted = "submitted" // Ao menos uma tentativa entregue
+	AssignmentMissed    = "missed"    // Encerrada sem entrega
+)
+
+// Classroom é uma turma; o criador é o professor
+type Classroom struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	TeacherID    string `json:"teacherId"`
+	TeacherName  string `json:"teacherName,omitempty"`
+	InviteCode   string `json:"inviteCode,omitempty"` // Apenas para o professor
+	StudentCount int    `json:"studentCount"`
+	Role         string `json:"role,omitempty"` // Papel do usuário autenticado: teacher | student
+	CreatedAt    int64  `json:"createdAt"`
+}
+
+// ClassMember é um aluno matriculado na turma
+type ClassMember struct {
+	UserID   string `json:"userId"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	JoinedAt int64  `json:"joinedAt"`
+}
+
+// Assignment é um exame atribuído a uma turma
+type Assignment struct {
+	ID             string         `json:"id"`
+	ClassID        string         `json:"classId"`
+	ClassName      string         `json:"className,omitempty"`
+	ExamID         string         `json:"examId"`
+	ExamTitle      string         `json:"examTitle,omitempty"`
+	Title          string         `json:"title,omitempty"`
+	OpensAt        int64          `json:"opensAt"`
+	ClosesAt       int64          `json:"closesAt,omitempty"`      // 0 = sem prazo
+	AttemptPolicy  *AttemptPolicy `json:"attemptPolicy,omitempty"` // Prevalece sobre a política do exame
+	ExamPolicy     *AttemptPolicy `json:"-"`                       // Política do exame (quando a atividade não define a sua)
+	CreatedBy      string         `json:"createdBy,omitempty"`
+	CreatedAt      int64          `json:"createdAt"`
+	Status         string         `json:"status,omitempty"`   // Apenas na visão do aluno
+	Attempts       int            `json:"attempts,omitempty"` // Tentativas do aluno (visão do aluno)
+	Score          *int           `json:"score,omitempty"`    // Nota que conta (visão do aluno, conforme scoringMode)
+	TotalQuestions int            `json:"totalQuestions,omitempty"`
+}
+
+// AssignmentGrade é a situação de um aluno em uma atividade
+type AssignmentGrade struct {
+	AssignmentID   string `json:"assignmentId"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	Score          *int   `json:"score,omitempty"` // Nota da tentativa que conta (conforme scoringMode)
+	TotalQuestions int    `json:"totalQuestions,omitempty"`
+	ResultID       string `json:"resultId,omitempty"`
+	SubmittedAt    int64  `json:"submittedAt,omitempty"`
+}
+
+// GradebookRow reúne as notas de um aluno em todas as atividades da turma
+type GradebookRow struct {
+	Student ClassMember       `json:"student"`
+	Grades  []AssignmentGrade `json:"grades"` // Na mesma ordem de Gradebook.Assignments
+}
+
+// Gradebook é o quadro de notas da turma
+type Gradebook struct {
+	Class       Classroom      `json:"class"`
+	Assignments []Assignment   `json:"assignments"`
+	Rows        []GradebookRow `json:"rows"`
+}
+
+// TwoFactorStatus resume a configuração de 2FA do usuário
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"` // Exigido pelo papel do usuário
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+// TwoFactorPolicy define se o 2FA é obrigatório para um papel
+type TwoFactorPolicy struct {
+	Role      string `json:"role"`
+	Required  bool   `json:"required"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	UpdatedAt int64  `json:"updatedAt,omitempty"`
+}
+
+// OAuthLinkRequest é um vínculo pendente entre uma identidade social e uma conta com o mesmo email
+type OAuthLinkRequest struct {
+	Token     string `json:"-"`
+	UserID    string `json:"userId"`
+	Provider  string `json:"provider"`
+	Subject   string `json:"-"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// SAMLAttributeMapping indica os atributos da asserção usados para email, nome e papel (Name ou FriendlyName)
+type SAMLAttributeMapping struct {
+	Email string `json:"email,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Role  string `json:"role,omitempty"`
+}
+
+// SAMLConfig é a configuração de SSO SAML 2.0 de uma organização
+type SAMLConfig struct {
+	OrgID            string               `json:"orgId"`
+	Enabled          bool                 `json:"enabled"`
+	IdPMetadata      string               `json:"idpMetadata"` // XML de metadata do IdP
+	IdPEntityID      string               `json:"idpEntityId"`
+	EmailDomains     []string             `json:"emailDomains"`
+	Domains          []SAMLDomain         `json:"domains,omitempty"` // Situação da verificação de cada domínio (calculado)
+	AttributeMapping SAMLAttributeMapping `json:"attributeMapping"`
+	RoleMapping      map[string]string    `json:"roleMapping,omitempty"` // Valor do atributo de papel -> recruiter | viewer
+	DefaultRole      string               `json:"defaultRole"`           // Papel dos usuários provisionados sem mapeamento
+	UpdatedBy        string               `json:"updatedBy,omitempty"`
+	UpdatedAt        int64                `json:"updatedAt,omitempty"`
+	// Dados do Service Provider para cadastro no IdP (calculados)
+	SPEntityID  string `json:"spEntityId,omitempty"`
+	ACSURL      string `json:"acsUrl,omitempty"`
+	MetadataURL string `json:"metadataUrl,omitempty"`
+	LoginURL    string `json:"loginUrl,omitempty"`
+}
+
+// SAMLDomain é um domínio de email do SSO. Só domínios verificados (registro DNS TXT ou aprovação
+// do admin) são usados na descoberta, no login e no provisionamento de usuários.
+type SAMLDomain struct {
+	Domain     string `json:"domain"`
+	Verified   bool   `json:"verified"`
+	VerifiedAt int64  `json:"verifiedAt,omitempty"`
+	TXTName    string `json:"txtName,omitempty"`  // Registro DNS TXT que comprova a posse (pendentes)
+	TXTValue   string `json:"txtValue,omitempty"` // Valor esperado no registro
+	Token      string `json:"-"`
+}
+
+// Session é um login ativo do usuário (dispositivo com refresh token válido)
+type Session struct {
+	ID         string `json:"id"`
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"userAgent,omitempty"`
+	CreatedAt  int64  `json:"createdAt"`
+	LastUsedAt int64  `json:"lastUsedAt"`
+	ExpiresAt  int64  `json:"expiresAt"`         // Validade do refresh token atual
+	Current    bool   `json:"current,omitempty"` // Sessão da requisição
+}
+
+// JWTSigningKey é uma chave de assinatura dos access tokens gravada no banco (compartilhada entre réplicas)
+type JWTSigningKey struct {
+	ID          string // kid
+	Algorithm   string // RS256 | EdDSA
+	PrivateKey  string // PEM (PKCS#8); no banco, cifrada com AES-GCM ("v1:...")
+	ActivatesAt int64  // Passa a assinar (chaves novas são publicadas no JWKS antes disso)
+}
+
+// LoginLockout é a situação de bloqueio de login de uma conta
+type LoginLockout struct {
+	FailedAttempts int   `json:"failedAttempts"` // Falhas desde o último login ou bloqueio
+	LastFailedAt   int64 `json:"lastFailedAt,omitempty"`
+	LockedUntil    int64 `json:"lockedUntil,omitempty"` // Bloqueio temporário em vigor
+}
+
+// AuditEvent é um registro da trilha de auditoria de segurança
+type AuditEvent struct {
+	ID         int64                  `json:"id"`
+	Action     string                 `json:"action"`            // Ex.: USER_DELETED, ORG_MEMBER_UPDATED, LOGIN_FAILED
+	ActorID    string                 `json:"actorId,omitempty"` // Usuário que executou (vazio: anônimo)
+	TargetType string                 `json:"targetType,omitempty"`
+	TargetID   string                 `json:"targetId,omitempty"`
+	IP         string                 `json:"ip,omitempty"`
+	UserAgent  string                 `json:"userAgent,omitempty"`
+	Details    string                 `json:"details,omitempty"`
+	Changes    map[string]AuditChange `json:"changes,omitempty"` // Campos alterados (valores sensíveis mascarados)
+	CreatedAt  int64                  `json:"createdAt"`
+}
+
+// AuditChange é o valor de um campo antes e depois da alteração
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditFilter filtra a consulta da trilha de auditoria (campos vazios não filtram)
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       int64 // createdAt mínimo
+	To         int64 // createdAt máximo
+	Cursor     int64 // Eventos com ID menor (paginação)
+	Limit      int
+}
@@ -44,3 +44,19 @@ type LinkRepository interface {
 	GetByCompanyID(companyID string) ([]PublicLink, error)
 	GetByToken(token string) (PublicLink, error)
 }
+
+type AnalyticsRepository interface {
+	GetUserOverallStats(userID string, f AnalyticsFilter) (PerformanceStat, error)
+	GetUserSubjectStats(userID string, f AnalyticsFilter) ([]PerformanceStat, error)
+	GetUserTopicStats(userID string, f AnalyticsFilter) ([]PerformanceStat, error)
+	GetUserTrend(userID string, f AnalyticsFilter) ([]TrendPoint, error)
+}
+
+type ReviewRepository interface {
+	GetReviewCards(userID string) ([]ReviewCard, error)
+	GetDueReviewCards(userID string, limit int) ([]ReviewCard, error)
+	CountDueReviewCards(userID string) (int, error)
+	GetReviewCard(userID, questionID string) (ReviewCard, error)
+	UpdateReviewCard(userID string, c ReviewCard) error
+	DeleteReviewCard(userID, questionID string) error
+}
@@ -0,0 +1,329 @@
+// Package pdf gera documentos PDF simples (texto, linhas, formas e imagens)
+// usando apenas a biblioteca padrão e as fontes padrão Helvetica/Helvetica-Bold.
+// Coordenadas são em pontos, com origem no canto superior esquerdo da página.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Decoders registrados para ImageFromDataURL
+	_ "image/png"
+	"strings"
+)
+
+// Tamanhos de página A4 em pontos
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Document é um PDF em construção
+type Document struct {
+	Width, Height float64
+	pages         []*bytes.Buffer
+	current       int      // Índice da página em que as operações desenham
+	images        [][]byte // Objetos XObject já serializados
+	bold          bool
+	size          float64
+}
+
+// New cria um documento com o tamanho de página informado (use A4Height, A4Width para paisagem)
+func New(width, height float64) *Document {
+	return &Document{Width: width, Height: height, size: 12}
+}
+
+// AddPage inicia uma nova página; as operações seguintes desenham nela
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.current = len(d.pages) - 1
+}
+
+// SetPage volta a desenhar na página n (1..PageCount), por exemplo para numeração "X de Y"
+func (d *Document) SetPage(n int) {
+	if n >= 1 && n <= len(d.pages) {
+		d.current = n - 1
+	}
+}
+
+// PageCount retorna o número de páginas
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[d.current]
+}
+
+// SetFont define a fonte (Helvetica ou Helvetica-Bold) e o tamanho em pontos
+func (d *Document) SetFont(bold bool, size float64) {
+	d.bold = bold
+	d.size = size
+}
+
+// FontSize retorna o tamanho de fonte atual
+func (d *Document) FontSize() float64 {
+	return d.size
+}
+
+// SetFillColor define a cor de preenchimento (também usada no texto)
+func (d *Document) SetFillColor(r, g, b uint8) {
+	fmt.Fprintf(d.page(), "%.3f %.3f %.3f rg\n", float64(r)/255, float64(g)/255, float64(b)/255)
+}
+
+// SetStrokeColor define a cor de linhas e contornos
+func (d *Document) SetStrokeColor(r, g, b uint8) {
+	fmt.Fprintf(d.page(), "%.3f %.3f %.3f RG\n", float64(r)/255, float64(g)/255, float64(b)/255)
+}
+
+// SetLineWidth define a espessura das linhas
+func (d *Document) SetLineWidth(w float64) {
+	fmt.Fprintf(d.page(), "%.2f w\n", w)
+}
+
+// StringWidth retorna a largura do texto com a fonte atual
+func (d *Document) StringWidth(s string) float64 {
+	total := 0
+	for _, r := range s {
+		total += runeWidth(r, d.bold)
+	}
+	return float64(total) * d.size / 1000
+}
+
+// Text escreve o texto com a linha de base em (x, y)
+func (d *Document) Text(x, y float64, s string) {
+	font := "F1"
+	if d.bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, d.size, x, d.Height-y, escape(encodeWinAnsi(s)))
+}
+
+// TextCentered escreve o texto centralizado horizontalmente na página
+func (d *Document) TextCentered(y float64, s string) {
+	d.Text((d.Width-d.StringWidth(s))/2, y, s)
+}
+
+// TextRight escreve o texto alinhado à direita em x
+func (d *Document) TextRight(x, y float64, s string) {
+	d.Text(x-d.StringWidth(s), y, s)
+}
+
+// WrapText quebra o texto em linhas que cabem em maxWidth com a fonte atual.
+// Quebras de linha explícitas são preservadas; palavras maiores que a linha são partidas.
+func (d *Document) WrapText(s string, maxWidth float64) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		current := ""
+		for _, word := range words {
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+			if d.StringWidth(candidate) <= maxWidth {
+				current = candidate
+				continue
+			}
+			if current != "" {
+				lines = append(lines, current)
+			}
+			// Palavra sozinha maior que a linha: parte por caractere
+			for d.StringWidth(word) > maxWidth {
+				cut := 0
+				for i := range word {
+					if i > 0 && d.StringWidth(word[:i]) > maxWidth {
+						break
+					}
+					cut = i
+				}
+				if cut == 0 {
+					break
+				}
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			current = word
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// Line desenha uma linha entre (x1, y1) e (x2, y2)
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page(), "%.2f %.2f m %.2f %.2f l S\n", x1, d.Height-y1, x2, d.Height-y2)
+}
+
+// Rect desenha um retângulo com canto superior esquerdo em (x, y)
+func (d *Document) Rect(x, y, w, h float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	fmt.Fprintf(d.page(), "%.2f %.2f %.2f %.2f re %s\n", x, d.Height-y-h, w, h, op)
+}
+
+// Circle desenha um círculo de raio r centrado em (x, y), aproximado por curvas de Bézier
+func (d *Document) Circle(x, y, r float64, fill bool) {
+	const k = 0.5523 // Constante de aproximação do arco de 90° por Bézier cúbica
+	cy := d.Height - y
+	b := d.page()
+	fmt.Fprintf(b, "%.2f %.2f m\n", x+r, cy)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x+r, cy+k*r, x+k*r, cy+r, x, cy+r)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x-k*r, cy+r, x-r, cy+k*r, x-r, cy)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x-r, cy-k*r, x-k*r, cy-r, x, cy-r)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x+k*r, cy-r, x+r, cy-k*r, x+r, cy)
+	if fill {
+		b.WriteString("f\n")
+	} else {
+		b.WriteString("S\n")
+	}
+}
+
+// Image desenha a imagem no retângulo com canto superior esquerdo em (x, y).
+// Transparência é combinada com fundo branco.
+func (d *Document) Image(img image.Image, x, y, w, h float64) {
+	bounds := img.Bounds()
+	raw := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			r, g, b, a := img.At(px, py).RGBA()
+			// Componentes pré-multiplicados: soma o branco na parte transparente
+			white := 0xffff - a
+			raw = append(raw, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+	var data bytes.Buffer
+	zw := zlib.NewWriter(&data)
+	zw.Write(raw)
+	zw.Close()
+
+	var obj bytes.Buffer
+	fmt.Fprintf(&obj, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n",
+		bounds.Dx(), bounds.Dy(), data.Len())
+	obj.Write(data.Bytes())
+	obj.WriteString("\nendstream")
+	d.images = append(d.images, obj.Bytes())
+
+	fmt.Fprintf(d.page(), "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, h, x, d.Height-y-h, len(d.images))
+}
+
+// maxDataURLSize limita o tamanho das imagens embutidas (logos)
+const maxDataURLSize = 2 << 20
+
+// maxImagePixels limita as dimensões decodificadas (um PNG pequeno pode declarar milhões de pixels)
+const maxImagePixels = 4_000_000
+
+// ImageFromDataURL decodifica uma imagem PNG ou JPEG no formato data:image/...;base64,...
+// URLs externas não são baixadas.
+func ImageFromDataURL(s string) (image.Image, error) {
+	if len(s) > maxDataURLSize {
+		return nil, errors.New("imagem muito grande")
+	}
+	if !strings.HasPrefix(s, "data:image/") {
+		return nil, errors.New("imagem não é um data URL")
+	}
+	comma := strings.Index(s, ",")
+	if comma < 0 || !strings.HasSuffix(s[:comma], ";base64") {
+		return nil, errors.New("data URL inválido")
+	}
+	raw, err := base64.StdEncoding.DecodeString(s[comma+1:])
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, errors.New("dimensões da imagem excedem o limite")
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	return img, err
+}
+
+// Bytes serializa o documento completo
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// Numeração: 1 catálogo, 2 páginas, 3-4 fontes, imagens, depois (página, conteúdo) por página
+	objects := [][]byte{nil, nil,
+		[]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"),
+		[]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>"),
+	}
+	firstImage := len(objects) + 1
+	objects = append(objects, d.images...)
+
+	xobjects := ""
+	if len(d.images) > 0 {
+		xobjects = " /XObject <<"
+		for i := range d.images {
+			xobjects += fmt.Sprintf(" /Im%d %d 0 R", i+1, firstImage+i)
+		}
+		xobjects += " >>"
+	}
+
+	kids := []string{}
+	for _, content := range d.pages {
+		pageNum := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageNum))
+		objects = append(objects, []byte(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >>%s >> /Contents %d 0 R >>",
+			d.Width, d.Height, xobjects, pageNum+1)))
+
+		var data bytes.Buffer
+		zw := zlib.NewWriter(&data)
+		zw.Write(content.Bytes())
+		zw.Close()
+		var stream bytes.Buffer
+		fmt.Fprintf(&stream, "<< /Length %d /Filter /FlateDecode >>\nstream\n", data.Len())
+		stream.Write(data.Bytes())
+		stream.WriteString("\nendstream")
+		objects = append(objects, stream.Bytes())
+	}
+	objects[0] = []byte("<< /Type /Catalog /Pages 2 0 R >>")
+	objects[1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// escape protege os caracteres especiais de strings literais do PDF
+func escape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch c {
+		case '\\', '(', ')':
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
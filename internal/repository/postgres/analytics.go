@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"database/sql"
+	"esimulate-backend/internal/domain"
+	"fmt"
+	"time"
+)
+
+// --- Analytics Implementation ---
+// Todas as consultas leem answer_facts (populada por trigger em results),
+// evitando expandir o JSONB de respostas a cada requisição.
+
+// analyticsWhere monta o filtro comum (usuário, período e exames) das consultas de analytics
+func analyticsWhere(userID string, f domain.AnalyticsFilter) (string, []interface{}) {
+	where := "af.user_id = $1"
+	args := []interface{}{userID}
+	argIndex := 2
+
+	if f.From > 0 {
+		where += fmt.Sprintf(" AND af.answered_at >= $%d", argIndex)
+		args = append(args, time.UnixMilli(f.From))
+		argIndex++
+	}
+	if f.To > 0 {
+		where += fmt.Sprintf(" AND af.answered_at <= $%d", argIndex)
+		args = append(args, time.UnixMilli(f.To))
+		argIndex++
+	}
+	if len(f.ExamIDs) > 0 {
+		placeholders := ""
+		for i, id := range f.ExamIDs {
+			if i > 0 {
+				placeholders += ","
+			}
+			placeholders += fmt.Sprintf("$%d", argIndex)
+			args = append(args, id)
+			argIndex++
+		}
+		where += fmt.Sprintf(" AND af.exam_id::text IN (%s)", placeholders)
+	}
+	return where, args
+}
+
+func (r *PostgresRepo) GetUserOverallStats(userID string, f domain.AnalyticsFilter) (domain.PerformanceStat, error) {
+	where, args := analyticsWhere(userID, f)
+	var s domain.PerformanceStat
+	query := fmt.Sprintf(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE af.is_correct), COALESCE(AVG(af.time_seconds), 0)
+		FROM answer_facts af
+		WHERE %s`, where)
+	err := r.DB.QueryRow(query, args...).Scan(&s.Answered, &s.Correct, &s.AvgTimePerQuestion)
+	return s, err
+}
+
+func (r *PostgresRepo) GetUserSubjectStats(userID string, f domain.AnalyticsFilter) ([]domain.PerformanceStat, error) {
+	where, args := analyticsWhere(userID, f)
+	query := fmt.Sprintf(`
+		SELECT af.subject_id, s.name, COUNT(*), COUNT(*) FILTER (WHERE af.is_correct), AVG(af.time_seconds)
+		FROM answer_facts af
+		LEFT JOIN subjects s ON s.id = af.subject_id
+		WHERE %s
+		GROUP BY af.subject_id, s.name
+		ORDER BY s.name`, where)
+	rows, err := r.DB.Query(query, args...)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	stats := []domain.PerformanceStat{}
+	for rows.Next() {
+		var s domain.PerformanceStat
+		var subjectID, subjectName sql.NullString
+		if err := rows.Scan(&subjectID, &subjectName, &s.Answered, &s.Correct, &s.AvgTimePerQuestion); err != nil { continue }
+		s.SubjectID = subjectID.String
+		s.SubjectName = subjectName.String
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func (r *PostgresRepo) GetUserTopicStats(userID string, f domain.AnalyticsFilter) ([]domain.PerformanceStat, error) {
+	where, args := analyticsWhere(userID, f)
+	query := fmt.Sprintf(`
+		SELECT af.topic_id, t.name, t.subject_id, s.name, COUNT(*), COUNT(*) FILTER (WHERE af.is_correct), AVG(af.time_seconds)
+		FROM answer_facts af
+		JOIN topics t ON t.id = af.topic_id
+		LEFT JOIN subjects s ON s.id = t.subject_id
+		WHERE %s
+		GROUP BY af.topic_id, t.name, t.subject_id, s.name
+		ORDER BY s.name, t.name`, where)
+	rows, err := r.DB.Query(query, args...)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	stats := []domain.PerformanceStat{}
+	for rows.Next() {
+		var s domain.PerformanceStat
+		var subjectID, subjectName sql.NullString
+		if err := rows.Scan(&s.TopicID, &s.TopicName, &subjectID, &subjectName, &s.Answered, &s.Correct, &s.AvgTimePerQuestion); err != nil { continue }
+		s.SubjectID = subjectID.String
+		s.SubjectName = subjectName.String
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func (r *PostgresRepo) GetUserTrend(userID string, f domain.AnalyticsFilter) ([]domain.TrendPoint, error) {
+	where, args := analyticsWhere(userID, f)
+	// Interval é validado no service; aqui apenas garantimos um valor seguro para date_trunc
+	interval := "week"
+	switch f.Interval {
+	case "day", "week", "month":
+		interval = f.Interval
+	}
+	query := fmt.Sprintf(`
+		SELECT date_trunc('%s', af.answered_at) AS period, COUNT(*), COUNT(*) FILTER (WHERE af.is_correct)
+		FROM answer_facts af
+		WHERE %s
+		GROUP BY period
+		ORDER BY period`, interval, where)
+	rows, err := r.DB.Query(query, args...)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	trend := []domain.TrendPoint{}
+	for rows.Next() {
+		var p domain.TrendPoint
+		var period time.Time
+		if err := rows.Scan(&period, &p.Answered, &p.Correct); err != nil { continue }
+		p.Period = period.UnixMilli()
+		trend = append(trend, p)
+	}
+	return trend, nil
+}
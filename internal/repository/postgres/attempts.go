@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// --- Attempts (Limite de Tentativas) Implementation ---
+
+// attemptScopeQuery monta a contagem das tentativas do escopo e a data da última (created_at: horário do servidor).
+// Com AssignmentID, conta apenas as entregas da atividade; com LinkID, as do link; sem eles, todas as do exame.
+// O candidato é identificado pelo userID (autenticado) ou pelo email (link público).
+func attemptScopeQuery(scope domain.AttemptScope) (string, []interface{}) {
+	query := `SELECT COUNT(*), MAX(created_at) FROM results WHERE exam_id=$1`
+	args := []interface{}{scope.ExamID}
+	if scope.AssignmentID != "" {
+		args = append(args, scope.AssignmentID)
+		query += fmt.Sprintf(" AND assignment_id=$%d", len(args))
+	} else if scope.LinkID != "" {
+		args = append(args, scope.LinkID)
+		query += fmt.Sprintf(" AND link_id=$%d", len(args))
+	}
+	if scope.UserID != "" {
+		args = append(args, scope.UserID)
+		query += fmt.Sprintf(" AND user_id=$%d", len(args))
+	} else {
+		args = append(args, scope.Email)
+		query += fmt.Sprintf(" AND lower(candidate_email)=lower($%d)", len(args))
+	}
+	return query, args
+}
+
+// CountAttempts retorna quantas tentativas o candidato já fez no escopo e a data da última
+func (r *PostgresRepo) CountAttempts(scope domain.AttemptScope) (int, time.Time, error) {
+	query, args := attemptScopeQuery(scope)
+	var count int
+	var last sql.NullTime
+	if err := r.DB.QueryRow(query, args...).Scan(&count, &last); err != nil {
+		return 0, time.Time{}, err
+	}
+	return count, last.Time, nil
+}
+
+// ErrAttemptUnavailable indica um attemptId inexistente, de outro candidato ou já submetido
+var ErrAttemptUnavailable = errors.New("tentativa inválida")
+
+// CreateResultWithinPolicy grava o resultado em uma transação serializada por candidato e exame (pg_advisory_xact_lock):
+// a contagem do escopo é refeita com o lock e check pode recusar a gravação, para que envios simultâneos
+// não ultrapassem o limite de tentativas. A tentativa res.AttemptID, quando informada (do mesmo exame, usuário e link),
+// é marcada como submetida na mesma transação; se já tiver sido, nada é gravado (ErrAttemptUnavailable).
+func (r *PostgresRepo) CreateResultWithinPolicy(res domain.ExamResult, scope domain.AttemptScope, check func(count int, last time.Time) error) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	candidate := scope.UserID
+	if candidate == "" {
+		candidate = strings.ToLower(strings.TrimSpace(scope.Email))
+	}
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext($1))", "attempts:"+scope.ExamID+":"+candidate); err != nil {
+		return err
+	}
+	query, args := attemptScopeQuery(scope)
+	var count int
+	var last sql.NullTime
+	if err := tx.QueryRow(query, args...).Scan(&count, &last); err != nil {
+		return err
+	}
+	if err := check(count, last.Time); err != nil {
+		return err
+	}
+	if err := insertResult(tx, res); err != nil {
+		return err
+	}
+	if res.AttemptID == "" {
+		return tx.Commit()
+	}
+	claim, err := tx.Exec(`UPDATE exam_attempts SET submitted_at=NOW(), result_id=$2
+		WHERE id=$1 AND exam_id=$3 AND user_id IS NOT DISTINCT FROM $4 AND link_id IS NOT DISTINCT FROM $5 AND submitted_at IS NULL`,
+		res.AttemptID, res.ID, res.ExamID, nullString(res.UserID), nullString(res.LinkID))
+	if err != nil {
+		return err
+	}
+	if n, err := claim.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAttemptUnavailable
+	}
+	return tx.Commit()
+}
+
+// GetCompanyAttemptResults retorna as tentativas feitas pelos links da organização, em ordem cronológica,
+// para agrupamento por candidato
+func (r *PostgresRepo) GetCompanyAttemptResults(orgID string) ([]domain.ExamResult, error) {
+	query := `SELECT r.id, r.exam_id, r.link_id, r.candidate_name, r.candidate_email, r.score, r.total_questions,
+			r.time_spent_seconds, r.date, e.title, r.ability
+		FROM results r
+		JOIN exams e ON r.exam_id = e.id
+		WHERE r.candidate_email IS NOT NULL
+			AND r.link_id IN (SELECT id FROM public_links WHERE org_id = $1)
+		ORDER BY r.created_at`
+	rows, err := r.DB.Query(query, orgID)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	results := []domain.ExamResult{}
+	for rows.Next() {
+		var res domain.ExamResult
+		var linkID, name sql.NullString
+		var date time.Time
+		var ability []byte
+		if err := rows.Scan(&res.ID, &res.ExamID, &linkID, &name, &res.CandidateEmail, &res.Score, &res.TotalQuestions,
+			&res.TimeSpentSeconds, &date, &res.ExamTitle, &ability); err != nil { continue }
+		res.LinkID = linkID.String
+		res.CandidateName = name.String
+		res.Date = date.UnixMilli()
+		if len(ability) > 0 { json.Unmarshal(ability, &res.Ability) }
+		results = append(results, res)
+	}
+	return results, nil
+}
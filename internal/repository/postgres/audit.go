@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Trilha de auditoria ---
+
+// CreateAuditEvent grava um evento de auditoria
+func (r *PostgresRepo) CreateAuditEvent(e domain.AuditEvent) error {
+	var changes interface{} // NULL sem alterações
+	if len(e.Changes) > 0 {
+		data, _ := json.Marshal(e.Changes)
+		changes = string(data)
+	}
+	_, err := r.DB.Exec(`
+		INSERT INTO audit_events (action, actor_id, target_type, target_id, ip, user_agent, details, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		e.Action, nullString(e.ActorID), nullString(e.TargetType), nullString(e.TargetID),
+		nullString(e.IP), nullString(e.UserAgent), nullString(e.Details), changes, time.UnixMilli(e.CreatedAt))
+	return err
+}
+
+// ListAuditEvents lista os eventos do filtro, do mais recente para o mais antigo
+func (r *PostgresRepo) ListAuditEvents(f domain.AuditFilter) ([]domain.AuditEvent, error) {
+	where := []string{"TRUE"}
+	args := []interface{}{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+	if f.ActorID != "" {
+		add("actor_id = ?", f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+	if f.TargetType != "" {
+		add("target_type = ?", f.TargetType)
+	}
+	if f.TargetID != "" {
+		add("target_id = ?", f.TargetID)
+	}
+	if f.From > 0 {
+		add("created_at >= ?", time.UnixMilli(f.From))
+	}
+	if f.To > 0 {
+		add("created_at <= ?", time.UnixMilli(f.To))
+	}
+	if f.Cursor > 0 {
+		add("id < ?", f.Cursor)
+	}
+	args = append(args, f.Limit)
+
+	rows, err := r.DB.Query(`
+		SELECT id, action, COALESCE(actor_id, ''), COALESCE(target_type, ''), COALESCE(target_id, ''),
+			COALESCE(ip, ''), COALESCE(user_agent, ''), COALESCE(details, ''), changes, created_at
+		FROM audit_events
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.AuditEvent{}
+	for rows.Next() {
+		var e domain.AuditEvent
+		var changes []byte
+		var createdAt time.Time
+		if err := rows.Scan(&e.ID, &e.Action, &e.ActorID, &e.TargetType, &e.TargetID,
+			&e.IP, &e.UserAgent, &e.Details, &changes, &createdAt); err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 {
+			json.Unmarshal(changes, &e.Changes)
+		}
+		e.CreatedAt = createdAt.UnixMilli()
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// DeleteAuditEventsBefore remove eventos anteriores a before (retenção) e retorna quantos foram removidos
+func (r *PostgresRepo) DeleteAuditEventsBefore(before time.Time) (int64, error) {
+	res, err := r.DB.Exec("DELETE FROM audit_events WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"database/sql"
+	"esimulate-backend/internal/domain"
+)
+
+// --- Link Invitations & Reminders ---
+
+// RecordLinkInvitation registra o email convidado para o link (convites repetidos são ignorados)
+func (r *PostgresRepo) RecordLinkInvitation(linkID, email string) error {
+	_, err := r.DB.Exec(`INSERT INTO link_invitations (link_id, email) VALUES ($1, $2)
+		ON CONFLICT (link_id, lower(email)) DO NOTHING`, linkID, email)
+	return err
+}
+
+// GetPendingLinkReminders lista os convites ainda sem lembrete de links ativos cujo exame ou link
+// tem janela agendada; quem chama decide, pela janela efetiva, se o lembrete já é devido
+func (r *PostgresRepo) GetPendingLinkReminders() ([]domain.LinkReminder, error) {
+	rows, err := r.DB.Query(`SELECT li.id, li.email, pl.token, e.title, o.commercial_name, o.logo, pl.availability, e.availability
+		FROM link_invitations li
+		JOIN public_links pl ON pl.id = li.link_id
+		JOIN exams e ON e.id = pl.exam_id
+		LEFT JOIN organizations o ON o.id = pl.org_id
+		WHERE li.reminded_at IS NULL
+			AND pl.active = TRUE AND (pl.expires_at IS NULL OR pl.expires_at > NOW())
+			AND e.is_active = TRUE AND e.status = 'published'
+			AND (pl.availability IS NOT NULL OR e.availability IS NOT NULL)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []domain.LinkReminder{}
+	for rows.Next() {
+		var rem domain.LinkReminder
+		var orgName, orgLogo sql.NullString
+		var linkAvailability, examAvailability []byte
+		if err := rows.Scan(&rem.InvitationID, &rem.Email, &rem.LinkToken, &rem.ExamTitle, &orgName, &orgLogo,
+			&linkAvailability, &examAvailability); err != nil {
+			return nil, err
+		}
+		rem.OrgName, rem.OrgLogo = orgName.String, orgLogo.String
+		rem.LinkAvailability = parseJSONPtr[domain.Availability](linkAvailability)
+		rem.ExamAvailability = parseJSONPtr[domain.Availability](examAvailability)
+		reminders = append(reminders, rem)
+	}
+	return reminders, rows.Err()
+}
+
+// ClaimLinkInvitationReminder marca o lembrete do convite como enviado (ou dispensado) se ainda estiver pendente.
+// Retorna false quando outra instância já o reivindicou; quem reivindica é o único a enviar.
+func (r *PostgresRepo) ClaimLinkInvitationReminder(id string) (bool, error) {
+	var claimed string
+	err := r.DB.QueryRow(`UPDATE link_invitations SET reminded_at = NOW()
+		WHERE id=$1 AND reminded_at IS NULL
+		RETURNING id`, id).Scan(&claimed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReleaseLinkInvitationReminder devolve o lembrete à fila após falha no envio
+func (r *PostgresRepo) ReleaseLinkInvitationReminder(id string) error {
+	_, err := r.DB.Exec("UPDATE link_invitations SET reminded_at = NULL WHERE id=$1", id)
+	return err
+}
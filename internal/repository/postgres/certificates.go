@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"database/sql"
+	"esimulate-backend/internal/domain"
+	"time"
+)
+
+// --- Certificates Implementation ---
+
+const certificateColumns = `id, code, result_id, candidate_name, exam_title, score, total_questions, completed_at,
+	issuer_id, issuer_name, issued_at`
+
+func scanCertificate(row *sql.Row) (domain.Certificate, error) {
+	var c domain.Certificate
+	var issuerID sql.NullString
+	var completedAt, issuedAt time.Time
+	err := row.Scan(&c.ID, &c.Code, &c.ResultID, &c.CandidateName, &c.ExamTitle, &c.Score, &c.TotalQuestions, &completedAt,
+		&issuerID, &c.IssuerName, &issuedAt)
+	if err != nil {
+		return c, err
+	}
+	c.IssuerID = issuerID.String
+	c.CompletedAt = completedAt.UnixMilli()
+	c.IssuedAt = issuedAt.UnixMilli()
+	return c, nil
+}
+
+// CreateCertificate grava o certificado; se o resultado já tiver um, mantém o existente
+func (r *PostgresRepo) CreateCertificate(c domain.Certificate) error {
+	_, err := r.DB.Exec(`INSERT INTO certificates (id, code, result_id, candidate_name, exam_title, score, total_questions,
+			completed_at, issuer_id, issuer_name, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (result_id) DO NOTHING`,
+		c.ID, c.Code, c.ResultID, c.CandidateName, c.ExamTitle, c.Score, c.TotalQuestions,
+		time.UnixMilli(c.CompletedAt), nullString(c.IssuerID), c.IssuerName, time.UnixMilli(c.IssuedAt))
+	return err
+}
+
+func (r *PostgresRepo) GetCertificateByResult(resultID string) (domain.Certificate, error) {
+	return scanCertificate(r.DB.QueryRow(`SELECT `+certificateColumns+` FROM certificates WHERE result_id=$1`, resultID))
+}
+
+func (r *PostgresRepo) GetCertificateByCode(code string) (domain.Certificate, error) {
+	return scanCertificate(r.DB.QueryRow(`SELECT `+certificateColumns+` FROM certificates WHERE code=$1`, code))
+}
+
+// GetResultCompanyID retorna o criador e a organização do link pelo qual o resultado foi obtido ("" se não houver).
+// Resultados públicos anteriores a results.link_id são atribuídos pelo link mais antigo para o exame.
+func (r *PostgresRepo) GetResultCompanyID(resultID string) (string, string, error) {
+	var companyID string
+	var orgID sql.NullString
+	err := r.DB.QueryRow(`SELECT pl.company_id, pl.org_id FROM results r
+		JOIN public_links pl ON (r.link_id = pl.id OR (r.link_id IS NULL AND r.user_id IS NULL AND pl.exam_id = r.exam_id))
+		WHERE r.id = $1
+		ORDER BY pl.created_at
+		LIMIT 1`, resultID).Scan(&companyID, &orgID)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return companyID, orgID.String, err
+}
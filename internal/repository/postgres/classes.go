@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"database/sql"
+	"esimulate-backend/internal/domain"
+	"time"
+)
+
+// --- Classes Implementation ---
+
+const classColumns = `c.id, c.name, c.description, c.teacher_id, u.name, c.invite_code, c.created_at,
+	(SELECT COUNT(*) FROM class_members cm WHERE cm.class_id = c.id)`
+
+func scanClass(scan func(dest ...any) error) (domain.Classroom, error) {
+	var c domain.Classroom
+	var description, teacherName sql.NullString
+	var createdAt time.Time
+	if err := scan(&c.ID, &c.Name, &description, &c.TeacherID, &teacherName, &c.InviteCode, &createdAt, &c.StudentCount); err != nil {
+		return c, err
+	}
+	c.Description = description.String
+	c.TeacherName = teacherName.String
+	c.CreatedAt = createdAt.UnixMilli()
+	return c, nil
+}
+
+func (r *PostgresRepo) CreateClass(c domain.Classroom) error {
+	_, err := r.DB.Exec(`INSERT INTO classes (id, name, description, teacher_id, invite_code, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		c.ID, c.Name, nullString(c.Description), c.TeacherID, c.InviteCode, time.UnixMilli(c.CreatedAt))
+	return err
+}
+
+func (r *PostgresRepo) GetClassByID(id string) (domain.Classroom, error) {
+	row := r.DB.QueryRow(`SELECT `+classColumns+` FROM classes c LEFT JOIN users u ON u.id = c.teacher_id WHERE c.id=$1`, id)
+	return scanClass(row.Scan)
+}
+
+// GetClassByInviteCode busca a turma pelo código de convite (sem diferenciar maiúsculas)
+func (r *PostgresRepo) GetClassByInviteCode(code string) (domain.Classroom, error) {
+	row := r.DB.QueryRow(`SELECT `+classColumns+` FROM classes c LEFT JOIN users u ON u.id = c.teacher_id
+		WHERE c.invite_code = upper($1)`, code)
+	return scanClass(row.Scan)
+}
+
+// GetUserClasses lista as turmas em que o usuário é professor ou aluno, com o papel preenchido
+func (r *PostgresRepo) GetUserClasses(userID string) ([]domain.Classroom, error) {
+	rows, err := r.DB.Query(`SELECT `+classColumns+` FROM classes c LEFT JOIN users u ON u.id = c.teacher_id
+		WHERE c.teacher_id = $1 OR c.id IN (SELECT class_id FROM class_members WHERE user_id = $1)
+		ORDER BY c.created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	classes := []domain.Classroom{}
+	for rows.Next() {
+		c, err := scanClass(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		c.Role = domain.ClassStudent
+		if c.TeacherID == userID {
+			c.Role = domain.ClassTeacher
+		}
+		classes = append(classes, c)
+	}
+	return classes, rows.Err()
+}
+
+// UpdateClassInviteCode substitui o código de convite (o anterior deixa de funcionar)
+func (r *PostgresRepo) UpdateClassInviteCode(id, code string) error {
+	res, err := r.DB.Exec("UPDATE classes SET invite_code=$2 WHERE id=$1", id, code)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteClass remove a turma, matrículas e atividades; os resultados perdem o vínculo com a atividade
+func (r *PostgresRepo) DeleteClass(id string) error {
+	res, err := r.DB.Exec("DELETE FROM classes WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AddClassMember matricula o usuário na turma (idempotente)
+func (r *PostgresRepo) AddClassMember(classID, userID string) error {
+	_, err := r.DB.Exec("INSERT INTO class_members (class_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", classID, userID)
+	return err
+}
+
+func (r *PostgresRepo) RemoveClassMember(classID, userID string) error {
+	res, err := r.DB.Exec("DELETE FROM class_members WHERE class_id=$1 AND user_id=$2", classID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *PostgresRepo) IsClassMember(classID, userID string) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM class_members WHERE class_id=$1 AND user_id=$2)", classID, userID).Scan(&exists)
+	return exists, err
+}
+
+// GetClassMembers lista os alunos da turma em ordem alfabética
+func (r *PostgresRepo) GetClassMembers(classID string) ([]domain.ClassMember, error) {
+	rows, err := r.DB.Query(`SELECT u.id, u.name, u.email, m.joined_at
+		FROM class_members m JOIN users u ON u.id = m.user_id
+		WHERE m.class_id = $1
+		ORDER BY u.name, u.email`, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []domain.ClassMember{}
+	for rows.Next() {
+		var m domain.ClassMember
+		var joinedAt time.Time
+		if err := rows.Scan(&m.UserID, &m.Name, &m.Email, &joinedAt); err != nil {
+			return nil, err
+		}
+		m.JoinedAt = joinedAt.UnixMilli()
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// --- Assignments ---
+
+const assignmentColumns = `a.id, a.class_id, c.name, a.exam_id, e.title, a.title, a.opens_at, a.closes_at,
+	a.attempt_policy, e.attempt_policy, a.created_by, a.created_at`
+
+const assignmentFrom = ` FROM class_assignments a
+	JOIN classes c ON c.id = a.class_id
+	JOIN exams e ON e.id = a.exam_id`
+
+func scanAssignment(scan func(dest ...any) error) (domain.Assignment, error) {
+	var a domain.Assignment
+	var title, createdBy sql.NullString
+	var closesAt sql.NullTime
+	var opensAt, createdAt time.Time
+	var policy, examPolicy []byte
+	if err := scan(&a.ID, &a.ClassID, &a.ClassName, &a.ExamID, &a.ExamTitle, &title, &opensAt, &closesAt,
+		&policy, &examPolicy, &createdBy, &createdAt); err != nil {
+		return a, err
+	}
+	a.Title = title.String
+	a.OpensAt = opensAt.UnixMilli()
+	if closesAt.Valid {
+		a.ClosesAt = closesAt.Time.UnixMilli()
+	}
+	a.AttemptPolicy = parseJSONPtr[domain.AttemptPolicy](policy)
+	a.ExamPolicy = parseJSONPtr[domain.AttemptPolicy](examPolicy)
+	a.CreatedBy = createdBy.String
+	a.CreatedAt = createdAt.UnixMilli()
+	return a, nil
+}
+
+func (r *PostgresRepo) queryAssignments(query string, args ...any) ([]domain.Assignment, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []domain.Assignment{}
+	for rows.Next() {
+		a, err := scanAssignment(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+func (r *PostgresRepo) CreateAssignment(a domain.Assignment) error {
+	var closesAt sql.NullTime
+	if a.ClosesAt > 0 {
+		closesAt = sql.NullTime{Time: time.UnixMilli(a.ClosesAt), Valid: true}
+	}
+	_, err := r.DB.Exec(`INSERT INTO class_assignments (id, class_id, exam_id, title, opens_at, closes_at, attempt_policy, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		a.ID, a.ClassID, a.ExamID, nullString(a.Title), time.UnixMilli(a.OpensAt), closesAt,
+		jsonOrNull(a.AttemptPolicy), nullString(a.CreatedBy), time.UnixMilli(a.CreatedAt))
+	return err
+}
+
+func (r *PostgresRepo) GetAssignmentByID(id string) (domain.Assignment, error) {
+	row := r.DB.QueryRow(`SELECT `+assignmentColumns+assignmentFrom+` WHERE a.id=$1`, id)
+	return scanAssignment(row.Scan)
+}
+
+// GetClassAssignments lista as atividades da turma pela data de abertura
+func (r *PostgresRepo) GetClassAssignments(classID string) ([]domain.Assignment, error) {
+	return r.queryAssignments(`SELECT `+assignmentColumns+assignmentFrom+`
+		WHERE a.class_id = $1
+		ORDER BY a.opens_at, a.created_at`, classID)
+}
+
+// GetStudentAssignments lista as atividades de todas as turmas em que o usuário é aluno
+func (r *PostgresRepo) GetStudentAssignments(userID string) ([]domain.Assignment, error) {
+	return r.queryAssignments(`SELECT `+assignmentColumns+assignmentFrom+`
+		WHERE a.class_id IN (SELECT class_id FROM class_members WHERE user_id = $1)
+		ORDER BY a.closes_at NULLS LAST, a.opens_at`, userID)
+}
+
+func (r *PostgresRepo) DeleteAssignment(classID, id string) error {
+	res, err := r.DB.Exec("DELETE FROM class_assignments WHERE id=$1 AND class_id=$2", id, classID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// getAssignmentResults retorna as tentativas entregues às atividades, em ordem cronológica (sem as respostas)
+func (r *PostgresRepo) getAssignmentResults(where string, arg string) ([]domain.ExamResult, error) {
+	rows, err := r.DB.Query(`SELECT r.id, r.exam_id, r.user_id, r.assignment_id, r.score, r.total_questions, r.time_spent_seconds, r.date
+		FROM results r
+		JOIN class_assignments a ON a.id = r.assignment_id
+		WHERE `+where+`
+		ORDER BY r.date`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []domain.ExamResult{}
+	for rows.Next() {
+		var res domain.ExamResult
+		var userID sql.NullString
+		var date time.Time
+		if err := rows.Scan(&res.ID, &res.ExamID, &userID, &res.AssignmentID, &res.Score, &res.TotalQuestions, &res.TimeSpentSeconds, &date); err != nil {
+			return nil, err
+		}
+		res.UserID = userID.String
+		res.Date = date.UnixMilli()
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// GetClassAssignmentResults retorna as tentativas de todos os alunos nas atividades da turma
+func (r *PostgresRepo) GetClassAssignmentResults(classID string) ([]domain.ExamResult, error) {
+	return r.getAssignmentResults("a.class_id = $1", classID)
+}
+
+// GetUserAssignmentResults retorna as tentativas do usuário em atividades de turmas
+func (r *PostgresRepo) GetUserAssignmentResults(userID string) ([]domain.ExamResult, error) {
+	return r.getAssignmentResults("r.user_id = $1", userID)
+}
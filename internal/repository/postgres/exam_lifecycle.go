@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// --- Exam Lifecycle Implementation ---
+
+// UpdateExamStatus altera o estado (draft | published | archived) de um exame ativo
+func (r *PostgresRepo) UpdateExamStatus(id, status string) error {
+	res, err := r.DB.Exec("UPDATE exams SET status=$2, updated_at=NOW() WHERE id=$1 AND is_active = TRUE", id, status)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SoftDeleteExam marca o exame como excluído, preservando resultados até a purga
+func (r *PostgresRepo) SoftDeleteExam(id string) error {
+	res, err := r.DB.Exec("UPDATE exams SET is_active = FALSE, deleted_at = NOW(), updated_at = NOW() WHERE id=$1 AND is_active = TRUE", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RestoreExam desfaz a exclusão lógica
+func (r *PostgresRepo) RestoreExam(id string) error {
+	res, err := r.DB.Exec("UPDATE exams SET is_active = TRUE, deleted_at = NULL, updated_at = NOW() WHERE id=$1 AND is_active = FALSE", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// PurgeDeletedExams remove definitivamente os exames excluídos antes de olderThan.
+// Os resultados, links e vínculos são removidos em cascata.
+func (r *PostgresRepo) PurgeDeletedExams(olderThan time.Time) (int64, error) {
+	res, err := r.DB.Exec("DELETE FROM exams WHERE is_active = FALSE AND deleted_at IS NOT NULL AND deleted_at < $1", olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// TransferExamOwnership troca o dono do exame, desde que o dono atual ainda seja currentOwner
+func (r *PostgresRepo) TransferExamOwnership(id, currentOwner, newOwner string) error {
+	res, err := r.DB.Exec("UPDATE exams SET created_by=$3, updated_at=NOW() WHERE id=$1 AND created_by=$2 AND is_active = TRUE", id, currentOwner, newOwner)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"fmt"
+	"time"
+)
+
+// --- IRT / Adaptive Implementation ---
+
+// GetItemResponses retorna todas as respostas dicotômicas registradas em answer_facts
+func (r *PostgresRepo) GetItemResponses() ([]domain.ItemResponse, error) {
+	rows, err := r.DB.Query("SELECT result_id, question_id, is_correct FROM answer_facts")
+	if err != nil { return nil, err }
+	defer rows.Close()
+	responses := []domain.ItemResponse{}
+	for rows.Next() {
+		var resp domain.ItemResponse
+		if err := rows.Scan(&resp.PersonID, &resp.QuestionID, &resp.Correct); err != nil { continue }
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+// SaveItemParams grava (upsert) os parâmetros calibrados em uma única transação
+func (r *PostgresRepo) SaveItemParams(params []domain.ItemParams) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, p := range params {
+		_, err := tx.Exec(`INSERT INTO question_irt_params (question_id, model, discrimination, difficulty, responses, calibrated_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+			ON CONFLICT (question_id) DO UPDATE SET
+				model=$2, discrimination=$3, difficulty=$4, responses=$5, calibrated_at=NOW()`,
+			p.QuestionID, p.Model, p.Discrimination, p.Difficulty, p.Responses)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetItemParams retorna os parâmetros calibrados das questões informadas, indexados por question_id
+func (r *PostgresRepo) GetItemParams(questionIDs []string) (map[string]domain.ItemParams, error) {
+	params := make(map[string]domain.ItemParams)
+	if len(questionIDs) == 0 {
+		return params, nil
+	}
+	placeholders := ""
+	args := make([]interface{}, len(questionIDs))
+	for i, id := range questionIDs {
+		if i > 0 {
+			placeholders += ","
+		}
+		placeholders += fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	rows, err := r.DB.Query(fmt.Sprintf(`SELECT question_id, model, discrimination, difficulty, responses, calibrated_at
+		FROM question_irt_params WHERE question_id::text IN (%s)`, placeholders), args...)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	for rows.Next() {
+		var p domain.ItemParams
+		var calibratedAt time.Time
+		if err := rows.Scan(&p.QuestionID, &p.Model, &p.Discrimination, &p.Difficulty, &p.Responses, &calibratedAt); err != nil { continue }
+		p.CalibratedAt = calibratedAt.UnixMilli()
+		params[p.QuestionID] = p
+	}
+	return params, nil
+}
+
+func (r *PostgresRepo) CreateAdaptiveSession(s domain.AdaptiveSession) error {
+	respJSON, _ := json.Marshal(s.Responses)
+	_, err := r.DB.Exec(`INSERT INTO adaptive_sessions
+			(id, exam_id, user_id, link_id, candidate_name, candidate_email, theta, se, responses, current_question_id, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		s.ID, s.ExamID, nullString(s.UserID), nullString(s.LinkID), s.CandidateName, s.CandidateEmail,
+		s.Theta, s.SE, respJSON, nullString(s.CurrentQuestionID), s.Status, time.UnixMilli(s.CreatedAt))
+	return err
+}
+
+func (r *PostgresRepo) GetAdaptiveSession(id string) (domain.AdaptiveSession, error) {
+	var s domain.AdaptiveSession
+	var userID, linkID, name, email, currentQ, resultID sql.NullString
+	var respJSON []byte
+	var createdAt time.Time
+	var finishedAt sql.NullTime
+	err := r.DB.QueryRow(`SELECT id, exam_id, user_id, link_id, candidate_name, candidate_email, theta, se, responses,
+			current_question_id, status, result_id, created_at, finished_at
+		FROM adaptive_sessions WHERE id=$1`, id).
+		Scan(&s.ID, &s.ExamID, &userID, &linkID, &name, &email, &s.Theta, &s.SE, &respJSON,
+			&currentQ, &s.Status, &resultID, &createdAt, &finishedAt)
+	if err != nil {
+		return s, err
+	}
+	s.UserID = userID.String
+	s.LinkID = linkID.String
+	s.CandidateName = name.String
+	s.CandidateEmail = email.String
+	s.CurrentQuestionID = currentQ.String
+	s.ResultID = resultID.String
+	s.CreatedAt = createdAt.UnixMilli()
+	if finishedAt.Valid {
+		s.FinishedAt = finishedAt.Time.UnixMilli()
+	}
+	s.Responses = []domain.AdaptiveAnswer{}
+	json.Unmarshal(respJSON, &s.Responses)
+	return s, nil
+}
+
+// UpdateAdaptiveSession grava o progresso da sessão (respostas, estimativa e próxima questão) se ela ainda estiver
+// ativa e na questão respondida (expectedQuestionID). Retorna sql.ErrNoRows se outra resposta já avançou a sessão,
+// para que respostas simultâneas à mesma questão não sejam gravadas duas vezes.
+func (r *PostgresRepo) UpdateAdaptiveSession(s domain.AdaptiveSession, expectedQuestionID string) error {
+	respJSON, _ := json.Marshal(s.Responses)
+	var finishedAt interface{}
+	if s.FinishedAt > 0 {
+		finishedAt = time.UnixMilli(s.FinishedAt)
+	}
+	res, err := r.DB.Exec(`UPDATE adaptive_sessions SET
+			theta=$2, se=$3, responses=$4, current_question_id=$5, status=$6, result_id=$7, finished_at=$8
+		WHERE id=$1 AND status='active' AND current_question_id=$9`,
+		s.ID, s.Theta, s.SE, respJSON, nullString(s.CurrentQuestionID), s.Status, nullString(s.ResultID), finishedAt, expectedQuestionID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FinishAdaptiveSession encerra a sessão se ela ainda estiver ativa e na questão respondida (sql.ErrNoRows caso contrário).
+// A atualização condicional garante um único resultado por sessão mesmo com respostas simultâneas.
+func (r *PostgresRepo) FinishAdaptiveSession(s domain.AdaptiveSession, expectedQuestionID string) error {
+	respJSON, _ := json.Marshal(s.Responses)
+	res, err := r.DB.Exec(`UPDATE adaptive_sessions SET
+			theta=$2, se=$3, responses=$4, current_question_id=NULL, status='finished', finished_at=$5
+		WHERE id=$1 AND status='active' AND current_question_id=$6`,
+		s.ID, s.Theta, s.SE, respJSON, time.UnixMilli(s.FinishedAt), expectedQuestionID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetAdaptiveSessionResult associa o resultado gravado à sessão finalizada
+func (r *PostgresRepo) SetAdaptiveSessionResult(sessionID, resultID string) error {
+	_, err := r.DB.Exec("UPDATE adaptive_sessions SET result_id=$2 WHERE id=$1", sessionID, resultID)
+	return err
+}
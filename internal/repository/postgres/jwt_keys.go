@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"esimulate-backend/internal/domain"
+	"time"
+)
+
+// --- Chaves de assinatura JWT ---
+
+// GetSigningKeys retorna as chaves publicadas, da mais antiga para a mais recente
+func (r *PostgresRepo) GetSigningKeys() ([]domain.JWTSigningKey, error) {
+	rows, err := r.DB.Query("SELECT kid, algorithm, private_key, activates_at FROM jwt_signing_keys ORDER BY activates_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []domain.JWTSigningKey
+	for rows.Next() {
+		var k domain.JWTSigningKey
+		var activatesAt time.Time
+		if err := rows.Scan(&k.ID, &k.Algorithm, &k.PrivateKey, &activatesAt); err != nil {
+			return nil, err
+		}
+		k.ActivatesAt = activatesAt.UnixMilli()
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// CreateSigningKey grava a próxima chave se nenhuma outra réplica já agendou uma chave ativando após `after`.
+// k.PrivateKey já chega cifrada pelo serviço (AES-GCM). Retorna false quando a chave não foi gravada.
+func (r *PostgresRepo) CreateSigningKey(k domain.JWTSigningKey, after time.Time) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Serializa a rotação entre réplicas
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('jwt_signing_keys'))"); err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM jwt_signing_keys WHERE activates_at > $1)", after).Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+	if _, err := tx.Exec(`INSERT INTO jwt_signing_keys (kid, algorithm, private_key, activates_at) VALUES ($1, $2, $3, $4)`,
+		k.ID, k.Algorithm, k.PrivateKey, time.UnixMilli(k.ActivatesAt)); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// EncryptSigningKey substitui a chave privada em texto puro pela versão cifrada.
+// A condição sobre o valor atual evita sobrescrever a chave se outra réplica já a cifrou.
+func (r *PostgresRepo) EncryptSigningKey(kid, plaintext, encrypted string) error {
+	_, err := r.DB.Exec("UPDATE jwt_signing_keys SET private_key = $3 WHERE kid = $1 AND private_key = $2", kid, plaintext, encrypted)
+	return err
+}
+
+// DeleteRetiredSigningKeys exclui as chaves substituídas antes de `before` (tokens assinados por elas já expiraram)
+func (r *PostgresRepo) DeleteRetiredSigningKeys(before time.Time) (int64, error) {
+	res, err := r.DB.Exec(`
+		DELETE FROM jwt_signing_keys k
+		WHERE EXISTS (
+			SELECT 1 FROM jwt_signing_keys n
+			WHERE n.activates_at > k.activates_at AND n.activates_at < $1
+		)`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"time"
+)
+
+// --- Bloqueio de contas (falhas de login) ---
+
+// GetLoginLockout retorna as falhas e o bloqueio da conta (zerado se não houver falhas)
+func (r *PostgresRepo) GetLoginLockout(userID string) (domain.LoginLockout, error) {
+	var l domain.LoginLockout
+	var lastFailedAt time.Time
+	var lockedUntil sql.NullTime
+	err := r.DB.QueryRow("SELECT failed_count, last_failed_at, locked_until FROM login_failures WHERE user_id=$1", userID).
+		Scan(&l.FailedAttempts, &lastFailedAt, &lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.LoginLockout{}, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	l.LastFailedAt = lastFailedAt.UnixMilli()
+	if lockedUntil.Valid {
+		l.LockedUntil = lockedUntil.Time.UnixMilli()
+	}
+	return l, nil
+}
+
+// IncrementLoginFailures registra uma senha incorreta e retorna o total de falhas seguidas
+func (r *PostgresRepo) IncrementLoginFailures(userID string) (int, error) {
+	var count int
+	err := r.DB.QueryRow(`
+		INSERT INTO login_failures (user_id, failed_count, last_failed_at) VALUES ($1, 1, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET failed_count = login_failures.failed_count + 1, last_failed_at = NOW()
+		RETURNING failed_count`, userID).Scan(&count)
+	return count, err
+}
+
+// LockAccount bloqueia a conta até `until` se ela atingiu minFailures falhas e zera a contagem.
+// Retorna false se outra requisição já aplicou o bloqueio.
+func (r *PostgresRepo) LockAccount(userID string, minFailures int, until time.Time) (bool, error) {
+	res, err := r.DB.Exec("UPDATE login_failures SET locked_until=$3, failed_count=0 WHERE user_id=$1 AND failed_count >= $2",
+		userID, minFailures, until)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ClearLoginFailures zera falhas e bloqueio (login bem-sucedido, nova senha ou desbloqueio pelo admin)
+func (r *PostgresRepo) ClearLoginFailures(userID string) error {
+	_, err := r.DB.Exec("DELETE FROM login_failures WHERE user_id=$1", userID)
+	return err
+}
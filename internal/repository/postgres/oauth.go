@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"esimulate-backend/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- Social Login (OIDC / OAuth2) ---
+
+func (r *PostgresRepo) CreateOAuthState(state, provider, codeVerifier, nonce string, expiresAt time.Time) error {
+	_, err := r.DB.Exec(`INSERT INTO oauth_states (state, provider, code_verifier, nonce, expires_at) VALUES ($1, $2, $3, $4, $5)`,
+		state, provider, codeVerifier, nonce, expiresAt)
+	return err
+}
+
+// ConsumeOAuthState exclui o state (uso único) e retorna provider, code_verifier, nonce e expiração
+func (r *PostgresRepo) ConsumeOAuthState(state string) (string, string, string, time.Time, error) {
+	var provider, codeVerifier, nonce string
+	var expiresAt time.Time
+	err := r.DB.QueryRow(`DELETE FROM oauth_states WHERE state=$1 RETURNING provider, code_verifier, nonce, expires_at`, state).
+		Scan(&provider, &codeVerifier, &nonce, &expiresAt)
+	return provider, codeVerifier, nonce, expiresAt, err
+}
+
+// DeleteExpiredOAuthStates remove fluxos abandonados e vínculos pendentes expirados
+func (r *PostgresRepo) DeleteExpiredOAuthStates() error {
+	if _, err := r.DB.Exec("DELETE FROM oauth_states WHERE expires_at < NOW()"); err != nil {
+		return err
+	}
+	_, err := r.DB.Exec("DELETE FROM oauth_link_requests WHERE expires_at < NOW()")
+	return err
+}
+
+// GetUserIDByOAuthIdentity retorna o usuário vinculado à identidade (sql.ErrNoRows se não houver)
+func (r *PostgresRepo) GetUserIDByOAuthIdentity(provider, subject string) (string, error) {
+	var userID string
+	err := r.DB.QueryRow("SELECT user_id FROM oauth_identities WHERE provider=$1 AND subject=$2", provider, subject).Scan(&userID)
+	return userID, err
+}
+
+func (r *PostgresRepo) TouchOAuthIdentity(provider, subject string) error {
+	_, err := r.DB.Exec("UPDATE oauth_identities SET last_login_at=NOW() WHERE provider=$1 AND subject=$2", provider, subject)
+	return err
+}
+
+func (r *PostgresRepo) CreateOAuthIdentity(userID, provider, subject, email string) error {
+	_, err := r.DB.Exec(`INSERT INTO oauth_identities (user_id, provider, subject, email, last_login_at) VALUES ($1, $2, $3, $4, NOW())`,
+		userID, provider, subject, nullString(email))
+	return err
+}
+
+// CreateOAuthUser cria o usuário e a identidade social na mesma transação
+func (r *PostgresRepo) CreateOAuthUser(u domain.User, subject string) (domain.User, error) {
+	if u.ID == "" {
+		u.ID = uuid.New().String()
+	}
+	if u.CreatedAt == 0 {
+		u.CreatedAt = time.Now().UnixMilli()
+	}
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return u, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO users (id, name, email, password_hash, role, provider, created_at, is_verified, onboarding_completed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		u.ID, u.Name, u.Email, u.Password, u.Role, u.Provider, time.UnixMilli(u.CreatedAt), u.IsVerified, u.OnboardingCompleted); err != nil {
+		return u, err
+	}
+	if _, err := tx.Exec(`INSERT INTO oauth_identities (user_id, provider, subject, email, last_login_at) VALUES ($1, $2, $3, $4, NOW())`,
+		u.ID, u.Provider, subject, u.Email); err != nil {
+		return u, err
+	}
+	u.Password = ""
+	return u, tx.Commit()
+}
+
+func (r *PostgresRepo) CreateOAuthLinkRequest(req domain.OAuthLinkRequest) error {
+	_, err := r.DB.Exec(`INSERT INTO oauth_link_requests (token, user_id, provider, subject, email, expires_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		req.Token, req.UserID, req.Provider, req.Subject, req.Email, time.UnixMilli(req.ExpiresAt))
+	return err
+}
+
+func (r *PostgresRepo) GetOAuthLinkRequest(token string) (domain.OAuthLinkRequest, error) {
+	req := domain.OAuthLinkRequest{Token: token}
+	var expiresAt time.Time
+	err := r.DB.QueryRow("SELECT user_id, provider, subject, email, expires_at FROM oauth_link_requests WHERE token=$1", token).
+		Scan(&req.UserID, &req.Provider, &req.Subject, &req.Email, &expiresAt)
+	req.ExpiresAt = expiresAt.UnixMilli()
+	return req, err
+}
+
+func (r *PostgresRepo) DeleteOAuthLinkRequest(token string) error {
+	_, err := r.DB.Exec("DELETE FROM oauth_link_requests WHERE token=$1", token)
+	return err
+}
+
+// GetUserIDByEmailFold busca o usuário pelo email sem diferenciar maiúsculas
+func (r *PostgresRepo) GetUserIDByEmailFold(email string) (string, error) {
+	var userID string
+	err := r.DB.QueryRow("SELECT id FROM users WHERE lower(email) = lower($1) LIMIT 1", email).Scan(&userID)
+	return userID, err
+}
@@ -0,0 +1,244 @@
+package postgres
+
+import (
+	"database/sql"
+	"esimulate-backend/internal/domain"
+	"time"
+)
+
+// --- Organizations Implementation ---
+
+// CreateOrganization cria a organização com ownerID como primeiro membro (owner)
+func (r *PostgresRepo) CreateOrganization(o domain.Organization, ownerID string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	created := time.UnixMilli(o.CreatedAt)
+	if _, err := tx.Exec(`INSERT INTO organizations (id, commercial_name, logo, tax_id, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+		o.ID, o.CommercialName, nullString(o.Logo), nullString(o.TaxID), nullString(o.CreatedBy), created); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO organization_members (org_id, user_id, role, joined_at) VALUES ($1, $2, $3, $4)",
+		o.ID, ownerID, domain.OrgOwner, created); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanOrganization(row interface{ Scan(...interface{}) error }, withRole bool) (domain.Organization, error) {
+	var o domain.Organization
+	var logo, taxID, createdBy sql.NullString
+	var createdAt, updatedAt time.Time
+	dest := []interface{}{&o.ID, &o.CommercialName, &logo, &taxID, &createdBy, &createdAt, &updatedAt}
+	if withRole {
+		dest = append(dest, &o.Role)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return o, err
+	}
+	o.Logo, o.TaxID, o.CreatedBy = logo.String, taxID.String, createdBy.String
+	o.CreatedAt = createdAt.UnixMilli()
+	o.UpdatedAt = updatedAt.UnixMilli()
+	return o, nil
+}
+
+const organizationColumns = `o.id, o.commercial_name, o.logo, o.tax_id, o.created_by, o.created_at, o.updated_at`
+
+// GetOrganization retorna a organização pelo ID
+func (r *PostgresRepo) GetOrganization(id string) (domain.Organization, error) {
+	return scanOrganization(r.DB.QueryRow(`SELECT `+organizationColumns+` FROM organizations o WHERE o.id=$1`, id), false)
+}
+
+// GetUserOrganizations lista as organizações do usuário com o papel dele, da mais antiga para a mais recente
+func (r *PostgresRepo) GetUserOrganizations(userID string) ([]domain.Organization, error) {
+	rows, err := r.DB.Query(`SELECT `+organizationColumns+`, m.role
+		FROM organizations o JOIN organization_members m ON m.org_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY m.joined_at, o.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	orgs := []domain.Organization{}
+	for rows.Next() {
+		o, err := scanOrganization(rows, true)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, rows.Err()
+}
+
+// UpdateOrganization atualiza o perfil da organização
+func (r *PostgresRepo) UpdateOrganization(o domain.Organization) error {
+	res, err := r.DB.Exec("UPDATE organizations SET commercial_name=$2, logo=$3, tax_id=$4, updated_at=NOW() WHERE id=$1",
+		o.ID, o.CommercialName, nullString(o.Logo), nullString(o.TaxID))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetOrgMemberRole retorna o papel do usuário na organização ("" se não for membro)
+func (r *PostgresRepo) GetOrgMemberRole(orgID, userID string) (string, error) {
+	var role string
+	err := r.DB.QueryRow("SELECT role FROM organization_members WHERE org_id=$1 AND user_id=$2", orgID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// GetOrgMembers lista os membros da organização
+func (r *PostgresRepo) GetOrgMembers(orgID string) ([]domain.OrgMember, error) {
+	rows, err := r.DB.Query(`SELECT u.id, u.name, u.email, m.role, m.joined_at
+		FROM organization_members m JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = $1
+		ORDER BY m.joined_at`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := []domain.OrgMember{}
+	for rows.Next() {
+		var m domain.OrgMember
+		var joinedAt time.Time
+		if err := rows.Scan(&m.UserID, &m.Name, &m.Email, &m.Role, &joinedAt); err != nil {
+			return nil, err
+		}
+		m.JoinedAt = joinedAt.UnixMilli()
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// UpdateOrgMemberRole altera o papel de um membro
+func (r *PostgresRepo) UpdateOrgMemberRole(orgID, userID, role string) error {
+	res, err := r.DB.Exec("UPDATE organization_members SET role=$3 WHERE org_id=$1 AND user_id=$2", orgID, userID, role)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RemoveOrgMember remove o membro da organização
+func (r *PostgresRepo) RemoveOrgMember(orgID, userID string) error {
+	res, err := r.DB.Exec("DELETE FROM organization_members WHERE org_id=$1 AND user_id=$2", orgID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CountOrgOwners conta os owners da organização (ela deve manter ao menos um)
+func (r *PostgresRepo) CountOrgOwners(orgID string) (int, error) {
+	var n int
+	err := r.DB.QueryRow("SELECT COUNT(*) FROM organization_members WHERE org_id=$1 AND role='owner'", orgID).Scan(&n)
+	return n, err
+}
+
+// --- Organization Invitations ---
+
+// CreateOrgInvitation registra um convite pendente
+func (r *PostgresRepo) CreateOrgInvitation(inv domain.OrgInvitation) error {
+	_, err := r.DB.Exec(`INSERT INTO organization_invitations (id, org_id, email, role, token, invited_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		inv.ID, inv.OrgID, inv.Email, inv.Role, inv.Token, nullString(inv.InvitedBy), time.UnixMilli(inv.CreatedAt), time.UnixMilli(inv.ExpiresAt))
+	return err
+}
+
+const invitationColumns = `i.id, i.org_id, o.commercial_name, i.email, i.role, i.token, i.invited_by, i.created_at, i.expires_at, i.accepted_at`
+
+func scanInvitation(row interface{ Scan(...interface{}) error }) (domain.OrgInvitation, error) {
+	var inv domain.OrgInvitation
+	var invitedBy sql.NullString
+	var createdAt, expiresAt time.Time
+	var acceptedAt sql.NullTime
+	if err := row.Scan(&inv.ID, &inv.OrgID, &inv.OrgName, &inv.Email, &inv.Role, &inv.Token, &invitedBy, &createdAt, &expiresAt, &acceptedAt); err != nil {
+		return inv, err
+	}
+	inv.InvitedBy = invitedBy.String
+	inv.CreatedAt = createdAt.UnixMilli()
+	inv.ExpiresAt = expiresAt.UnixMilli()
+	if acceptedAt.Valid {
+		inv.AcceptedAt = acceptedAt.Time.UnixMilli()
+	}
+	return inv, nil
+}
+
+// GetOrgInvitations lista os convites pendentes (não aceitos e não expirados)
+func (r *PostgresRepo) GetOrgInvitations(orgID string) ([]domain.OrgInvitation, error) {
+	rows, err := r.DB.Query(`SELECT `+invitationColumns+`
+		FROM organization_invitations i JOIN organizations o ON o.id = i.org_id
+		WHERE i.org_id = $1 AND i.accepted_at IS NULL AND i.expires_at > NOW()
+		ORDER BY i.created_at DESC`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	invitations := []domain.OrgInvitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, rows.Err()
+}
+
+// GetOrgInvitationByToken retorna o convite pelo token enviado por email
+func (r *PostgresRepo) GetOrgInvitationByToken(token string) (domain.OrgInvitation, error) {
+	return scanInvitation(r.DB.QueryRow(`SELECT `+invitationColumns+`
+		FROM organization_invitations i JOIN organizations o ON o.id = i.org_id
+		WHERE i.token = $1`, token))
+}
+
+// AcceptOrgInvitation inclui o usuário na organização e marca o convite como aceito.
+// Se o usuário já for membro, mantém o papel atual.
+func (r *PostgresRepo) AcceptOrgInvitation(inv domain.OrgInvitation, userID string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE organization_invitations SET accepted_at=NOW() WHERE id=$1 AND accepted_at IS NULL", inv.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec(`INSERT INTO organization_members (org_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (org_id, user_id) DO NOTHING`, inv.OrgID, userID, inv.Role); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteOrgInvitation cancela um convite pendente
+func (r *PostgresRepo) DeleteOrgInvitation(orgID, id string) error {
+	res, err := r.DB.Exec("DELETE FROM organization_invitations WHERE id=$1 AND org_id=$2 AND accepted_at IS NULL", id, orgID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
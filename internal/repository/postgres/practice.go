@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"fmt"
+	"time"
+)
+
+// --- Practice (Simulados de Prática) Implementation ---
+
+// GetPracticeCandidates sorteia questões do banco acessíveis ao usuário (públicas ou de sua autoria)
+// conforme a estratégia. Subjects e topics, quando ambos informados, são combinados com AND.
+func (r *PostgresRepo) GetPracticeCandidates(userID string, opts domain.PracticeOptions) ([]domain.Question, error) {
+	args := []interface{}{userID}
+	argIndex := 2
+
+	inList := func(ids []string) string {
+		placeholders := ""
+		for i, id := range ids {
+			if i > 0 {
+				placeholders += ","
+			}
+			placeholders += fmt.Sprintf("$%d", argIndex)
+			args = append(args, id)
+			argIndex++
+		}
+		return placeholders
+	}
+
+	from := "questions q"
+	where := "(q.is_public = true OR q.created_by = $1)"
+	order := "random()"
+
+	if len(opts.SubjectIDs) > 0 {
+		where += fmt.Sprintf(" AND q.subject_id::text IN (%s)", inList(opts.SubjectIDs))
+	}
+	if len(opts.TopicIDs) > 0 {
+		where += fmt.Sprintf(" AND q.topic_id::text IN (%s)", inList(opts.TopicIDs))
+	}
+	if opts.AvoidLastN > 0 {
+		where += fmt.Sprintf(` AND q.id NOT IN (
+			SELECT af.question_id FROM answer_facts af
+			WHERE af.result_id IN (SELECT id FROM results WHERE user_id = $1 ORDER BY date DESC LIMIT $%d))`, argIndex)
+		args = append(args, opts.AvoidLastN)
+		argIndex++
+	}
+
+	switch opts.Strategy {
+	case domain.PracticeUnseen:
+		where += " AND NOT EXISTS (SELECT 1 FROM answer_facts af WHERE af.user_id = $1 AND af.question_id = q.id)"
+	case domain.PracticeWrong:
+		where += " AND EXISTS (SELECT 1 FROM answer_facts af WHERE af.user_id = $1 AND af.question_id = q.id AND NOT af.is_correct)"
+	case domain.PracticeWeakest:
+		// Tópicos sem histórico ficam depois dos tópicos com desempenho conhecido
+		from += ` LEFT JOIN (
+			SELECT topic_id, AVG(CASE WHEN is_correct THEN 1.0 ELSE 0.0 END) AS accuracy
+			FROM answer_facts WHERE user_id = $1 AND topic_id IS NOT NULL
+			GROUP BY topic_id) ts ON ts.topic_id = q.topic_id`
+		order = "ts.accuracy ASC NULLS LAST, random()"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT q.id, q.text, q.options, q.correct_index, q.explanation, q.subject_id, q.topic_id, q.is_public, q.is_verified
+		FROM %s
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d`, from, where, order, argIndex)
+	args = append(args, opts.Count)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	questions := []domain.Question{}
+	for rows.Next() {
+		var q domain.Question
+		var opt []byte
+		var explanation, subjectID, topicID sql.NullString
+		if err := rows.Scan(&q.ID, &q.Text, &opt, &q.CorrectIndex, &explanation, &subjectID, &topicID, &q.IsPublic, &q.IsVerified); err != nil { continue }
+		q.Explanation = explanation.String
+		q.SubjectID = subjectID.String
+		q.TopicID = topicID.String
+		json.Unmarshal(opt, &q.Options)
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
+
+// CreateExamFromBank cria um exame que apenas referencia questões já existentes,
+// sem o upsert de CreateExam (que sobrescreveria questões de outros autores)
+func (r *PostgresRepo) CreateExamFromBank(e domain.Exam, questionIDs []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sJSON, _ := json.Marshal(e.Subjects)
+	if e.CreatedAt == 0 {
+		e.CreatedAt = time.Now().UnixMilli()
+	}
+	_, err = tx.Exec(`INSERT INTO exams (id, title, description, subjects, time_limit, is_public, created_by, created_at,
+			adaptive_config, attempt_policy, cloned_from)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		e.ID, e.Title, e.Description, sJSON, e.TimeLimit, e.IsPublic, e.CreatedBy, time.UnixMilli(e.CreatedAt),
+		jsonOrNull(e.Adaptive), jsonOrNull(e.AttemptPolicy), nullString(e.ClonedFrom))
+	if err != nil {
+		return err
+	}
+
+	for _, qID := range questionIDs {
+		_, err = tx.Exec("INSERT INTO exam_questions (exam_id, question_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", e.ID, qID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
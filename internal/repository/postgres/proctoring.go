@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"fmt"
+	"time"
+)
+
+// --- Proctoring Implementation ---
+
+// CreateExamAttempt registra uma tentativa iniciada
+func (r *PostgresRepo) CreateExamAttempt(a domain.ExamAttempt) error {
+	_, err := r.DB.Exec(`INSERT INTO exam_attempts (id, exam_id, user_id, link_id, started_at) VALUES ($1, $2, $3, $4, $5)`,
+		a.ID, a.ExamID, nullString(a.UserID), nullString(a.LinkID), time.UnixMilli(a.StartedAt))
+	return err
+}
+
+// GetExamAttempt busca uma tentativa iniciada
+func (r *PostgresRepo) GetExamAttempt(id string) (domain.ExamAttempt, error) {
+	var a domain.ExamAttempt
+	var userID, linkID sql.NullString
+	var startedAt time.Time
+	var submittedAt sql.NullTime
+	err := r.DB.QueryRow(`SELECT id, exam_id, user_id, link_id, started_at, submitted_at FROM exam_attempts WHERE id=$1`, id).
+		Scan(&a.ID, &a.ExamID, &userID, &linkID, &startedAt, &submittedAt)
+	if err != nil {
+		return a, err
+	}
+	a.UserID = userID.String
+	a.LinkID = linkID.String
+	a.StartedAt = startedAt.UnixMilli()
+	if submittedAt.Valid {
+		a.SubmittedAt = submittedAt.Time.UnixMilli()
+	}
+	return a, nil
+}
+
+// DeleteStaleExamAttempts remove tentativas iniciadas há mais de uma semana e nunca submetidas
+func (r *PostgresRepo) DeleteStaleExamAttempts() error {
+	_, err := r.DB.Exec(`DELETE FROM exam_attempts WHERE submitted_at IS NULL AND started_at < NOW() - INTERVAL '7 days'`)
+	return err
+}
+
+// CreateProctoringEvents grava um lote de eventos de integridade em uma única transação
+func (r *PostgresRepo) CreateProctoringEvents(events []domain.ProctoringEvent) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, e := range events {
+		var details interface{} // NULL quando não há detalhes
+		if len(e.Details) > 0 {
+			details, _ = json.Marshal(e.Details)
+		}
+		_, err := tx.Exec(`INSERT INTO proctoring_events
+				(id, attempt_id, exam_id, link_id, user_id, type, details, ip, user_agent, occurred_at, received_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			e.ID, e.AttemptID, e.ExamID, nullString(e.LinkID), nullString(e.UserID), e.Type, details,
+			nullString(e.IP), nullString(e.UserAgent), time.UnixMilli(e.OccurredAt), time.UnixMilli(e.ReceivedAt))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetLastProctoringIP retorna o IP do último evento recebido na tentativa ("" se não houver)
+func (r *PostgresRepo) GetLastProctoringIP(attemptID string) (string, error) {
+	var ip sql.NullString
+	err := r.DB.QueryRow(`SELECT ip FROM proctoring_events WHERE attempt_id=$1 AND ip IS NOT NULL
+		ORDER BY received_at DESC LIMIT 1`, attemptID).Scan(&ip)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return ip.String, err
+}
+
+// AttachProctoringEvents associa os eventos ainda pendentes da tentativa ao resultado submetido
+func (r *PostgresRepo) AttachProctoringEvents(attemptID, examID, resultID string) error {
+	_, err := r.DB.Exec(`UPDATE proctoring_events SET result_id=$3
+		WHERE attempt_id=$1 AND exam_id=$2 AND result_id IS NULL`, attemptID, examID, resultID)
+	return err
+}
+
+// GetProctoringEvents lista os eventos associados a um resultado, em ordem cronológica
+func (r *PostgresRepo) GetProctoringEvents(resultID string) ([]domain.ProctoringEvent, error) {
+	rows, err := r.DB.Query(`SELECT id, attempt_id, exam_id, link_id, user_id, result_id, type, details, ip, user_agent,
+			occurred_at, received_at
+		FROM proctoring_events WHERE result_id=$1 ORDER BY occurred_at`, resultID)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	events := []domain.ProctoringEvent{}
+	for rows.Next() {
+		var e domain.ProctoringEvent
+		var linkID, userID, resID, ip, userAgent sql.NullString
+		var details []byte
+		var occurredAt, receivedAt time.Time
+		if err := rows.Scan(&e.ID, &e.AttemptID, &e.ExamID, &linkID, &userID, &resID, &e.Type, &details, &ip, &userAgent,
+			&occurredAt, &receivedAt); err != nil { continue }
+		e.LinkID = linkID.String
+		e.UserID = userID.String
+		e.ResultID = resID.String
+		e.IP = ip.String
+		e.UserAgent = userAgent.String
+		if len(details) > 0 { json.Unmarshal(details, &e.Details) }
+		e.OccurredAt = occurredAt.UnixMilli()
+		e.ReceivedAt = receivedAt.UnixMilli()
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// GetProctoringCounts retorna a contagem de eventos por tipo para cada resultado informado
+func (r *PostgresRepo) GetProctoringCounts(resultIDs []string) (map[string]map[string]int, error) {
+	counts := make(map[string]map[string]int)
+	if len(resultIDs) == 0 {
+		return counts, nil
+	}
+	placeholders := ""
+	args := make([]interface{}, len(resultIDs))
+	for i, id := range resultIDs {
+		if i > 0 {
+			placeholders += ","
+		}
+		placeholders += fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	rows, err := r.DB.Query(fmt.Sprintf(`SELECT result_id, type, COUNT(*) FROM proctoring_events
+		WHERE result_id::text IN (%s) GROUP BY result_id, type`, placeholders), args...)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	for rows.Next() {
+		var resultID, eventType string
+		var n int
+		if err := rows.Scan(&resultID, &eventType, &n); err != nil { continue }
+		if counts[resultID] == nil {
+			counts[resultID] = make(map[string]int)
+		}
+		counts[resultID][eventType] = n
+	}
+	return counts, nil
+}
+
+// ResultBelongsToCompany verifica se o resultado foi obtido por um link da organização
+func (r *PostgresRepo) ResultBelongsToCompany(resultID, orgID string) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(`SELECT EXISTS (
+			SELECT 1 FROM results r
+			JOIN public_links pl ON pl.id = r.link_id
+			WHERE r.id = $1 AND pl.org_id = $2
+		)`, resultID, orgID).Scan(&exists)
+	return exists, err
+}
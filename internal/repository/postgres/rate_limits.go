@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"strings"
+	"time"
+)
+
+// --- Rate limit (token bucket compartilhado entre réplicas) ---
+
+// rateLimitRefill é o saldo do balde existente reabastecido até $4 ($2: capacidade, $3: tokens por segundo)
+const rateLimitRefill = `LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4::timestamptz - b.updated_at))::float8, 0) * $3::float8)`
+
+// takeRateLimitTokenQuery insere o balde já com um token consumido ou atualiza o existente
+var takeRateLimitTokenQuery = strings.ReplaceAll(`
+	INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, full_at, allowed)
+	VALUES ($1, $2::float8 - 1, $4::timestamptz, $4::timestamptz + make_interval(secs => 1 / $3::float8), TRUE)
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = REFILL - CASE WHEN REFILL >= 1 THEN 1 ELSE 0 END,
+		allowed = REFILL >= 1,
+		updated_at = GREATEST(b.updated_at, $4::timestamptz),
+		full_at = GREATEST(b.updated_at, $4::timestamptz) + make_interval(secs => ($2::float8 - REFILL + CASE WHEN REFILL >= 1 THEN 1 ELSE 0 END) / $3::float8)
+	RETURNING tokens, allowed`, "REFILL", rateLimitRefill)
+
+// TakeRateLimitToken reabastece o balde key até now e consome um token se houver, numa única instrução
+// (o upsert bloqueia a linha, então réplicas concorrentes não consomem o mesmo token).
+// Retorna os tokens restantes e se a requisição foi aceita.
+func (r *PostgresRepo) TakeRateLimitToken(key string, capacity int, window time.Duration, now time.Time) (float64, bool, error) {
+	rate := float64(capacity) / window.Seconds()
+	var tokens float64
+	var allowed bool
+	err := r.DB.QueryRow(takeRateLimitTokenQuery, key, capacity, rate, now).Scan(&tokens, &allowed)
+	return tokens, allowed, err
+}
+
+// DeleteFullRateLimitBuckets remove baldes já reabastecidos (equivalentes a um balde novo)
+func (r *PostgresRepo) DeleteFullRateLimitBuckets() error {
+	_, err := r.DB.Exec("DELETE FROM rate_limit_buckets WHERE full_at < NOW()")
+	return err
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"esimulate-backend/internal/domain"
 	"fmt"
 	"time"
@@ -20,6 +21,16 @@ func NewPostgresRepo(db *sql.DB) *PostgresRepo {
 	return &PostgresRepo{DB: db}
 }
 
+// nullString converte string vazia em NULL (colunas UUID opcionais)
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullInt converte 0 em NULL
+func nullInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
+
 // --- User Implementation ---
 
 func (r *PostgresRepo) CreateUser(u domain.User) (domain.User, error) {
@@ -167,7 +178,13 @@ func (r *PostgresRepo) DeleteUser(id string) error {
 
 // --- Exam Implementation ---
 
-func (r *PostgresRepo) CreateExam(e domain.Exam) error {
+// ErrQuestionNotOwned indica uma questão de outro autor enviada no exame sem que o usuário tenha acesso a ela
+var ErrQuestionNotOwned = errors.New("questão pertence a outro autor")
+
+// CreateExam cria ou atualiza o exame e suas questões. actorID é quem envia a alteração (o dono do exame fica em e.CreatedBy).
+// Questões existentes só são alteradas pelo autor, por admin ou, se usadas apenas neste exame, pelos autores do exame;
+// as demais são mantidas como estão se já estavam no exame ou são públicas, e rejeitadas com ErrQuestionNotOwned caso contrário.
+func (r *PostgresRepo) CreateExam(e domain.Exam, actorID string, isAdmin bool) error {
 	// Iniciar transação
 	tx, err := r.DB.Begin()
 	if err != nil {
@@ -184,25 +201,54 @@ func (r *PostgresRepo) CreateExam(e domain.Exam) error {
 	
 	// 1. Criar/Atualizar exame (sem campo questions JSONB - usando apenas exam_questions)
 	// is_verified removido: será calculado baseado nas questões (todas devem estar verificadas)
-	query := `INSERT INTO exams (id, title, description, subjects, time_limit, is_public, created_by, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	if e.Status == "" {
+		e.Status = domain.ExamPublished
+	}
+	
+	// is_template só é alterado por SetExamTemplate; cloned_from, status e org_id só são gravados na criação
+	// (o estado muda apenas pelos endpoints de transição). Exames excluídos não são alterados (sql.ErrNoRows).
+	query := `INSERT INTO exams (id, title, description, subjects, time_limit, is_public, created_by, created_at, adaptive_config, attempt_policy, cloned_from, status, org_id, availability)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		ON CONFLICT (id) DO UPDATE SET 
 			title=$2, 
 			description=$3, 
 			subjects=$4,
 			time_limit=$5,
 			is_public=$6,
-			updated_at=NOW()`
-	_, err = tx.Exec(query, e.ID, e.Title, e.Description, sJSON, e.TimeLimit, e.IsPublic, e.CreatedBy, createdAtTime)
+			adaptive_config=$9,
+			attempt_policy=$10,
+			availability=$14,
+			updated_at=NOW()
+		WHERE exams.deleted_at IS NULL`
+	res, err := tx.Exec(query, e.ID, e.Title, e.Description, sJSON, e.TimeLimit, e.IsPublic, e.CreatedBy, createdAtTime,
+		jsonOrNull(e.Adaptive), jsonOrNull(e.AttemptPolicy), nullString(e.ClonedFrom), e.Status, nullString(e.OrgID), jsonOrNull(e.Availability))
 	if err != nil {
 		return err
 	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
 	
-	// 2. Remover relacionamentos antigos (se for update)
-	_, err = tx.Exec("DELETE FROM exam_questions WHERE exam_id=$1", e.ID)
+	// 2. Remover relacionamentos antigos (se for update), guardando as questões que já estavam no exame
+	linked := make(map[string]bool)
+	rows, err := tx.Query("DELETE FROM exam_questions WHERE exam_id=$1 RETURNING question_id", e.ID)
 	if err != nil {
 		return err
 	}
+	for rows.Next() {
+		var qID string
+		if err := rows.Scan(&qID); err != nil {
+			rows.Close()
+			return err
+		}
+		linked[qID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
 	
 	// 3. Para cada questão: fazer upsert na tabela questions e criar relacionamento
 	for _, q := range e.Questions {
@@ -223,8 +269,10 @@ func (r *PostgresRepo) CreateExam(e domain.Exam) error {
 			topicID.Valid = true
 		}
 		
-		upsertQuery := `INSERT INTO questions (id, text, options, correct_index, explanation, subject_id, topic_id, is_public, is_verified)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		// created_by só é gravado na inserção: editar a questão em outro exame não transfere a autoria.
+		// A atualização só vale para o autor da questão, admin ou questões do dono do exame usadas apenas neste exame.
+		upsertQuery := `INSERT INTO questions (id, text, options, correct_index, explanation, subject_id, topic_id, is_public, is_verified, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			ON CONFLICT (id) DO UPDATE SET 
 				text=$2, 
 				options=$3, 
@@ -234,11 +282,24 @@ func (r *PostgresRepo) CreateExam(e domain.Exam) error {
 				topic_id=$7,
 				is_public=$8,
 				is_verified=$9,
-				updated_at=NOW()`
-		_, err = tx.Exec(upsertQuery, q.ID, q.Text, optJSON, q.CorrectIndex, q.Explanation, subjectID, topicID, q.IsPublic, q.IsVerified)
+				updated_at=NOW()
+			WHERE questions.created_by = $11 OR $12
+				OR (questions.created_by = $10 AND NOT EXISTS (
+					SELECT 1 FROM exam_questions eq WHERE eq.question_id = questions.id AND eq.exam_id <> $13))`
+		res, err = tx.Exec(upsertQuery, q.ID, q.Text, optJSON, q.CorrectIndex, q.Explanation, subjectID, topicID, q.IsPublic, q.IsVerified,
+			nullString(e.CreatedBy), nullString(actorID), isAdmin, e.ID)
 		if err != nil {
 			return err
 		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 && !linked[q.ID] {
+			// Questão de outro autor: só pode ser referenciada se for pública
+			var isPublic bool
+			if err := tx.QueryRow("SELECT is_public FROM questions WHERE id=$1", q.ID).Scan(&isPublic); err != nil || !isPublic {
+				return ErrQuestionNotOwned
+			}
+		}
 		
 		// Criar relacionamento exam_questions
 		_, err = tx.Exec("INSERT INTO exam_questions (exam_id, question_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", e.ID, q.ID)
@@ -255,8 +316,10 @@ func (r *PostgresRepo) GetExams() ([]domain.Exam, error) {
 	// Buscar exames (sem questions - usando apenas exam_questions)
 	// is_verified removido: será calculado baseado nas questões
 	rows, err := r.DB.Query(`
-		SELECT id, title, description, subjects, time_limit, is_public, created_by, created_at 
+		SELECT id, title, description, subjects, time_limit, is_public, created_by, created_at, adaptive_config, attempt_policy,
+			is_template, cloned_from, status, deleted_at, org_id, availability
 		FROM exams 
+		WHERE is_active = TRUE AND status <> 'archived'
 		ORDER BY created_at DESC`)
 	if err != nil { return nil, err }
 	defer rows.Close()
@@ -270,8 +333,20 @@ func (r *PostgresRepo) GetExams() ([]domain.Exam, error) {
 		var timeLimit sql.NullInt64
 		var createdAt time.Time
 		var createdBy string
-		rows.Scan(&e.ID, &e.Title, &e.Description, &s, &timeLimit, &e.IsPublic, &createdBy, &createdAt)
+		var adaptive, attemptPolicy, availability []byte
+		var clonedFrom, orgID sql.NullString
+		var deletedAt sql.NullTime
+		rows.Scan(&e.ID, &e.Title, &e.Description, &s, &timeLimit, &e.IsPublic, &createdBy, &createdAt, &adaptive, &attemptPolicy,
+			&e.IsTemplate, &clonedFrom, &e.Status, &deletedAt, &orgID, &availability)
+		e.ClonedFrom = clonedFrom.String
+		e.OrgID = orgID.String
+		if deletedAt.Valid {
+			e.DeletedAt = deletedAt.Time.UnixMilli()
+		}
 		e.CreatedAt = createdAt.UnixMilli()
+		e.Adaptive = parseJSONPtr[domain.AdaptiveConfig](adaptive)
+		e.AttemptPolicy = parseJSONPtr[domain.AttemptPolicy](attemptPolicy)
+		e.Availability = parseJSONPtr[domain.Availability](availability)
 		e.CreatedBy = createdBy
 		if timeLimit.Valid {
 			e.TimeLimit = int(timeLimit.Int64)
@@ -335,24 +410,41 @@ func (r *PostgresRepo) GetExams() ([]domain.Exam, error) {
 	return exams, nil
 }
 
-func (r *PostgresRepo) GetExamsByUser(userID string, publicOnly bool, ownerOnly bool) ([]domain.Exam, error) {
+// GetExamsByUser lista os exames visíveis ao usuário.
+// status vazio lista exames ativos não arquivados (rascunhos apenas do próprio usuário);
+// status draft|published|archived|deleted lista somente os exames do usuário naquele estado.
+func (r *PostgresRepo) GetExamsByUser(userID string, publicOnly bool, ownerOnly bool, status string, orgID string) ([]domain.Exam, error) {
 	// Construir query baseada nos filtros
 	// is_verified removido: será calculado baseado nas questões
-	query := `SELECT e.id, e.title, e.description, e.subjects, e.time_limit, e.is_public, e.created_by, e.created_at FROM exams e WHERE 1=1`
-	args := []interface{}{}
-	argIndex := 1
+	// $1 é sempre o usuário; o papel concedido a ele (direto ou via grupo) vem em shared_role
+	sharedRole := shareRoleSQL("e.id", "$1")
+	query := `SELECT e.id, e.title, e.description, e.subjects, e.time_limit, e.is_public, e.created_by, e.created_at, e.adaptive_config, e.attempt_policy,
+		e.is_template, e.cloned_from, e.status, e.deleted_at, e.org_id, e.availability, ` + sharedRole + ` AS shared_role FROM exams e WHERE 1=1`
+	args := []interface{}{userID}
 	
+	switch {
+	case status == "deleted":
+		// Apenas o autor vê seus exames excluídos
+		query += " AND e.is_active = FALSE AND e.created_by=$1"
+	case status != "":
+		// Filtro por estado: exames do usuário ou compartilhados com ele
+		query += " AND e.is_active = TRUE AND e.status=$2 AND (e.created_by=$1 OR " + sharedRole + " IS NOT NULL)"
+		args = append(args, status)
+	default:
+		query += " AND e.is_active = TRUE AND e.status <> 'archived'"
+		if publicOnly && !ownerOnly {
+			query += " AND e.is_public=true AND e.status='published'"
+		} else if !ownerOnly {
+			// Padrão: exames do usuário, compartilhados com ele OU exames públicos publicados
+			query += " AND (e.created_by=$1 OR " + sharedRole + " IS NOT NULL OR (e.is_public=true AND e.status='published'))"
+		}
+	}
 	if ownerOnly {
-		query += fmt.Sprintf(" AND e.created_by=$%d", argIndex)
-		args = append(args, userID)
-		argIndex++
-	} else if publicOnly {
-		query += " AND e.is_public=true"
-	} else {
-		// Padrão: exames do usuário OU exames públicos
-		query += fmt.Sprintf(" AND (e.created_by=$%d OR e.is_public=true)", argIndex)
-		args = append(args, userID)
-		argIndex++
+		query += " AND e.created_by=$1"
+	}
+	if orgID != "" {
+		query += fmt.Sprintf(" AND e.org_id=$%d", len(args)+1)
+		args = append(args, orgID)
 	}
 	
 	query += " ORDER BY e.created_at DESC"
@@ -370,8 +462,25 @@ func (r *PostgresRepo) GetExamsByUser(userID string, publicOnly bool, ownerOnly
 		var timeLimit sql.NullInt64
 		var createdAt time.Time
 		var createdBy string
-		rows.Scan(&e.ID, &e.Title, &e.Description, &s, &timeLimit, &e.IsPublic, &createdBy, &createdAt)
+		var adaptive, attemptPolicy, availability []byte
+		var clonedFrom, orgID, sharedRole sql.NullString
+		var deletedAt sql.NullTime
+		rows.Scan(&e.ID, &e.Title, &e.Description, &s, &timeLimit, &e.IsPublic, &createdBy, &createdAt, &adaptive, &attemptPolicy,
+			&e.IsTemplate, &clonedFrom, &e.Status, &deletedAt, &orgID, &availability, &sharedRole)
+		e.ClonedFrom = clonedFrom.String
+		e.OrgID = orgID.String
+		if createdBy == userID {
+			e.AccessRole = domain.ShareOwner
+		} else {
+			e.AccessRole = sharedRole.String
+		}
+		if deletedAt.Valid {
+			e.DeletedAt = deletedAt.Time.UnixMilli()
+		}
 		e.CreatedBy = createdBy
+		e.Adaptive = parseJSONPtr[domain.AdaptiveConfig](adaptive)
+		e.AttemptPolicy = parseJSONPtr[domain.AttemptPolicy](attemptPolicy)
+		e.Availability = parseJSONPtr[domain.Availability](availability)
 		e.CreatedAt = createdAt.UnixMilli()
 		if timeLimit.Valid {
 			e.TimeLimit = int(timeLimit.Int64)
@@ -441,17 +550,30 @@ func (r *PostgresRepo) GetExamByID(id string) (domain.Exam, error) {
 	var s []byte
 	var timeLimit sql.NullInt64
 	var createdAt time.Time
+	var adaptive, attemptPolicy, availability []byte
+	var clonedFrom, orgID sql.NullString
+	var deletedAt sql.NullTime
 	
-	// Buscar exame
+	// Buscar exame (inclusive arquivados e excluídos: quem chama decide o acesso)
 	// is_verified removido: será calculado baseado nas questões
 	err := r.DB.QueryRow(`
-		SELECT id, title, description, subjects, time_limit, is_public, created_by, created_at 
+		SELECT id, title, description, subjects, time_limit, is_public, created_by, created_at, adaptive_config, attempt_policy,
+			is_template, cloned_from, status, deleted_at, org_id, availability
 		FROM exams 
 		WHERE id=$1`, id).
-		Scan(&e.ID, &e.Title, &e.Description, &s, &timeLimit, &e.IsPublic, &e.CreatedBy, &createdAt)
+		Scan(&e.ID, &e.Title, &e.Description, &s, &timeLimit, &e.IsPublic, &e.CreatedBy, &createdAt, &adaptive, &attemptPolicy,
+			&e.IsTemplate, &clonedFrom, &e.Status, &deletedAt, &orgID, &availability)
 	if err != nil {
 		return e, err
 	}
+	e.ClonedFrom = clonedFrom.String
+	e.OrgID = orgID.String
+	if deletedAt.Valid {
+		e.DeletedAt = deletedAt.Time.UnixMilli()
+	}
+	e.Adaptive = parseJSONPtr[domain.AdaptiveConfig](adaptive)
+	e.AttemptPolicy = parseJSONPtr[domain.AttemptPolicy](attemptPolicy)
+	e.Availability = parseJSONPtr[domain.Availability](availability)
 	
 	e.CreatedAt = createdAt.UnixMilli()
 	if timeLimit.Valid {
@@ -487,6 +609,29 @@ func (r *PostgresRepo) GetExamByID(id string) (domain.Exam, error) {
 	return e, nil
 }
 
+// jsonOrNull serializa configurações opcionais em JSONB (nil grava NULL)
+func jsonOrNull[T any](v *T) interface{} {
+	if v == nil {
+		return nil
+	}
+	b, _ := json.Marshal(v)
+	return b
+}
+
+// parseJSONPtr lê uma coluna JSONB opcional (NULL retorna nil)
+func parseJSONPtr[T any](data []byte) *T {
+	if len(data) == 0 {
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	return &v
+}
+
+// DeleteExam remove o exame definitivamente (com resultados em cascata).
+// Usado apenas pela purga de exames excluídos; a exclusão pela API é SoftDeleteExam.
 func (r *PostgresRepo) DeleteExam(id string) error {
 	_, err := r.DB.Exec("DELETE FROM exams WHERE id=$1", id)
 	return err
@@ -494,7 +639,9 @@ func (r *PostgresRepo) DeleteExam(id string) error {
 
 // --- Question Implementation ---
 
-func (r *PostgresRepo) CreateQuestion(q domain.Question) error {
+// CreateQuestion cria a questão ou, se o id já existir, a atualiza. created_by só é gravado na inserção e
+// a atualização só vale para o autor da questão ou admin; caso contrário retorna ErrQuestionNotOwned.
+func (r *PostgresRepo) CreateQuestion(q domain.Question, isAdmin bool) error {
 	optJSON, _ := json.Marshal(q.Options)
 	
 	// Preparar subject_id e topic_id (podem ser NULL)
@@ -508,8 +655,8 @@ func (r *PostgresRepo) CreateQuestion(q domain.Question) error {
 		topicID.Valid = true
 	}
 	
-	query := `INSERT INTO questions (id, text, options, correct_index, explanation, subject_id, topic_id, is_public, is_verified)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	query := `INSERT INTO questions (id, text, options, correct_index, explanation, subject_id, topic_id, is_public, is_verified, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (id) DO UPDATE SET 
 			text=$2, 
 			options=$3, 
@@ -518,9 +665,19 @@ func (r *PostgresRepo) CreateQuestion(q domain.Question) error {
 			subject_id=$6, 
 			topic_id=$7,
 			is_public=$8,
-			is_verified=$9`
-	_, err := r.DB.Exec(query, q.ID, q.Text, optJSON, q.CorrectIndex, q.Explanation, subjectID, topicID, q.IsPublic, q.IsVerified)
-	return err
+			is_verified=$9,
+			updated_at=NOW()
+		WHERE questions.created_by = $10 OR $11`
+	res, err := r.DB.Exec(query, q.ID, q.Text, optJSON, q.CorrectIndex, q.Explanation, subjectID, topicID, q.IsPublic, q.IsVerified, nullString(q.CreatedBy), isAdmin)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrQuestionNotOwned
+	}
+	return nil
 }
 
 func (r *PostgresRepo) GetQuestions() ([]domain.Question, error) {
@@ -546,6 +703,13 @@ func (r *PostgresRepo) GetQuestions() ([]domain.Question, error) {
 	return questions, nil
 }
 
+// GetQuestionOwner retorna o autor da questão ("" para questões sem autor); sql.ErrNoRows se ela não existir
+func (r *PostgresRepo) GetQuestionOwner(id string) (string, error) {
+	var createdBy sql.NullString
+	err := r.DB.QueryRow("SELECT created_by FROM questions WHERE id=$1", id).Scan(&createdBy)
+	return createdBy.String, err
+}
+
 func (r *PostgresRepo) DeleteQuestion(id string) error {
 	_, err := r.DB.Exec("DELETE FROM questions WHERE id=$1", id)
 	return err
@@ -553,19 +717,54 @@ func (r *PostgresRepo) DeleteQuestion(id string) error {
 
 // --- Result Implementation ---
 
-func (r *PostgresRepo) CreateResult(res domain.ExamResult) error {
+// insertResult grava o resultado na transação de CreateResultWithinPolicy. A data é sempre a do servidor (res.Date é ignorado).
+func insertResult(tx *sql.Tx, res domain.ExamResult) error {
 	ansJSON, _ := json.Marshal(res.Answers)
 	var userID sql.NullString
 	if res.UserID != "" { userID.String = res.UserID; userID.Valid = true }
+	var ability interface{} // NULL para resultados não adaptativos
+	if res.Ability != nil { ability, _ = json.Marshal(res.Ability) }
 
-	query := `INSERT INTO results (id, exam_id, user_id, candidate_name, candidate_email, score, total_questions, answers, time_spent_seconds, date)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
-	_, err := r.DB.Exec(query, res.ID, res.ExamID, userID, res.CandidateName, res.CandidateEmail, res.Score, res.TotalQuestions, ansJSON, res.TimeSpentSeconds, res.Date)
+	query := `INSERT INTO results (id, exam_id, user_id, candidate_name, candidate_email, score, total_questions, answers, time_spent_seconds, date, ability, link_id,
+			attempt_id, submit_ip, user_agent, assignment_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), $10, $11, $12, $13, $14, $15)`
+	_, err := tx.Exec(query, res.ID, res.ExamID, userID, res.CandidateName, res.CandidateEmail, res.Score, res.TotalQuestions, ansJSON, res.TimeSpentSeconds, ability, nullString(res.LinkID),
+		nullString(res.AttemptID), nullString(res.SubmitIP), nullString(res.UserAgent), nullString(res.AssignmentID))
 	return err
 }
 
+// GetRecordedScore retorna os acertos do resultado registrados em answer_facts na submissão,
+// com o gabarito vigente naquele momento (edições posteriores do exame não alteram a contagem)
+func (r *PostgresRepo) GetRecordedScore(resultID string) (int, error) {
+	var score int
+	err := r.DB.QueryRow("SELECT COUNT(*) FILTER (WHERE is_correct) FROM answer_facts WHERE result_id=$1", resultID).Scan(&score)
+	return score, err
+}
+
+// GetResultByID retorna um resultado com os dados de identificação (sem as respostas)
+func (r *PostgresRepo) GetResultByID(id string) (domain.ExamResult, error) {
+	var res domain.ExamResult
+	var userID, linkID, name, email, attemptID sql.NullString
+	var date time.Time
+	var ability []byte
+	err := r.DB.QueryRow(`SELECT r.id, r.exam_id, r.user_id, r.link_id, r.candidate_name, r.candidate_email, r.score, r.total_questions,
+			r.time_spent_seconds, r.date, e.title, r.ability, r.attempt_id
+		FROM results r JOIN exams e ON r.exam_id = e.id WHERE r.id=$1`, id).
+		Scan(&res.ID, &res.ExamID, &userID, &linkID, &name, &email, &res.Score, &res.TotalQuestions,
+			&res.TimeSpentSeconds, &date, &res.ExamTitle, &ability, &attemptID)
+	if err != nil { return res, err }
+	res.UserID = userID.String
+	res.LinkID = linkID.String
+	res.AttemptID = attemptID.String
+	res.CandidateName = name.String
+	res.CandidateEmail = email.String
+	res.Date = date.UnixMilli()
+	if len(ability) > 0 { json.Unmarshal(ability, &res.Ability) }
+	return res, nil
+}
+
 func (r *PostgresRepo) GetResultsByUser(userID string) ([]domain.ExamResult, error) {
-	query := `SELECT r.id, r.exam_id, r.score, r.total_questions, r.time_spent_seconds, r.date, e.title 
+	query := `SELECT r.id, r.exam_id, r.score, r.total_questions, r.time_spent_seconds, r.date, e.title, r.ability 
 		FROM results r JOIN exams e ON r.exam_id = e.id WHERE r.user_id=$1 ORDER BY r.date DESC`
 	rows, err := r.DB.Query(query, userID)
 	if err != nil { return nil, err }
@@ -573,26 +772,42 @@ func (r *PostgresRepo) GetResultsByUser(userID string) ([]domain.ExamResult, err
 	var results []domain.ExamResult
 	for rows.Next() {
 		var res domain.ExamResult
-		rows.Scan(&res.ID, &res.ExamID, &res.Score, &res.TotalQuestions, &res.TimeSpentSeconds, &res.Date, &res.ExamTitle)
+		var ability []byte
+		var date time.Time
+		rows.Scan(&res.ID, &res.ExamID, &res.Score, &res.TotalQuestions, &res.TimeSpentSeconds, &date, &res.ExamTitle, &ability)
+		res.Date = date.UnixMilli()
+		if len(ability) > 0 { json.Unmarshal(ability, &res.Ability) }
 		results = append(results, res)
 	}
 	return results, nil
 }
 
-func (r *PostgresRepo) GetCompanyResults(companyID string) ([]domain.ExamResult, error) {
-	query := `SELECT r.id, r.candidate_name, r.candidate_email, r.score, r.total_questions, r.date, e.title
+// GetCompanyResults retorna os resultados de candidatos obtidos pelos links da organização
+func (r *PostgresRepo) GetCompanyResults(orgID string) ([]domain.ExamResult, error) {
+	query := `SELECT r.id, r.exam_id, r.candidate_name, r.candidate_email, r.score, r.total_questions, r.date, e.title, r.ability,
+			r.link_id, r.attempt_id, r.submit_ip, r.user_agent
 		FROM results r
-		JOIN public_links pl ON pl.exam_id = r.exam_id
+		JOIN public_links pl ON pl.id = r.link_id
 		JOIN exams e ON r.exam_id = e.id
-		WHERE pl.company_id = $1 AND r.candidate_name IS NOT NULL
+		WHERE pl.org_id = $1 AND r.candidate_name IS NOT NULL
 		ORDER BY r.date DESC`
-	rows, err := r.DB.Query(query, companyID)
+	rows, err := r.DB.Query(query, orgID)
 	if err != nil { return nil, err }
 	defer rows.Close()
 	var results []domain.ExamResult
 	for rows.Next() {
 		var res domain.ExamResult
-		rows.Scan(&res.ID, &res.CandidateName, &res.CandidateEmail, &res.Score, &res.TotalQuestions, &res.Date, &res.ExamTitle)
+		var ability []byte
+		var date time.Time
+		var linkID, attemptID, submitIP, userAgent sql.NullString
+		rows.Scan(&res.ID, &res.ExamID, &res.CandidateName, &res.CandidateEmail, &res.Score, &res.TotalQuestions, &date, &res.ExamTitle, &ability,
+			&linkID, &attemptID, &submitIP, &userAgent)
+		res.Date = date.UnixMilli()
+		res.LinkID = linkID.String
+		res.AttemptID = attemptID.String
+		res.SubmitIP = submitIP.String
+		res.UserAgent = userAgent.String
+		if len(ability) > 0 { json.Unmarshal(ability, &res.Ability) }
 		results = append(results, res)
 	}
 	return results, nil
@@ -605,14 +820,15 @@ func (r *PostgresRepo) CreateLink(l domain.PublicLink) error {
 		// Converter milissegundos para timestamp
 		expiresAt = time.Unix(l.ExpiresAt/1000, 0)
 	}
-	_, err := r.DB.Exec(`INSERT INTO public_links (id, exam_id, company_id, token, label, active, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-		l.ID, l.ExamID, l.CompanyID, l.Token, l.Label, l.Active, expiresAt, time.Unix(l.CreatedAt/1000, 0))
+	_, err := r.DB.Exec(`INSERT INTO public_links (id, exam_id, company_id, token, label, active, expires_at, created_at, attempt_policy, flag_threshold, org_id, availability) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		l.ID, l.ExamID, l.CompanyID, l.Token, l.Label, l.Active, expiresAt, time.Unix(l.CreatedAt/1000, 0), jsonOrNull(l.AttemptPolicy), nullInt(l.FlagThreshold), nullString(l.OrgID), jsonOrNull(l.Availability))
 	return err
 }
 
-func (r *PostgresRepo) GetLinks(companyID string) ([]domain.PublicLink, error) {
-	rows, err := r.DB.Query(`SELECT pl.id, pl.exam_id, pl.token, pl.label, pl.active, pl.expires_at, pl.created_at, e.title 
-		FROM public_links pl JOIN exams e ON pl.exam_id = e.id WHERE pl.company_id=$1`, companyID)
+// GetLinks lista os links da organização
+func (r *PostgresRepo) GetLinks(orgID string) ([]domain.PublicLink, error) {
+	rows, err := r.DB.Query(`SELECT pl.id, pl.exam_id, pl.company_id, pl.token, pl.label, pl.active, pl.expires_at, pl.created_at, e.title, pl.attempt_policy, pl.flag_threshold, pl.availability
+		FROM public_links pl JOIN exams e ON pl.exam_id = e.id WHERE pl.org_id=$1`, orgID)
 	if err != nil { return nil, err }
 	defer rows.Close()
 	var links []domain.PublicLink
@@ -620,8 +836,14 @@ func (r *PostgresRepo) GetLinks(companyID string) ([]domain.PublicLink, error) {
 		var l domain.PublicLink
 		var expiresAt sql.NullTime
 		var createdAt time.Time
-		err := rows.Scan(&l.ID, &l.ExamID, &l.Token, &l.Label, &l.Active, &expiresAt, &createdAt, &l.ExamTitle)
+		var attemptPolicy, availability []byte
+		var flagThreshold sql.NullInt64
+		err := rows.Scan(&l.ID, &l.ExamID, &l.CompanyID, &l.Token, &l.Label, &l.Active, &expiresAt, &createdAt, &l.ExamTitle, &attemptPolicy, &flagThreshold, &availability)
 		if err != nil { continue }
+		l.FlagThreshold = int(flagThreshold.Int64)
+		l.OrgID = orgID
+		l.AttemptPolicy = parseJSONPtr[domain.AttemptPolicy](attemptPolicy)
+		l.Availability = parseJSONPtr[domain.Availability](availability)
 		if expiresAt.Valid {
 			l.ExpiresAt = expiresAt.Time.UnixMilli()
 		}
@@ -657,6 +879,14 @@ func (r *PostgresRepo) MarkTokenAsUsed(token string) error {
 	return err
 }
 
+// ConsumeToken exclui o token do tipo informado e retorna o dono (uso único, seguro contra requisições concorrentes)
+func (r *PostgresRepo) ConsumeToken(token, tokenType string) (string, time.Time, error) {
+	var userID string
+	var expiresAt time.Time
+	err := r.DB.QueryRow("DELETE FROM tokens WHERE token=$1 AND type=$2 RETURNING user_id, expires_at", token, tokenType).Scan(&userID, &expiresAt)
+	return userID, expiresAt, err
+}
+
 // InvalidateRefreshToken invalida um refresh token específico (usado no logout)
 func (r *PostgresRepo) InvalidateRefreshToken(token string) error {
 	_, err := r.DB.Exec("DELETE FROM tokens WHERE token=$1 AND type='refresh_token'", token)
@@ -689,8 +919,22 @@ func (r *PostgresRepo) GetActiveRefreshTokensCount(userID string) (int, error) {
 	return count, err
 }
 
-// RevokeOldRefreshTokens revoga os refresh tokens mais antigos de um usuário, mantendo apenas os N mais recentes
+// RevokeOldRefreshTokens revoga as sessões e refresh tokens mais antigos de um usuário, mantendo apenas os N mais recentes
 func (r *PostgresRepo) RevokeOldRefreshTokens(userID string, keepCount int) error {
+	// Sessões usadas há mais tempo (os refresh tokens delas são excluídos em cascata)
+	_, err := r.DB.Exec(`
+		DELETE FROM user_sessions
+		WHERE user_id=$1
+		AND id NOT IN (
+			SELECT id FROM user_sessions
+			WHERE user_id=$1
+			ORDER BY last_used_at DESC
+			LIMIT $2
+		)`, userID, keepCount)
+	if err != nil {
+		return err
+	}
+
 	// Deletar tokens antigos, mantendo apenas os N mais recentes
 	query := `
 		DELETE FROM tokens 
@@ -705,13 +949,13 @@ func (r *PostgresRepo) RevokeOldRefreshTokens(userID string, keepCount int) erro
 			ORDER BY created_at DESC 
 			LIMIT $2
 		)`
-	_, err := r.DB.Exec(query, userID, keepCount)
+	_, err = r.DB.Exec(query, userID, keepCount)
 	return err
 }
 
 // InvalidateAllUserRefreshTokens invalida todos os refresh tokens de um usuário (usado em caso de reutilização suspeita)
 func (r *PostgresRepo) InvalidateAllUserRefreshTokens(userID string) error {
-	_, err := r.DB.Exec("DELETE FROM tokens WHERE user_id=$1 AND type='refresh_token'", userID)
+	_, err := r.DeleteUserSessions(userID)
 	return err
 }
 
@@ -733,6 +977,20 @@ func (r *PostgresRepo) CleanupExpiredData() error {
 	if err := r.DeleteExpiredTokens(); err != nil {
 		return fmt.Errorf("erro ao limpar tokens: %w", err)
 	}
+	// Limpar sessões sem refresh token
+	if err := r.DeleteEndedSessions(); err != nil {
+		return fmt.Errorf("erro ao limpar sessões: %w", err)
+	}
+	// Limpar access tokens revogados já expirados
+	if err := r.DeleteExpiredRevokedTokens(); err != nil {
+		return fmt.Errorf("erro ao limpar tokens revogados: %w", err)
+	}
+	if err := r.DeleteFullRateLimitBuckets(); err != nil {
+		return fmt.Errorf("erro ao limpar baldes de rate limit: %w", err)
+	}
+	if err := r.DeleteStaleExamAttempts(); err != nil {
+		return fmt.Errorf("erro ao limpar tentativas abandonadas: %w", err)
+	}
 	// Limpar links expirados
 	if err := r.DeleteExpiredLinks(); err != nil {
 		return fmt.Errorf("erro ao limpar links: %w", err)
@@ -741,12 +999,29 @@ func (r *PostgresRepo) CleanupExpiredData() error {
 }
 
 func (r *PostgresRepo) GetLinkByToken(token string) (domain.PublicLink, error) {
+	return r.getLink("token", token)
+}
+
+// GetLinkByID busca o link pelo id (ex.: sessões adaptativas iniciadas pelo link)
+func (r *PostgresRepo) GetLinkByID(id string) (domain.PublicLink, error) {
+	return r.getLink("id", id)
+}
+
+// getLink busca o link pela coluna informada (id ou token)
+func (r *PostgresRepo) getLink(column, value string) (domain.PublicLink, error) {
 	var l domain.PublicLink
 	var expiresAt sql.NullTime
 	var createdAt time.Time
-	err := r.DB.QueryRow("SELECT id, exam_id, label, active, expires_at, created_at FROM public_links WHERE token=$1", token).
-		Scan(&l.ID, &l.ExamID, &l.Label, &l.Active, &expiresAt, &createdAt)
+	var attemptPolicy, availability []byte
+	var flagThreshold sql.NullInt64
+	var orgID sql.NullString
+	err := r.DB.QueryRow("SELECT id, exam_id, company_id, token, label, active, expires_at, created_at, attempt_policy, flag_threshold, org_id, availability FROM public_links WHERE "+column+"=$1", value).
+		Scan(&l.ID, &l.ExamID, &l.CompanyID, &l.Token, &l.Label, &l.Active, &expiresAt, &createdAt, &attemptPolicy, &flagThreshold, &orgID, &availability)
 	if err != nil { return l, err }
+	l.OrgID = orgID.String
+	l.FlagThreshold = int(flagThreshold.Int64)
+	l.AttemptPolicy = parseJSONPtr[domain.AttemptPolicy](attemptPolicy)
+	l.Availability = parseJSONPtr[domain.Availability](availability)
 	if expiresAt.Valid {
 		l.ExpiresAt = expiresAt.Time.UnixMilli()
 	}
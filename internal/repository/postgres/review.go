@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"esimulate-backend/internal/domain"
+	"time"
+)
+
+// --- Review (Caderno de Erros) Implementation ---
+// Os cartões são criados pelo trigger enqueue_review_card (answer_facts);
+// aqui apenas lemos e reagendamos.
+
+const reviewCardColumns = `rc.question_id, rc.ease_factor, rc.interval_days, rc.repetitions, rc.lapses,
+	rc.due_at, rc.last_reviewed_at, rc.created_at,
+	q.text, q.options, q.correct_index, q.explanation, q.subject_id, q.topic_id, q.is_public, q.is_verified`
+
+// scanReviewCard lê uma linha com as colunas de reviewCardColumns
+func scanReviewCard(scan func(dest ...interface{}) error) (domain.ReviewCard, error) {
+	var c domain.ReviewCard
+	var q domain.Question
+	var dueAt, createdAt time.Time
+	var lastReviewedAt sql.NullTime
+	var opt []byte
+	var explanation, subjectID, topicID sql.NullString
+	err := scan(&c.QuestionID, &c.EaseFactor, &c.IntervalDays, &c.Repetitions, &c.Lapses,
+		&dueAt, &lastReviewedAt, &createdAt,
+		&q.Text, &opt, &q.CorrectIndex, &explanation, &subjectID, &topicID, &q.IsPublic, &q.IsVerified)
+	if err != nil {
+		return c, err
+	}
+	c.DueAt = dueAt.UnixMilli()
+	c.CreatedAt = createdAt.UnixMilli()
+	if lastReviewedAt.Valid {
+		c.LastReviewedAt = lastReviewedAt.Time.UnixMilli()
+	}
+	q.ID = c.QuestionID
+	q.Explanation = explanation.String
+	q.SubjectID = subjectID.String
+	q.TopicID = topicID.String
+	json.Unmarshal(opt, &q.Options)
+	c.Question = &q
+	return c, nil
+}
+
+func (r *PostgresRepo) GetReviewCards(userID string) ([]domain.ReviewCard, error) {
+	rows, err := r.DB.Query(`SELECT `+reviewCardColumns+`
+		FROM review_cards rc JOIN questions q ON q.id = rc.question_id
+		WHERE rc.user_id=$1
+		ORDER BY rc.due_at`, userID)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	cards := []domain.ReviewCard{}
+	for rows.Next() {
+		c, err := scanReviewCard(rows.Scan)
+		if err != nil { continue }
+		cards = append(cards, c)
+	}
+	return cards, nil
+}
+
+func (r *PostgresRepo) GetDueReviewCards(userID string, limit int) ([]domain.ReviewCard, error) {
+	rows, err := r.DB.Query(`SELECT `+reviewCardColumns+`
+		FROM review_cards rc JOIN questions q ON q.id = rc.question_id
+		WHERE rc.user_id=$1 AND rc.due_at <= NOW()
+		ORDER BY rc.due_at
+		LIMIT $2`, userID, limit)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	cards := []domain.ReviewCard{}
+	for rows.Next() {
+		c, err := scanReviewCard(rows.Scan)
+		if err != nil { continue }
+		cards = append(cards, c)
+	}
+	return cards, nil
+}
+
+func (r *PostgresRepo) CountDueReviewCards(userID string) (int, error) {
+	var count int
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM review_cards WHERE user_id=$1 AND due_at <= NOW()`, userID).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresRepo) GetReviewCard(userID, questionID string) (domain.ReviewCard, error) {
+	row := r.DB.QueryRow(`SELECT `+reviewCardColumns+`
+		FROM review_cards rc JOIN questions q ON q.id = rc.question_id
+		WHERE rc.user_id=$1 AND rc.question_id=$2`, userID, questionID)
+	return scanReviewCard(row.Scan)
+}
+
+// UpdateReviewCard grava o novo agendamento calculado pelo service
+func (r *PostgresRepo) UpdateReviewCard(userID string, c domain.ReviewCard) error {
+	_, err := r.DB.Exec(`UPDATE review_cards SET
+			ease_factor=$3, interval_days=$4, repetitions=$5, lapses=$6, due_at=$7, last_reviewed_at=$8
+		WHERE user_id=$1 AND question_id=$2`,
+		userID, c.QuestionID, c.EaseFactor, c.IntervalDays, c.Repetitions, c.Lapses,
+		time.UnixMilli(c.DueAt), time.UnixMilli(c.LastReviewedAt))
+	return err
+}
+
+func (r *PostgresRepo) DeleteReviewCard(userID, questionID string) error {
+	_, err := r.DB.Exec("DELETE FROM review_cards WHERE user_id=$1 AND question_id=$2", userID, questionID)
+	return err
+}
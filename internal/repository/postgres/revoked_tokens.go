@@ -0,0 +1,27 @@
+package postgres
+
+import "time"
+
+// --- Access tokens revogados (blacklist compartilhado entre réplicas) ---
+
+// AddRevokedToken bloqueia o token até expiresAt (mantém a maior expiração se já estiver bloqueado)
+func (r *PostgresRepo) AddRevokedToken(tokenID string, expiresAt time.Time) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO revoked_tokens (token_id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (token_id) DO UPDATE SET expires_at = GREATEST(revoked_tokens.expires_at, EXCLUDED.expires_at)`,
+		tokenID, expiresAt)
+	return err
+}
+
+// IsTokenRevoked informa se o token está bloqueado e o bloqueio ainda vale
+func (r *PostgresRepo) IsTokenRevoked(tokenID string) (bool, error) {
+	var revoked bool
+	err := r.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE token_id=$1 AND expires_at > NOW())", tokenID).Scan(&revoked)
+	return revoked, err
+}
+
+// DeleteExpiredRevokedTokens remove bloqueios de tokens que já expiraram
+func (r *PostgresRepo) DeleteExpiredRevokedTokens() error {
+	_, err := r.DB.Exec("DELETE FROM revoked_tokens WHERE expires_at < NOW()")
+	return err
+}
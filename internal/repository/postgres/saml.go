@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- SAML SSO ---
+
+// ErrSAMLDomainTaken indica que o domínio já foi verificado por outra organização
+var ErrSAMLDomainTaken = errors.New("domínio já utilizado por outra organização")
+
+func (r *PostgresRepo) GetSAMLConfig(orgID string) (domain.SAMLConfig, error) {
+	c := domain.SAMLConfig{OrgID: orgID, EmailDomains: []string{}}
+	var attrMapping, roleMapping []byte
+	var updatedBy sql.NullString
+	var updatedAt time.Time
+	err := r.DB.QueryRow(`SELECT enabled, idp_metadata, idp_entity_id, attribute_mapping, role_mapping, default_role, updated_by, updated_at
+		FROM org_saml_configs WHERE org_id=$1`, orgID).
+		Scan(&c.Enabled, &c.IdPMetadata, &c.IdPEntityID, &attrMapping, &roleMapping, &c.DefaultRole, &updatedBy, &updatedAt)
+	if err != nil {
+		return c, err
+	}
+	if len(attrMapping) > 0 {
+		json.Unmarshal(attrMapping, &c.AttributeMapping)
+	}
+	if len(roleMapping) > 0 {
+		json.Unmarshal(roleMapping, &c.RoleMapping)
+	}
+	c.UpdatedBy = updatedBy.String
+	c.UpdatedAt = updatedAt.UnixMilli()
+
+	rows, err := r.DB.Query("SELECT domain, verification_token, verified_at FROM org_saml_domains WHERE org_id=$1 ORDER BY domain", orgID)
+	if err != nil {
+		return c, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d domain.SAMLDomain
+		var token sql.NullString
+		var verifiedAt sql.NullTime
+		if err := rows.Scan(&d.Domain, &token, &verifiedAt); err != nil {
+			return c, err
+		}
+		d.Token = token.String
+		if verifiedAt.Valid {
+			d.Verified, d.VerifiedAt = true, verifiedAt.Time.UnixMilli()
+		}
+		c.EmailDomains = append(c.EmailDomains, d.Domain)
+		c.Domains = append(c.Domains, d)
+	}
+	return c, rows.Err()
+}
+
+// GetSAMLDomainOwner retorna a organização que atende o domínio verificado (sql.ErrNoRows se nenhuma)
+func (r *PostgresRepo) GetSAMLDomainOwner(domainName string) (string, error) {
+	var orgID string
+	err := r.DB.QueryRow("SELECT org_id FROM org_saml_domains WHERE domain=$1 AND verified_at IS NOT NULL", domainName).Scan(&orgID)
+	return orgID, err
+}
+
+// VerifySAMLDomain marca o domínio pendente da organização como verificado
+// (verifiedBy vazio: registro DNS; senão o admin que aprovou). Retorna sql.ErrNoRows se não houver domínio pendente.
+func (r *PostgresRepo) VerifySAMLDomain(orgID, domainName, verifiedBy string) error {
+	res, err := r.DB.Exec(`UPDATE org_saml_domains SET verified_at=NOW(), verified_by=$3
+		WHERE org_id=$1 AND domain=$2 AND verified_at IS NULL`, orgID, domainName, nullString(verifiedBy))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SaveSAMLConfig grava a configuração e substitui os domínios da organização.
+// Domínios mantidos preservam a verificação; novos entram pendentes, com o token de c.Domains.
+// Um domínio pendente de outra organização é assumido; um já verificado, não.
+func (r *PostgresRepo) SaveSAMLConfig(c domain.SAMLConfig) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	attrMapping, _ := json.Marshal(c.AttributeMapping)
+	var roleMapping interface{}
+	if len(c.RoleMapping) > 0 {
+		b, _ := json.Marshal(c.RoleMapping)
+		roleMapping = b
+	}
+	if _, err := tx.Exec(`INSERT INTO org_saml_configs (org_id, enabled, idp_metadata, idp_entity_id, attribute_mapping, role_mapping, default_role, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (org_id) DO UPDATE SET enabled=EXCLUDED.enabled, idp_metadata=EXCLUDED.idp_metadata, idp_entity_id=EXCLUDED.idp_entity_id,
+			attribute_mapping=EXCLUDED.attribute_mapping, role_mapping=EXCLUDED.role_mapping, default_role=EXCLUDED.default_role,
+			updated_by=EXCLUDED.updated_by, updated_at=EXCLUDED.updated_at`,
+		c.OrgID, c.Enabled, c.IdPMetadata, c.IdPEntityID, attrMapping, roleMapping, c.DefaultRole,
+		nullString(c.UpdatedBy), time.UnixMilli(c.UpdatedAt)); err != nil {
+		return err
+	}
+	keep := make(map[string]bool, len(c.Domains))
+	for _, d := range c.Domains {
+		keep[d.Domain] = true
+		res, err := tx.Exec(`INSERT INTO org_saml_domains (domain, org_id, verification_token) VALUES ($1, $2, $3)
+			ON CONFLICT (domain) DO UPDATE SET
+				verification_token = CASE WHEN org_saml_domains.org_id = EXCLUDED.org_id
+					THEN COALESCE(org_saml_domains.verification_token, EXCLUDED.verification_token) ELSE EXCLUDED.verification_token END,
+				verified_at = CASE WHEN org_saml_domains.org_id = EXCLUDED.org_id THEN org_saml_domains.verified_at END,
+				verified_by = CASE WHEN org_saml_domains.org_id = EXCLUDED.org_id THEN org_saml_domains.verified_by END,
+				org_id = EXCLUDED.org_id
+			WHERE org_saml_domains.org_id = EXCLUDED.org_id OR org_saml_domains.verified_at IS NULL`, d.Domain, c.OrgID, d.Token)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrSAMLDomainTaken
+		}
+	}
+	rows, err := tx.Query("SELECT domain FROM org_saml_domains WHERE org_id=$1", c.OrgID)
+	if err != nil {
+		return err
+	}
+	var removed []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			rows.Close()
+			return err
+		}
+		if !keep[d] {
+			removed = append(removed, d)
+		}
+	}
+	rows.Close()
+	for _, d := range removed {
+		if _, err := tx.Exec("DELETE FROM org_saml_domains WHERE org_id=$1 AND domain=$2", c.OrgID, d); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepo) DeleteSAMLConfig(orgID string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM org_saml_domains WHERE org_id=$1", orgID); err != nil {
+		return err
+	}
+	res, err := tx.Exec("DELETE FROM org_saml_configs WHERE org_id=$1", orgID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepo) CreateSAMLRequest(relayState, requestID, orgID string, expiresAt time.Time) error {
+	r.DB.Exec("DELETE FROM saml_requests WHERE expires_at < NOW()")
+	_, err := r.DB.Exec("INSERT INTO saml_requests (relay_state, request_id, org_id, expires_at) VALUES ($1, $2, $3, $4)",
+		relayState, requestID, orgID, expiresAt)
+	return err
+}
+
+// ConsumeSAMLRequest exclui o AuthnRequest (uso único) e retorna request_id, organização e expiração
+func (r *PostgresRepo) ConsumeSAMLRequest(relayState string) (string, string, time.Time, error) {
+	var requestID, orgID string
+	var expiresAt time.Time
+	err := r.DB.QueryRow("DELETE FROM saml_requests WHERE relay_state=$1 RETURNING request_id, org_id, expires_at", relayState).
+		Scan(&requestID, &orgID, &expiresAt)
+	return requestID, orgID, expiresAt, err
+}
+
+// CreateSAMLUser provisiona o usuário (just-in-time) já como membro da organização
+func (r *PostgresRepo) CreateSAMLUser(u domain.User, orgID, orgRole string) (domain.User, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return u, err
+	}
+	defer tx.Rollback()
+
+	if u.ID == "" {
+		u.ID = uuid.New().String()
+	}
+	if u.CreatedAt == 0 {
+		u.CreatedAt = time.Now().UnixMilli()
+	}
+	if _, err := tx.Exec(`INSERT INTO users (id, name, email, password_hash, role, provider, created_at, is_verified, onboarding_completed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		u.ID, u.Name, u.Email, u.Password, u.Role, u.Provider, time.UnixMilli(u.CreatedAt), u.IsVerified, u.OnboardingCompleted); err != nil {
+		return u, err
+	}
+	if _, err := tx.Exec("INSERT INTO organization_members (org_id, user_id, role) VALUES ($1, $2, $3)", orgID, u.ID, orgRole); err != nil {
+		return u, err
+	}
+	u.Password = ""
+	return u, tx.Commit()
+}
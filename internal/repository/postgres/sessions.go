@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"esimulate-backend/internal/domain"
+	"time"
+)
+
+// --- Sessões (refresh tokens por dispositivo) ---
+
+// CreateSession abre a sessão do login e retorna o id
+func (r *PostgresRepo) CreateSession(userID, ip, userAgent string) (string, error) {
+	var id string
+	err := r.DB.QueryRow(`INSERT INTO user_sessions (user_id, ip, user_agent) VALUES ($1, $2, $3) RETURNING id`,
+		userID, nullString(ip), nullString(userAgent)).Scan(&id)
+	return id, err
+}
+
+// CreateSessionRefreshToken grava o refresh token associado à sessão
+func (r *PostgresRepo) CreateSessionRefreshToken(sessionID, userID, token string, expiresAt time.Time) error {
+	_, err := r.DB.Exec(`INSERT INTO tokens (user_id, token, type, expires_at, session_id) VALUES ($1, $2, 'refresh_token', $3, $4)`,
+		userID, token, expiresAt, sessionID)
+	return err
+}
+
+// GetRefreshTokenSessionID retorna a sessão do refresh token ("" para tokens anteriores às sessões)
+func (r *PostgresRepo) GetRefreshTokenSessionID(token string) (string, error) {
+	var sessionID *string
+	err := r.DB.QueryRow(`SELECT session_id FROM tokens WHERE token=$1 AND type='refresh_token'`, token).Scan(&sessionID)
+	if err != nil || sessionID == nil {
+		return "", err
+	}
+	return *sessionID, nil
+}
+
+// TouchSession registra o uso da sessão (refresh)
+func (r *PostgresRepo) TouchSession(sessionID string) error {
+	_, err := r.DB.Exec("UPDATE user_sessions SET last_used_at=NOW() WHERE id=$1", sessionID)
+	return err
+}
+
+// GetUserSessions lista as sessões com refresh token válido, da mais recente para a mais antiga
+func (r *PostgresRepo) GetUserSessions(userID string) ([]domain.Session, error) {
+	rows, err := r.DB.Query(`
+		SELECT s.id, COALESCE(s.ip, ''), COALESCE(s.user_agent, ''), s.created_at, s.last_used_at, MAX(t.expires_at)
+		FROM user_sessions s
+		JOIN tokens t ON t.session_id = s.id AND t.type = 'refresh_token' AND t.used = false AND t.expires_at > NOW()
+		WHERE s.user_id = $1
+		GROUP BY s.id
+		ORDER BY s.last_used_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []domain.Session{}
+	for rows.Next() {
+		var s domain.Session
+		var createdAt, lastUsedAt, expiresAt time.Time
+		if err := rows.Scan(&s.ID, &s.IP, &s.UserAgent, &createdAt, &lastUsedAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		s.CreatedAt = createdAt.UnixMilli()
+		s.LastUsedAt = lastUsedAt.UnixMilli()
+		s.ExpiresAt = expiresAt.UnixMilli()
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteSession revoga a sessão do usuário (os refresh tokens são excluídos em cascata)
+func (r *PostgresRepo) DeleteSession(userID, sessionID string) (bool, error) {
+	res, err := r.DB.Exec("DELETE FROM user_sessions WHERE id=$1 AND user_id=$2", sessionID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// DeleteSessionByRefreshToken revoga a sessão do refresh token (logout)
+func (r *PostgresRepo) DeleteSessionByRefreshToken(token string) error {
+	_, err := r.DB.Exec(`DELETE FROM user_sessions WHERE id = (SELECT session_id FROM tokens WHERE token=$1 AND type='refresh_token')`, token)
+	return err
+}
+
+// DeleteUserSessions revoga todas as sessões e refresh tokens do usuário e retorna os ids das sessões
+func (r *PostgresRepo) DeleteUserSessions(userID string) ([]string, error) {
+	rows, err := r.DB.Query("DELETE FROM user_sessions WHERE user_id=$1 RETURNING id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Refresh tokens sem sessão (emitidos antes das sessões)
+	_, err = r.DB.Exec("DELETE FROM tokens WHERE user_id=$1 AND type='refresh_token'", userID)
+	return ids, err
+}
+
+// DeleteEndedSessions exclui sessões sem refresh token (expiradas, revogadas ou encerradas no logout)
+func (r *PostgresRepo) DeleteEndedSessions() error {
+	_, err := r.DB.Exec("DELETE FROM user_sessions s WHERE NOT EXISTS (SELECT 1 FROM tokens t WHERE t.session_id = s.id)")
+	return err
+}
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"database/sql"
+	"esimulate-backend/internal/domain"
+	"fmt"
+	"time"
+)
+
+// --- Exam Sharing Implementation ---
+
+// shareRoleSQL retorna a subconsulta com o maior papel concedido ao usuário no exame: direto, via grupo
+// ou pela organização dona do exame (owner -> owner, recruiter -> editor, viewer -> viewer).
+// Alunos de turmas não entram aqui: acessam o exame da atividade apenas pela rota da atividade, sem gabarito.
+func shareRoleSQL(examCol, userArg string) string {
+	return fmt.Sprintf(`(SELECT g.role FROM (
+			SELECT s.role FROM exam_shares s
+			LEFT JOIN user_group_members gm ON gm.group_id = s.group_id
+			WHERE s.exam_id = %[1]s AND (s.user_id = %[2]s OR gm.user_id = %[2]s)
+			UNION ALL
+			SELECT CASE m.role WHEN 'owner' THEN 'owner' WHEN 'recruiter' THEN 'editor' ELSE 'viewer' END
+			FROM exams oe JOIN organization_members m ON m.org_id = oe.org_id
+			WHERE oe.id = %[1]s AND m.user_id = %[2]s
+		) g
+		ORDER BY CASE g.role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END DESC
+		LIMIT 1)`, examCol, userArg)
+}
+
+// GetExamShareRole retorna o papel efetivo concedido ao usuário no exame ("" se nenhum)
+func (r *PostgresRepo) GetExamShareRole(examID, userID string) (string, error) {
+	var role sql.NullString
+	err := r.DB.QueryRow("SELECT "+shareRoleSQL("$1", "$2"), examID, userID).Scan(&role)
+	return role.String, err
+}
+
+// UpsertExamShare concede (ou altera) o acesso de um usuário ou grupo ao exame
+func (r *PostgresRepo) UpsertExamShare(s domain.ExamShare) (string, error) {
+	target := "(exam_id, user_id) WHERE user_id IS NOT NULL"
+	if s.GroupID != "" {
+		target = "(exam_id, group_id) WHERE group_id IS NOT NULL"
+	}
+	var id string
+	err := r.DB.QueryRow(`INSERT INTO exam_shares (id, exam_id, user_id, group_id, role, granted_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT `+target+` DO UPDATE SET role = EXCLUDED.role, granted_by = EXCLUDED.granted_by
+		RETURNING id`,
+		s.ID, s.ExamID, nullString(s.UserID), nullString(s.GroupID), s.Role, nullString(s.GrantedBy), time.UnixMilli(s.CreatedAt)).Scan(&id)
+	return id, err
+}
+
+// GetExamShares lista quem tem acesso concedido ao exame
+func (r *PostgresRepo) GetExamShares(examID string) ([]domain.ExamShare, error) {
+	rows, err := r.DB.Query(`SELECT s.id, s.exam_id, s.user_id, u.name, u.email, s.group_id, g.name, s.role, s.granted_by, s.created_at
+		FROM exam_shares s
+		LEFT JOIN users u ON u.id = s.user_id
+		LEFT JOIN user_groups g ON g.id = s.group_id
+		WHERE s.exam_id = $1
+		ORDER BY s.created_at`, examID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []domain.ExamShare{}
+	for rows.Next() {
+		var s domain.ExamShare
+		var userID, userName, userEmail, groupID, groupName, grantedBy sql.NullString
+		var createdAt time.Time
+		if err := rows.Scan(&s.ID, &s.ExamID, &userID, &userName, &userEmail, &groupID, &groupName, &s.Role, &grantedBy, &createdAt); err != nil {
+			return nil, err
+		}
+		s.UserID, s.UserName, s.UserEmail = userID.String, userName.String, userEmail.String
+		s.GroupID, s.GroupName = groupID.String, groupName.String
+		s.GrantedBy = grantedBy.String
+		s.CreatedAt = createdAt.UnixMilli()
+		shares = append(shares, s)
+	}
+	return shares, rows.Err()
+}
+
+// GetExamShare retorna uma concessão do exame
+func (r *PostgresRepo) GetExamShare(examID, shareID string) (domain.ExamShare, error) {
+	var s domain.ExamShare
+	var userID, groupID sql.NullString
+	err := r.DB.QueryRow("SELECT id, exam_id, user_id, group_id, role FROM exam_shares WHERE id=$1 AND exam_id=$2", shareID, examID).
+		Scan(&s.ID, &s.ExamID, &userID, &groupID, &s.Role)
+	s.UserID, s.GroupID = userID.String, groupID.String
+	return s, err
+}
+
+// DeleteExamShare revoga uma concessão do exame
+func (r *PostgresRepo) DeleteExamShare(examID, shareID string) error {
+	res, err := r.DB.Exec("DELETE FROM exam_shares WHERE id=$1 AND exam_id=$2", shareID, examID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// --- User Groups ---
+
+// CreateGroup cria o grupo e inclui o dono como membro
+func (r *PostgresRepo) CreateGroup(g domain.UserGroup) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	created := time.UnixMilli(g.CreatedAt)
+	if _, err := tx.Exec("INSERT INTO user_groups (id, name, owner_id, created_at) VALUES ($1, $2, $3, $4)", g.ID, g.Name, g.OwnerID, created); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO user_group_members (group_id, user_id, added_at) VALUES ($1, $2, $3)", g.ID, g.OwnerID, created); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetGroupByID retorna o grupo (sem membros)
+func (r *PostgresRepo) GetGroupByID(id string) (domain.UserGroup, error) {
+	var g domain.UserGroup
+	var createdAt time.Time
+	err := r.DB.QueryRow("SELECT id, name, owner_id, created_at FROM user_groups WHERE id=$1", id).Scan(&g.ID, &g.Name, &g.OwnerID, &createdAt)
+	g.CreatedAt = createdAt.UnixMilli()
+	g.Members = []domain.GroupMember{}
+	return g, err
+}
+
+// GetUserGroups lista os grupos dos quais o usuário é dono ou membro, com os membros
+func (r *PostgresRepo) GetUserGroups(userID string) ([]domain.UserGroup, error) {
+	rows, err := r.DB.Query(`SELECT g.id, g.name, g.owner_id, g.created_at, u.id, u.name, u.email, m.added_at
+		FROM user_groups g
+		JOIN user_group_members m ON m.group_id = g.id
+		JOIN users u ON u.id = m.user_id
+		WHERE g.id IN (SELECT group_id FROM user_group_members WHERE user_id = $1) OR g.owner_id = $1
+		ORDER BY g.name, g.id, u.name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []domain.UserGroup{}
+	index := map[string]int{}
+	for rows.Next() {
+		var g domain.UserGroup
+		var m domain.GroupMember
+		var createdAt, addedAt time.Time
+		if err := rows.Scan(&g.ID, &g.Name, &g.OwnerID, &createdAt, &m.UserID, &m.Name, &m.Email, &addedAt); err != nil {
+			return nil, err
+		}
+		m.AddedAt = addedAt.UnixMilli()
+		i, ok := index[g.ID]
+		if !ok {
+			g.CreatedAt = createdAt.UnixMilli()
+			g.Members = []domain.GroupMember{}
+			groups = append(groups, g)
+			i = len(groups) - 1
+			index[g.ID] = i
+		}
+		groups[i].Members = append(groups[i].Members, m)
+	}
+	return groups, rows.Err()
+}
+
+// AddGroupMember inclui o usuário no grupo (idempotente)
+func (r *PostgresRepo) AddGroupMember(groupID, userID string) error {
+	_, err := r.DB.Exec("INSERT INTO user_group_members (group_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", groupID, userID)
+	return err
+}
+
+// RemoveGroupMember remove o usuário do grupo
+func (r *PostgresRepo) RemoveGroupMember(groupID, userID string) error {
+	res, err := r.DB.Exec("DELETE FROM user_group_members WHERE group_id=$1 AND user_id=$2", groupID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteGroup remove o grupo; as concessões feitas ao grupo são removidas em cascata
+func (r *PostgresRepo) DeleteGroup(id string) error {
+	res, err := r.DB.Exec("DELETE FROM user_groups WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// IsGroupMember indica se o usuário pertence ao grupo
+func (r *PostgresRepo) IsGroupMember(groupID, userID string) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM user_group_members WHERE group_id=$1 AND user_id=$2)", groupID, userID).Scan(&exists)
+	return exists, err
+}
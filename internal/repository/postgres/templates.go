@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"database/sql"
+	"esimulate-backend/internal/domain"
+)
+
+// --- Exam Templates Implementation ---
+
+// SetExamTemplate marca ou desmarca o exame como modelo do catálogo
+func (r *PostgresRepo) SetExamTemplate(id string, isTemplate bool) error {
+	res, err := r.DB.Exec("UPDATE exams SET is_template=$2, updated_at=NOW() WHERE id=$1", id, isTemplate)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetTemplateExams retorna os modelos do catálogo com suas questões
+func (r *PostgresRepo) GetTemplateExams() ([]domain.Exam, error) {
+	rows, err := r.DB.Query(`SELECT id FROM exams WHERE is_template = TRUE AND is_active = TRUE AND status = 'published' ORDER BY title`)
+	if err != nil { return nil, err }
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	exams := []domain.Exam{}
+	for _, id := range ids {
+		e, err := r.GetExamByID(id)
+		if err != nil { continue }
+		exams = append(exams, e)
+	}
+	return exams, nil
+}
@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"database/sql"
+	"esimulate-backend/internal/domain"
+	"time"
+)
+
+// --- Two-Factor Authentication ---
+
+// GetTOTPState retorna o segredo TOTP ("" se não configurado) e se o 2FA está ativo
+func (r *PostgresRepo) GetTOTPState(userID string) (string, bool, error) {
+	var secret sql.NullString
+	var enabled bool
+	err := r.DB.QueryRow("SELECT totp_secret, totp_enabled FROM users WHERE id=$1", userID).Scan(&secret, &enabled)
+	return secret.String, enabled, err
+}
+
+// SetPendingTOTPSecret grava um novo segredo ainda não confirmado (não altera um 2FA ativo)
+func (r *PostgresRepo) SetPendingTOTPSecret(userID, secret string) error {
+	res, err := r.DB.Exec(`UPDATE users SET totp_secret=$2, totp_last_step=NULL, updated_at=NOW()
+		WHERE id=$1 AND totp_enabled = FALSE`, userID, secret)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// EnableTOTP ativa o 2FA com o segredo pendente e grava os códigos de recuperação
+func (r *PostgresRepo) EnableTOTP(userID string, step int64, recoveryHashes []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE users SET totp_enabled=TRUE, totp_last_step=$2, updated_at=NOW()
+		WHERE id=$1 AND totp_secret IS NOT NULL AND totp_enabled = FALSE`, userID, step)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if err := replaceRecoveryCodes(tx, userID, recoveryHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableTOTP remove o segredo e os códigos de recuperação
+func (r *PostgresRepo) DisableTOTP(userID string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET totp_secret=NULL, totp_enabled=FALSE, totp_last_step=NULL, updated_at=NOW()
+		WHERE id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumeTOTPStep registra o passo aceito; retorna sql.ErrNoRows se o passo (ou um posterior) já foi usado
+func (r *PostgresRepo) ConsumeTOTPStep(userID string, step int64) error {
+	res, err := r.DB.Exec(`UPDATE users SET totp_last_step=$2
+		WHERE id=$1 AND (totp_last_step IS NULL OR totp_last_step < $2)`, userID, step)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID string, hashes []string) error {
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if _, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes invalida os códigos de recuperação anteriores e grava os novos
+func (r *PostgresRepo) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := replaceRecoveryCodes(tx, userID, hashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode marca o código como usado; retorna false se não existir ou já tiver sido usado
+func (r *PostgresRepo) UseRecoveryCode(userID, hash string) (bool, error) {
+	res, err := r.DB.Exec(`UPDATE recovery_codes SET used_at=NOW()
+		WHERE id = (SELECT id FROM recovery_codes WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL LIMIT 1)`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func (r *PostgresRepo) CountRecoveryCodes(userID string) (int, error) {
+	var count int
+	err := r.DB.QueryRow("SELECT COUNT(*) FROM recovery_codes WHERE user_id=$1 AND used_at IS NULL", userID).Scan(&count)
+	return count, err
+}
+
+// ClaimTwoFactorChallenge reserva o desafio de login para uma verificação (used=TRUE) e retorna o usuário.
+// Retorna sql.ErrNoRows se o desafio não existir, já estiver em uso, expirado ou esgotado (maxFailures).
+func (r *PostgresRepo) ClaimTwoFactorChallenge(token string, maxFailures int) (string, error) {
+	var userID string
+	err := r.DB.QueryRow(`UPDATE tokens SET used=TRUE
+		WHERE token=$1 AND type='2fa_challenge' AND used=FALSE AND expires_at > NOW() AND failed_attempts < $2
+		RETURNING user_id`, token, maxFailures).Scan(&userID)
+	return userID, err
+}
+
+// ReleaseTwoFactorChallenge libera o desafio reservado sem contar falha (erro interno ou cadastro pendente)
+func (r *PostgresRepo) ReleaseTwoFactorChallenge(token string) error {
+	_, err := r.DB.Exec("UPDATE tokens SET used=FALSE WHERE token=$1 AND type='2fa_challenge'", token)
+	return err
+}
+
+// FailTwoFactorChallenge conta um código incorreto e libera o desafio para nova tentativa;
+// ao atingir maxFailures o desafio é excluído. Retorna o total de falhas do desafio.
+func (r *PostgresRepo) FailTwoFactorChallenge(token string, maxFailures int) (int, error) {
+	var failures int
+	err := r.DB.QueryRow(`UPDATE tokens SET used=FALSE, failed_attempts=failed_attempts+1
+		WHERE token=$1 AND type='2fa_challenge'
+		RETURNING failed_attempts`, token).Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+	if failures >= maxFailures {
+		_, err = r.DB.Exec("DELETE FROM tokens WHERE token=$1", token)
+	}
+	return failures, err
+}
+
+// --- Two-Factor Policies ---
+
+// IsTwoFactorRequired indica se o papel exige 2FA
+func (r *PostgresRepo) IsTwoFactorRequired(role string) (bool, error) {
+	var required bool
+	err := r.DB.QueryRow("SELECT required FROM two_factor_policies WHERE role=$1", role).Scan(&required)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return required, err
+}
+
+func (r *PostgresRepo) GetTwoFactorPolicies() ([]domain.TwoFactorPolicy, error) {
+	rows, err := r.DB.Query("SELECT role, required, updated_by, updated_at FROM two_factor_policies ORDER BY role")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []domain.TwoFactorPolicy{}
+	for rows.Next() {
+		var p domain.TwoFactorPolicy
+		var updatedBy sql.NullString
+		var updatedAt time.Time
+		if err := rows.Scan(&p.Role, &p.Required, &updatedBy, &updatedAt); err != nil {
+			return nil, err
+		}
+		p.UpdatedBy = updatedBy.String
+		p.UpdatedAt = updatedAt.UnixMilli()
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+func (r *PostgresRepo) SetTwoFactorPolicy(p domain.TwoFactorPolicy) error {
+	_, err := r.DB.Exec(`INSERT INTO two_factor_policies (role, required, updated_by, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (role) DO UPDATE SET required=EXCLUDED.required, updated_by=EXCLUDED.updated_by, updated_at=EXCLUDED.updated_at`,
+		p.Role, p.Required, nullString(p.UpdatedBy), time.UnixMilli(p.UpdatedAt))
+	return err
+}
@@ -1,42 +1,79 @@
 package security
 
 import (
+	"encoding/json"
+	"esimulate-backend/internal/domain"
 	"esimulate-backend/internal/logger"
+	"reflect"
+	"strings"
 	"time"
 )
 
-// SecurityEvent representa um evento de segurança
-type SecurityEvent struct {
-	Type      string    `json:"type"`
-	UserID    string    `json:"userId,omitempty"`
-	IP        string    `json:"ip"`
-	UserAgent string    `json:"userAgent,omitempty"`
-	Details   string    `json:"details,omitempty"`
-	Timestamp time.Time `json:"timestamp"`
+// AuditStore grava a trilha de auditoria (Postgres)
+type AuditStore interface {
+	CreateAuditEvent(e domain.AuditEvent) error
 }
 
-// AuditLogger registra eventos de segurança
-type AuditLogger struct{}
+// logOnlyEvents são eventos de alto volume registrados apenas no log, fora da trilha persistente
+var logOnlyEvents = map[string]bool{
+	"RATE_LIMIT":      true,
+	"REFRESH_SUCCESS": true,
+}
+
+// AuditLogger registra eventos de segurança no log e na trilha de auditoria
+type AuditLogger struct {
+	store AuditStore
+}
 
-// NewAuditLogger cria um novo audit logger
-func NewAuditLogger() *AuditLogger {
-	return &AuditLogger{}
+// NewAuditLogger cria um novo audit logger; store nil registra apenas no log
+func NewAuditLogger(store AuditStore) *AuditLogger {
+	return &AuditLogger{store: store}
+}
+
+// Record registra um evento completo (alvo e alterações). Falhas ao gravar vão para o log e não interrompem a requisição.
+func (al *AuditLogger) Record(e domain.AuditEvent) {
+	if e.CreatedAt == 0 {
+		e.CreatedAt = time.Now().UnixMilli()
+	}
+	target := ""
+	if e.TargetType != "" {
+		target = e.TargetType + ":" + e.TargetID
+	}
+	logger.Warn("[SECURITY] %s | User: %s | IP: %s | Target: %s | Details: %s",
+		e.Action, e.ActorID, e.IP, target, e.Details)
+
+	if al.store == nil || logOnlyEvents[e.Action] {
+		return
+	}
+	if err := al.store.CreateAuditEvent(e); err != nil {
+		logger.Error("Erro ao gravar evento de auditoria %s: %v", e.Action, err)
+	}
 }
 
 // LogEvent registra um evento de segurança
 func (al *AuditLogger) LogEvent(eventType, userID, ip, userAgent, details string) {
-	event := SecurityEvent{
-		Type:      eventType,
-		UserID:    userID,
+	al.Record(domain.AuditEvent{
+		Action:    eventType,
+		ActorID:   userID,
 		IP:        ip,
 		UserAgent: userAgent,
 		Details:   details,
-		Timestamp: time.Now(),
-	}
-	
-	// Log como JSON estruturado para facilitar análise
-	logger.Warn("[SECURITY] %s | User: %s | IP: %s | Details: %s", 
-		event.Type, event.UserID, event.IP, event.Details)
+	})
+}
+
+// LogChange registra uma alteração sobre um recurso ("exam:<id>", "user:<id>"...) com a diferença entre before e after
+// (nil em criações e exclusões)
+func (al *AuditLogger) LogChange(actorID, ip, userAgent, action, resource string, before, after any) {
+	targetType, targetID := splitResource(resource)
+	al.Record(domain.AuditEvent{
+		Action:     action,
+		ActorID:    actorID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         ip,
+		UserAgent:  userAgent,
+		Changes:    AuditDiff(before, after),
+	})
 }
 
 // LogLogin registra tentativa de login
@@ -67,9 +104,9 @@ func (al *AuditLogger) LogRateLimit(endpoint, ip string) {
 	al.LogEvent("RATE_LIMIT", "", ip, "", "Endpoint: "+endpoint)
 }
 
-// LogPasswordReset registra tentativa de reset de senha
+// LogPasswordReset registra a redefinição de senha pelo link enviado por email
 func (al *AuditLogger) LogPasswordReset(userID, ip, userAgent string) {
-	al.LogEvent("PASSWORD_RESET", userID, ip, userAgent, "")
+	al.LogChange(userID, ip, userAgent, "PASSWORD_RESET", "user:"+userID, nil, nil)
 }
 
 // LogLogout registra logout
@@ -77,3 +114,101 @@ func (al *AuditLogger) LogLogout(userID, ip, userAgent string) {
 	al.LogEvent("LOGOUT", userID, ip, userAgent, "")
 }
 
+// LogAccessDenied registra tentativa de operação sem permissão sobre um recurso
+func (al *AuditLogger) LogAccessDenied(userID, ip, userAgent, action, resource string) {
+	targetType, targetID := splitResource(resource)
+	al.Record(domain.AuditEvent{
+		Action:     "ACCESS_DENIED",
+		ActorID:    userID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         ip,
+		UserAgent:  userAgent,
+		Details:    "Action: " + action,
+	})
+}
+
+// LogSessionRevoked registra o encerramento de sessões (sessionID vazio: todas as sessões do usuário)
+func (al *AuditLogger) LogSessionRevoked(actorID, ip, userAgent, targetUserID, sessionID string) {
+	if sessionID == "" {
+		sessionID = "all"
+	}
+	al.Record(domain.AuditEvent{
+		Action:     "SESSION_REVOKED",
+		ActorID:    actorID,
+		TargetType: "user",
+		TargetID:   targetUserID,
+		IP:         ip,
+		UserAgent:  userAgent,
+		Details:    "Session: " + sessionID,
+	})
+}
+
+// splitResource separa "tipo:id" em tipo e id
+func splitResource(resource string) (string, string) {
+	targetType, targetID, ok := strings.Cut(resource, ":")
+	if !ok {
+		return resource, ""
+	}
+	return targetType, targetID
+}
+
+// auditSensitiveKeys são trechos de nomes de campo cujos valores não entram na trilha
+var auditSensitiveKeys = []string{"password", "secret", "token", "apikey", "privatekey", "recovery"}
+
+// AuditDiff compara os campos (JSON) de before e after e retorna os que mudaram.
+// Valores de campos sensíveis (senhas, segredos, tokens, API keys) são mascarados, mesmo aninhados.
+func AuditDiff(before, after any) map[string]domain.AuditChange {
+	b, a := auditFields(before), auditFields(after)
+	changes := make(map[string]domain.AuditChange)
+	for k, bv := range b {
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(bv, av) {
+			changes[k] = domain.AuditChange{Before: bv, After: av}
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = domain.AuditChange{After: av}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// auditFields converte o valor em um mapa de campos JSON, com os valores sensíveis mascarados
+func auditFields(v any) map[string]any {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if json.Unmarshal(data, &fields) != nil {
+		// Valor simples (ex.: um papel): registrado como campo "value"
+		var value any
+		json.Unmarshal(data, &value)
+		return map[string]any{"value": value}
+	}
+	redactAuditFields(fields)
+	return fields
+}
+
+// redactAuditFields mascara os valores de campos sensíveis em mapas aninhados
+func redactAuditFields(fields map[string]any) {
+	for k, v := range fields {
+		key := strings.ToLower(k)
+		for _, s := range auditSensitiveKeys {
+			if strings.Contains(key, s) {
+				fields[k] = "[redacted]"
+				break
+			}
+		}
+		if nested, ok := v.(map[string]any); ok && fields[k] != "[redacted]" {
+			redactAuditFields(nested)
+		}
+	}
+}
@@ -1,63 +1,65 @@
 package security
 
 import (
+	"esimulate-backend/internal/logger"
 	"sync"
 	"time"
 )
 
-// TokenBlacklist gerencia tokens revogados
-type TokenBlacklist struct {
+// TokenBlacklist bloqueia access tokens revogados (logout, sessão encerrada) até a expiração deles
+type TokenBlacklist interface {
+	// Add bloqueia o token até expiresAt (exp do token mais a tolerância de clock skew)
+	Add(tokenID string, expiresAt time.Time) error
+	// IsBlacklisted informa se o token está bloqueado
+	IsBlacklisted(tokenID string) bool
+}
+
+// BlacklistStore é o armazenamento compartilhado entre réplicas (Postgres; Redis pode implementar o mesmo contrato)
+type BlacklistStore interface {
+	AddRevokedToken(tokenID string, expiresAt time.Time) error
+	IsTokenRevoked(tokenID string) (bool, error)
+}
+
+// MemoryBlacklist gerencia tokens revogados em memória (uma única instância da API)
+type MemoryBlacklist struct {
 	tokens map[string]time.Time
 	mu     sync.RWMutex
 }
 
-// NewTokenBlacklist cria um novo blacklist
-func NewTokenBlacklist() *TokenBlacklist {
-	bl := &TokenBlacklist{
+// NewMemoryBlacklist cria um novo blacklist em memória
+func NewMemoryBlacklist() *MemoryBlacklist {
+	bl := &MemoryBlacklist{
 		tokens: make(map[string]time.Time),
 	}
-	
+
 	// Limpar tokens expirados periodicamente
 	go bl.cleanup()
-	
+
 	return bl
 }
 
 // Add adiciona um token ao blacklist com tempo de expiração
-func (bl *TokenBlacklist) Add(tokenID string, expiresAt time.Time) {
+func (bl *MemoryBlacklist) Add(tokenID string, expiresAt time.Time) error {
 	bl.mu.Lock()
 	defer bl.mu.Unlock()
 	bl.tokens[tokenID] = expiresAt
+	return nil
 }
 
-// IsBlacklisted verifica se um token está no blacklist
-func (bl *TokenBlacklist) IsBlacklisted(tokenID string) bool {
+// IsBlacklisted verifica se um token está no blacklist (entradas expiradas são removidas em cleanup)
+func (bl *MemoryBlacklist) IsBlacklisted(tokenID string) bool {
 	bl.mu.RLock()
 	defer bl.mu.RUnlock()
-	
+
 	expiresAt, exists := bl.tokens[tokenID]
-	if !exists {
-		return false
-	}
-	
-	// Se expirou, remover e retornar false
-	if time.Now().After(expiresAt) {
-		bl.mu.RUnlock()
-		bl.mu.Lock()
-		delete(bl.tokens, tokenID)
-		bl.mu.Unlock()
-		bl.mu.RLock()
-		return false
-	}
-	
-	return true
+	return exists && time.Now().Before(expiresAt)
 }
 
 // cleanup remove tokens expirados periodicamente
-func (bl *TokenBlacklist) cleanup() {
+func (bl *MemoryBlacklist) cleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
-	
+
 	for range ticker.C {
 		bl.mu.Lock()
 		now := time.Now()
@@ -70,3 +72,100 @@ func (bl *TokenBlacklist) cleanup() {
 	}
 }
 
+// Limites do cache local do blacklist compartilhado
+const (
+	blacklistCacheMaxEntries = 50000
+	blacklistCleanupInterval = time.Minute
+)
+
+// CachedBlacklist consulta o BlacklistStore compartilhado com cache local.
+// Tokens bloqueados ficam no cache até expirar; consultas negativas valem por negativeTTL,
+// que é o atraso máximo para uma revogação feita em outra réplica ser percebida.
+type CachedBlacklist struct {
+	store       BlacklistStore
+	negativeTTL time.Duration
+
+	mu      sync.RWMutex
+	revoked map[string]time.Time // tokenID -> expiração do bloqueio
+	allowed map[string]time.Time // tokenID -> validade da consulta negativa
+}
+
+// NewCachedBlacklist cria o blacklist compartilhado; negativeTTL 0 desativa o cache de consultas negativas
+func NewCachedBlacklist(store BlacklistStore, negativeTTL time.Duration) *CachedBlacklist {
+	bl := &CachedBlacklist{
+		store:       store,
+		negativeTTL: negativeTTL,
+		revoked:     make(map[string]time.Time),
+		allowed:     make(map[string]time.Time),
+	}
+	go bl.cleanup()
+	return bl
+}
+
+// Add grava o bloqueio no armazenamento compartilhado e no cache local
+func (bl *CachedBlacklist) Add(tokenID string, expiresAt time.Time) error {
+	bl.mu.Lock()
+	bl.revoked[tokenID] = expiresAt
+	delete(bl.allowed, tokenID)
+	bl.mu.Unlock()
+
+	if err := bl.store.AddRevokedToken(tokenID, expiresAt); err != nil {
+		logger.Error("Erro ao gravar token revogado: %v", err)
+		return err
+	}
+	return nil
+}
+
+// IsBlacklisted consulta o cache local e, se necessário, o armazenamento compartilhado.
+// Com o armazenamento indisponível o token é aceito (a falha é registrada no log).
+func (bl *CachedBlacklist) IsBlacklisted(tokenID string) bool {
+	now := time.Now()
+	bl.mu.RLock()
+	revokedUntil, revoked := bl.revoked[tokenID]
+	allowedUntil, allowed := bl.allowed[tokenID]
+	bl.mu.RUnlock()
+	if revoked && now.Before(revokedUntil) {
+		return true
+	}
+	if allowed && now.Before(allowedUntil) {
+		return false
+	}
+
+	isRevoked, err := bl.store.IsTokenRevoked(tokenID)
+	if err != nil {
+		logger.Error("Erro ao consultar tokens revogados: %v", err)
+		return false
+	}
+
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if isRevoked {
+		// A expiração exata fica no armazenamento; localmente basta até a próxima consulta negativa
+		bl.revoked[tokenID] = now.Add(bl.negativeTTL)
+	} else if bl.negativeTTL > 0 && len(bl.allowed) < blacklistCacheMaxEntries {
+		bl.allowed[tokenID] = now.Add(bl.negativeTTL)
+	}
+	return isRevoked
+}
+
+// cleanup remove entradas vencidas do cache local
+func (bl *CachedBlacklist) cleanup() {
+	ticker := time.NewTicker(blacklistCleanupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		bl.mu.Lock()
+		for tokenID, until := range bl.revoked {
+			if now.After(until) {
+				delete(bl.revoked, tokenID)
+			}
+		}
+		for tokenID, until := range bl.allowed {
+			if now.After(until) {
+				delete(bl.allowed, tokenID)
+			}
+		}
+		bl.mu.Unlock()
+	}
+}
@@ -0,0 +1,163 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Cabeçalhos aceitos para o IP do cliente atrás de proxies confiáveis
+const (
+	ClientIPHeaderXFF       = "X-Forwarded-For"
+	ClientIPHeaderForwarded = "Forwarded" // RFC 7239
+	ClientIPHeaderRealIP    = "X-Real-IP"
+)
+
+// ClientIPResolver determina o IP do cliente. Cabeçalhos de proxy só são lidos quando a conexão
+// vem de um proxy confiável; a lista é percorrida da direita para a esquerda, ignorando os proxies
+// confiáveis, e o primeiro endereço restante é o cliente (entradas à esquerda podem ser forjadas).
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+	header  string
+}
+
+// NewClientIPResolver cria o resolvedor; trustedProxies aceita CIDRs ou IPs (ex.: "10.0.0.0/8", "127.0.0.1")
+// e header é o cabeçalho preenchido pelo proxy (padrão X-Forwarded-For)
+func NewClientIPResolver(trustedProxies []string, header string) (*ClientIPResolver, error) {
+	switch {
+	case header == "":
+		header = ClientIPHeaderXFF
+	case strings.EqualFold(header, ClientIPHeaderXFF):
+		header = ClientIPHeaderXFF
+	case strings.EqualFold(header, ClientIPHeaderForwarded):
+		header = ClientIPHeaderForwarded
+	case strings.EqualFold(header, ClientIPHeaderRealIP):
+		header = ClientIPHeaderRealIP
+	default:
+		return nil, fmt.Errorf("cabeçalho de IP do cliente não suportado: %s", header)
+	}
+
+	res := &ClientIPResolver{header: header}
+	for _, p := range trustedProxies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, cidr, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("proxy confiável inválido: %s", p)
+		}
+		res.trusted = append(res.trusted, cidr)
+	}
+	return res, nil
+}
+
+// isTrusted informa se o endereço pertence a um proxy confiável
+func (cr *ClientIPResolver) isTrusted(ip net.IP) bool {
+	for _, cidr := range cr.trusted {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve retorna o IP do cliente da requisição
+func (cr *ClientIPResolver) Resolve(r *http.Request) string {
+	remote := net.ParseIP(remoteIP(r))
+	if remote == nil {
+		return remoteIP(r)
+	}
+	if !cr.isTrusted(remote) {
+		return remote.String()
+	}
+
+	var hops []string
+	switch cr.header {
+	case ClientIPHeaderForwarded:
+		hops = forwardedFor(r.Header.Values("Forwarded"))
+	case ClientIPHeaderRealIP:
+		hops = []string{strings.TrimSpace(r.Header.Get("X-Real-IP"))}
+	default:
+		for _, v := range r.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(v, ",")...)
+		}
+	}
+
+	// Da direita para a esquerda: o primeiro endereço fora dos proxies confiáveis é o cliente.
+	// Uma entrada inválida encerra a busca no último endereço conhecido.
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseHop(hops[i])
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !cr.isTrusted(ip) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// forwardedFor extrai os parâmetros for= do cabeçalho Forwarded, na ordem dos proxies
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, value)
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseHop interpreta um endereço de XFF ou Forwarded ("192.0.2.1", "192.0.2.1:4711", "[2001:db8::1]:4711");
+// "unknown" e identificadores ofuscados retornam nil
+func parseHop(hop string) net.IP {
+	hop = strings.Trim(strings.TrimSpace(hop), `"`)
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+	return net.ParseIP(strings.Trim(hop, "[]"))
+}
+
+// ClientIPMiddleware resolve o IP do cliente uma vez por requisição (lido por ClientIP)
+func ClientIPMiddleware(cr *ClientIPResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), "clientIP", cr.Resolve(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP retorna o IP do cliente resolvido pelo ClientIPMiddleware; sem ele, o IP da conexão
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value("clientIP").(string); ok && ip != "" {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+// remoteIP retorna o IP da conexão (sem a porta)
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,246 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritmos aceitos para assinar os access tokens
+const (
+	JWTAlgRS256 = "RS256"
+	JWTAlgEdDSA = "EdDSA"
+)
+
+// jwtKeyReloadInterval limita a recarga das chaves quando chega um token com kid desconhecido
+const jwtKeyReloadInterval = 10 * time.Second
+
+// SigningKey é uma chave de assinatura dos access tokens; assina a partir de ActivatesAt até a próxima chave ativar
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	PrivateKey  crypto.Signer
+	ActivatesAt time.Time
+}
+
+// JWK é uma chave pública no formato JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA: módulo
+	E   string `json:"e,omitempty"`   // RSA: expoente
+	Crv string `json:"crv,omitempty"` // OKP: curva (Ed25519)
+	X   string `json:"x,omitempty"`   // OKP: chave pública
+}
+
+// JWKS é o documento publicado em /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// GenerateSigningKey cria uma chave do algoritmo informado; o kid é derivado da chave pública
+func GenerateSigningKey(algorithm string, activatesAt time.Time) (SigningKey, error) {
+	var signer crypto.Signer
+	var err error
+	switch algorithm {
+	case JWTAlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case JWTAlgEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return SigningKey{}, fmt.Errorf("algoritmo JWT não suportado: %s", algorithm)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return SigningKey{}, err
+	}
+	thumb := sha256.Sum256(der)
+	return SigningKey{
+		ID:          base64.RawURLEncoding.EncodeToString(thumb[:12]),
+		Algorithm:   algorithm,
+		PrivateKey:  signer,
+		ActivatesAt: activatesAt,
+	}, nil
+}
+
+// EncodePrivateKey serializa a chave privada em PEM (PKCS#8)
+func (k SigningKey) EncodePrivateKey() (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// ParseSigningKey reconstrói a chave gravada por EncodePrivateKey
+func ParseSigningKey(id, algorithm, privatePEM string, activatesAt time.Time) (SigningKey, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return SigningKey{}, errors.New("chave privada inválida")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	var signer crypto.Signer
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if algorithm == JWTAlgRS256 {
+			signer = key
+		}
+	case ed25519.PrivateKey:
+		if algorithm == JWTAlgEdDSA {
+			signer = key
+		}
+	}
+	if signer == nil {
+		return SigningKey{}, fmt.Errorf("chave %s não corresponde ao algoritmo %s", id, algorithm)
+	}
+	return SigningKey{ID: id, Algorithm: algorithm, PrivateKey: signer, ActivatesAt: activatesAt}, nil
+}
+
+// method retorna o método de assinatura do golang-jwt para o algoritmo da chave
+func (k SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == JWTAlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// JWK retorna a chave pública da chave de assinatura
+func (k SigningKey) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+	switch pub := k.PrivateKey.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// JWTKeySet mantém em memória as chaves de assinatura e verificação dos access tokens.
+// A chave de assinatura é a mais recente já ativa; todas as chaves carregadas verificam (pelo kid).
+type JWTKeySet struct {
+	load func() ([]SigningKey, error)
+
+	mu       sync.RWMutex
+	keys     []SigningKey // Ordenadas por ActivatesAt
+	loadedAt time.Time
+}
+
+// NewJWTKeySet cria o conjunto de chaves; load busca as chaves publicadas (ex.: no banco)
+func NewJWTKeySet(load func() ([]SigningKey, error)) *JWTKeySet {
+	return &JWTKeySet{load: load}
+}
+
+// Reload recarrega as chaves (rotação feita por esta ou outra réplica)
+func (ks *JWTKeySet) Reload() error {
+	keys, err := ks.load()
+	if err != nil {
+		return err
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ActivatesAt.Before(keys[j].ActivatesAt) })
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.loadedAt = time.Now()
+	ks.mu.Unlock()
+	return nil
+}
+
+// signingKey retorna a chave ativa mais recente
+func (ks *JWTKeySet) signingKey(now time.Time) (SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for i := len(ks.keys) - 1; i >= 0; i-- {
+		if !ks.keys[i].ActivatesAt.After(now) {
+			return ks.keys[i], true
+		}
+	}
+	return SigningKey{}, false
+}
+
+// Sign assina as claims com a chave ativa, informando o kid no cabeçalho
+func (ks *JWTKeySet) Sign(claims jwt.Claims) (string, error) {
+	key, ok := ks.signingKey(time.Now())
+	if !ok {
+		return "", errors.New("nenhuma chave de assinatura ativa")
+	}
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// Keyfunc resolve a chave de verificação pelo kid do token (uso em jwt.Parse).
+// Um kid desconhecido provoca uma recarga limitada, para chaves recém-criadas por outra réplica.
+func (ks *JWTKeySet) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token sem kid")
+	}
+	key, ok := ks.find(kid)
+	if !ok {
+		ks.mu.RLock()
+		stale := time.Since(ks.loadedAt) >= jwtKeyReloadInterval
+		ks.mu.RUnlock()
+		if !stale || ks.Reload() != nil {
+			return nil, errors.New("chave de assinatura desconhecida")
+		}
+		if key, ok = ks.find(kid); !ok {
+			return nil, errors.New("chave de assinatura desconhecida")
+		}
+	}
+	if t.Method.Alg() != key.Algorithm {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.PrivateKey.Public(), nil
+}
+
+func (ks *JWTKeySet) find(kid string) (SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, k := range ks.keys {
+		if k.ID == kid {
+			return k, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+// JWKS retorna as chaves públicas de verificação (ativa, próxima e anteriores ainda em uso)
+func (ks *JWTKeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, k := range ks.keys {
+		set.Keys = append(set.Keys, k.JWK())
+	}
+	return set
+}
+
+// ValidJWTMethods são os algoritmos aceitos na verificação dos access tokens
+func ValidJWTMethods() []string {
+	return []string{JWTAlgRS256, JWTAlgEdDSA}
+}
@@ -0,0 +1,72 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// keyCipherPrefix identifica os valores cifrados (versão do formato)
+const keyCipherPrefix = "v1:"
+
+// KeyCipher cifra segredos guardados no banco (chaves privadas de assinatura JWT) com AES-256-GCM
+type KeyCipher struct {
+	aead cipher.AEAD
+}
+
+// NewKeyCipher cria o cifrador a partir da chave mestra em base64 (32 bytes, ex.: openssl rand -base64 32)
+func NewKeyCipher(encodedKey string) (*KeyCipher, error) {
+	encodedKey = strings.TrimSpace(encodedKey)
+	if encodedKey == "" {
+		return nil, errors.New("chave de criptografia não configurada")
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("chave de criptografia inválida (esperado base64 de 32 bytes)")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyCipher{aead: aead}, nil
+}
+
+// Encrypt cifra plaintext vinculado a aad (ex.: o kid), que precisa ser o mesmo em Decrypt.
+// O resultado é "v1:" + base64(nonce || ciphertext).
+func (c *KeyCipher) Encrypt(plaintext, aad string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return keyCipherPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decifra um valor produzido por Encrypt com o mesmo aad
+func (c *KeyCipher) Decrypt(value, aad string) (string, error) {
+	if !IsEncryptedKey(value) {
+		return "", errors.New("valor não cifrado")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, keyCipherPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("valor cifrado inválido")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(aad))
+	if err != nil {
+		return "", errors.New("falha ao decifrar (chave de criptografia incorreta?)")
+	}
+	return string(plaintext), nil
+}
+
+// IsEncryptedKey indica se o valor já está no formato de Encrypt (e não em texto puro)
+func IsEncryptedKey(value string) bool {
+	return strings.HasPrefix(value, keyCipherPrefix)
+}
@@ -0,0 +1,347 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OAuthProvider descreve um provedor de login social (authorization code + PKCE).
+// Com Issuer preenchido o provedor é OIDC (discovery + validação do id_token);
+// caso contrário é OAuth2 puro e a identidade vem de UserInfoURL/EmailsURL (GitHub).
+type OAuthProvider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	Issuer       string // OIDC: emissor (discovery em /.well-known/openid-configuration)
+	AuthURL      string // OAuth2 puro
+	TokenURL     string // OAuth2 puro
+	UserInfoURL  string // OAuth2 puro: dados do usuário
+	EmailsURL    string // OAuth2 puro: emails e status de verificação (GitHub)
+	Scopes       []string
+	Client       *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+	keysAt    time.Time
+}
+
+// OAuthIdentity é a identidade retornada pelo provedor após a troca do código
+type OAuthIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// jwksRefreshInterval limita a frequência de recarga das chaves quando aparece um kid desconhecido
+const jwksRefreshInterval = time.Minute
+
+// GeneratePKCE gera o code_verifier e o code_challenge (S256)
+func GeneratePKCE() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// IsOIDC indica se o provedor emite id_token
+func (p *OAuthProvider) IsOIDC() bool {
+	return p.Issuer != ""
+}
+
+func (p *OAuthProvider) httpClient() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// AuthorizationURL monta a URL de autorização para onde o navegador é redirecionado
+func (p *OAuthProvider) AuthorizationURL(state, nonce, codeChallenge, redirectURI string) (string, error) {
+	authURL := p.AuthURL
+	if p.IsOIDC() {
+		d, err := p.getDiscovery()
+		if err != nil {
+			return "", err
+		}
+		authURL = d.AuthorizationEndpoint
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("scope", strings.Join(p.Scopes, " "))
+	q.Set("state", state)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	if p.IsOIDC() {
+		q.Set("nonce", nonce)
+	}
+	sep := "?"
+	if strings.Contains(authURL, "?") {
+		sep = "&"
+	}
+	return authURL + sep + q.Encode(), nil
+}
+
+// Exchange troca o código de autorização pela identidade do usuário.
+// Em provedores OIDC o id_token é validado (assinatura, emissor, audiência, expiração e nonce).
+func (p *OAuthProvider) Exchange(code, codeVerifier, redirectURI, nonce string) (OAuthIdentity, error) {
+	tokenURL := p.TokenURL
+	if p.IsOIDC() {
+		d, err := p.getDiscovery()
+		if err != nil {
+			return OAuthIdentity{}, err
+		}
+		tokenURL = d.TokenEndpoint
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", p.ClientID)
+	form.Set("client_secret", p.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return OAuthIdentity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tok struct {
+		AccessToken      string `json:"access_token"`
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	// Erros do endpoint de token vêm com status 400/401 e corpo JSON (error/error_description)
+	if err := p.doJSON(req, &tok, true); err != nil {
+		return OAuthIdentity{}, err
+	}
+	if tok.Error != "" {
+		return OAuthIdentity{}, fmt.Errorf("provedor %s recusou o código: %s", p.Name, strings.TrimSpace(tok.Error+" "+tok.ErrorDescription))
+	}
+
+	if p.IsOIDC() {
+		if tok.IDToken == "" {
+			return OAuthIdentity{}, errors.New("resposta do provedor sem id_token")
+		}
+		return p.verifyIDToken(tok.IDToken, nonce)
+	}
+	if tok.AccessToken == "" {
+		return OAuthIdentity{}, errors.New("resposta do provedor sem access_token")
+	}
+	return p.fetchUserInfo(tok.AccessToken)
+}
+
+// verifyIDToken valida o id_token e extrai a identidade
+func (p *OAuthProvider) verifyIDToken(raw, nonce string) (OAuthIdentity, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return OAuthIdentity{}, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.publicKey(kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return OAuthIdentity{}, fmt.Errorf("id_token inválido: %w", err)
+	}
+
+	// Emissores multi-tenant (Microsoft "common") publicam o issuer com {tenantid}
+	expectedIssuer := d.Issuer
+	if tid, ok := claims["tid"].(string); ok {
+		expectedIssuer = strings.ReplaceAll(expectedIssuer, "{tenantid}", tid)
+	}
+	if iss, _ := claims["iss"].(string); iss != expectedIssuer {
+		return OAuthIdentity{}, errors.New("id_token inválido: emissor incorreto")
+	}
+	if n, _ := claims["nonce"].(string); n == "" || n != nonce {
+		return OAuthIdentity{}, errors.New("id_token inválido: nonce incorreto")
+	}
+
+	id := OAuthIdentity{}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.Name, _ = claims["name"].(string)
+	// email_verified (padrão OIDC) pode vir como bool ou string; a Microsoft usa xms_edov
+	id.EmailVerified = claimBool(claims["email_verified"]) || claimBool(claims["xms_edov"])
+	if id.Subject == "" {
+		return OAuthIdentity{}, errors.New("id_token inválido: sub ausente")
+	}
+	return id, nil
+}
+
+func claimBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true" || b == "1"
+	}
+	return false
+}
+
+// fetchUserInfo consulta a API do provedor OAuth2 puro (formato do GitHub)
+func (p *OAuthProvider) fetchUserInfo(accessToken string) (OAuthIdentity, error) {
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := p.getJSON(p.UserInfoURL, accessToken, &user); err != nil {
+		return OAuthIdentity{}, err
+	}
+	if user.ID == 0 {
+		return OAuthIdentity{}, errors.New("resposta do provedor sem identificador do usuário")
+	}
+	id := OAuthIdentity{Subject: strconv.FormatInt(user.ID, 10), Name: user.Name}
+	if id.Name == "" {
+		id.Name = user.Login
+	}
+
+	// Apenas o email primário e verificado é considerado
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(p.EmailsURL, accessToken, &emails); err != nil {
+		return OAuthIdentity{}, err
+	}
+	for _, e := range emails {
+		if e.Primary {
+			id.Email = e.Email
+			id.EmailVerified = e.Verified
+		}
+	}
+	return id, nil
+}
+
+// getDiscovery carrega (uma vez) o documento de discovery do emissor
+func (p *OAuthProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	var d oidcDiscovery
+	if err := p.getJSON(strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", "", &d); err != nil {
+		return nil, err
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("discovery OIDC incompleto")
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// publicKey retorna a chave RSA do kid, recarregando o JWKS em caso de rotação de chaves
+func (p *OAuthProvider) publicKey(kid string) (*rsa.PublicKey, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysAt) < jwksRefreshInterval {
+		return nil, errors.New("chave de assinatura desconhecida")
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(d.JWKSURI, "", &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+	p.keysAt = time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, errors.New("chave de assinatura desconhecida")
+}
+
+func (p *OAuthProvider) getJSON(u, bearer string, out interface{}) error {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return p.doJSON(req, out, false)
+}
+
+// doJSON executa a requisição e decodifica a resposta; errorBody aceita corpos de erro 400/401
+func (p *OAuthProvider) doJSON(req *http.Request, out interface{}, errorBody bool) error {
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao contatar provedor %s: %w", p.Name, err)
+	}
+	defer resp.Body.Close()
+	isErrorBody := errorBody && (resp.StatusCode == 400 || resp.StatusCode == 401)
+	if resp.StatusCode != 200 && !isErrorBody {
+		return fmt.Errorf("provedor %s respondeu %d", p.Name, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("resposta inválida do provedor %s: %w", p.Name, err)
+	}
+	return nil
+}
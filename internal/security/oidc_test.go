@@ -0,0 +1,208 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "client-123"
+
+// fakeIssuer é um provedor OIDC em memória: discovery, JWKS e endpoint de token com PKCE
+type fakeIssuer struct {
+	srv        *httptest.Server
+	key        *rsa.PrivateKey
+	challenges map[string]string // código -> code_challenge
+	idToken    string            // id_token devolvido na troca do código
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIssuer{key: key, challenges: make(map[string]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.srv.URL,
+			"authorization_endpoint": f.srv.URL + "/authorize",
+			"token_endpoint":         f.srv.URL + "/token",
+			"jwks_uri":               f.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		challenge, ok := f.challenges[r.PostForm.Get("code")]
+		if !ok || challenge != base64.RawURLEncoding.EncodeToString(sum[:]) {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "id_token": f.idToken})
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// sign assina um id_token com a chave do emissor
+func (f *fakeIssuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = "k1"
+	raw, err := tok.SignedString(f.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// validClaims são as claims de um id_token aceito para o nonce informado
+func (f *fakeIssuer) validClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            f.srv.URL,
+		"aud":            testClientID,
+		"sub":            "user-1",
+		"email":          "ana@exemplo.com",
+		"email_verified": true,
+		"name":           "Ana",
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func (f *fakeIssuer) provider() *OAuthProvider {
+	return &OAuthProvider{Name: "fake", ClientID: testClientID, ClientSecret: "secret", Issuer: f.srv.URL,
+		Scopes: []string{"openid", "email"}, Client: f.srv.Client()}
+}
+
+func TestOIDCAuthorizationURL(t *testing.T) {
+	f := newFakeIssuer(t)
+	authURL, err := f.provider().AuthorizationURL("state-1", "nonce-1", "challenge-1", "https://app/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL, f.srv.URL+"/authorize?") {
+		t.Errorf("URL = %s, esperado o authorization_endpoint do discovery", authURL)
+	}
+	q := u.Query()
+	for k, want := range map[string]string{
+		"client_id":             testClientID,
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        "challenge-1",
+		"code_challenge_method": "S256",
+		"redirect_uri":          "https://app/callback",
+	} {
+		if got := q.Get(k); got != want {
+			t.Errorf("%s = %q, esperado %q", k, got, want)
+		}
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	f := newFakeIssuer(t)
+	verifier, challenge, err := GeneratePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.challenges["code-1"] = challenge
+	f.idToken = f.sign(t, f.validClaims("nonce-1"))
+
+	id, err := f.provider().Exchange("code-1", verifier, "https://app/callback", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if id.Subject != "user-1" || id.Email != "ana@exemplo.com" || !id.EmailVerified || id.Name != "Ana" {
+		t.Errorf("identidade = %+v", id)
+	}
+}
+
+func TestOIDCExchangePKCEMismatch(t *testing.T) {
+	f := newFakeIssuer(t)
+	_, challenge, err := GeneratePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.challenges["code-1"] = challenge
+	f.idToken = f.sign(t, f.validClaims("nonce-1"))
+
+	otherVerifier, _, err := GeneratePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.provider().Exchange("code-1", otherVerifier, "https://app/callback", "nonce-1"); err == nil ||
+		!strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("err = %v, esperado recusa do code_verifier", err)
+	}
+}
+
+func TestOIDCVerifyIDTokenRejects(t *testing.T) {
+	f := newFakeIssuer(t)
+	tests := []struct {
+		name   string
+		mutate func(c jwt.MapClaims)
+		nonce  string
+	}{
+		{name: "emissor incorreto", mutate: func(c jwt.MapClaims) { c["iss"] = "https://outro-emissor" }, nonce: "nonce-1"},
+		{name: "audiência incorreta", mutate: func(c jwt.MapClaims) { c["aud"] = "outro-cliente" }, nonce: "nonce-1"},
+		{name: "nonce incorreto", mutate: func(c jwt.MapClaims) {}, nonce: "nonce-2"},
+		{name: "nonce ausente", mutate: func(c jwt.MapClaims) { delete(c, "nonce") }, nonce: ""},
+		{name: "expirado", mutate: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, nonce: "nonce-1"},
+		{name: "sem expiração", mutate: func(c jwt.MapClaims) { delete(c, "exp") }, nonce: "nonce-1"},
+		{name: "sem sub", mutate: func(c jwt.MapClaims) { delete(c, "sub") }, nonce: "nonce-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := f.validClaims("nonce-1")
+			tt.mutate(claims)
+			if _, err := f.provider().verifyIDToken(f.sign(t, claims), tt.nonce); err == nil {
+				t.Error("id_token deveria ser recusado")
+			}
+		})
+	}
+}
+
+func TestOIDCVerifyIDTokenRejectsForeignKey(t *testing.T) {
+	f := newFakeIssuer(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, f.validClaims("nonce-1"))
+	tok.Header["kid"] = "k1"
+	raw, err := tok.SignedString(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.provider().verifyIDToken(raw, "nonce-1"); err == nil {
+		t.Error("id_token assinado por outra chave deveria ser recusado")
+	}
+}
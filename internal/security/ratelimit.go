@@ -1,138 +1,244 @@
 package security
 
 import (
+	"esimulate-backend/internal/logger"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
-// RateLimiter implementa rate limiting simples em memória
-type RateLimiter struct {
-	requests map[string][]time.Time
-	mu       sync.RWMutex
-	limits   map[string]RateLimit
-}
+// Escopos de rate limit: a quem pertence o balde de tokens
+const (
+	RateLimitByIP   = "ip"   // IP do cliente
+	RateLimitByUser = "user" // Usuário autenticado ou, sem autenticação, IP
+)
 
-// RateLimit define limites para diferentes endpoints
+// RateLimit define um balde de tokens: até MaxRequests requisições seguidas,
+// com o balde reabastecido por completo ao longo de Window
 type RateLimit struct {
 	MaxRequests int
 	Window      time.Duration
+	Scope       string
 }
 
-// NewRateLimiter cria um novo rate limiter
-func NewRateLimiter() *RateLimiter {
-	rl := &RateLimiter{
-		requests: make(map[string][]time.Time),
-		limits: map[string]RateLimit{
-			"login":           {MaxRequests: 5, Window: 1 * time.Minute},
-			"register":        {MaxRequests: 3, Window: 1 * time.Hour},
-			"refresh":         {MaxRequests: 10, Window: 1 * time.Minute},
-			"forgot-password": {MaxRequests: 3, Window: 1 * time.Hour},
-			"verify-email":    {MaxRequests: 5, Window: 1 * time.Minute},
-		},
+// DefaultRateLimits são as regras padrão, sobrescritas por RATE_LIMITS (ex.: "login=10/1m,api=600/1m")
+var DefaultRateLimits = map[string]RateLimit{
+	"login":           {MaxRequests: 5, Window: 1 * time.Minute, Scope: RateLimitByIP},
+	"register":        {MaxRequests: 3, Window: 1 * time.Hour, Scope: RateLimitByIP},
+	"refresh":         {MaxRequests: 10, Window: 1 * time.Minute, Scope: RateLimitByIP},
+	"forgot-password": {MaxRequests: 3, Window: 1 * time.Hour, Scope: RateLimitByIP},
+	"reset-password":  {MaxRequests: 5, Window: 1 * time.Minute, Scope: RateLimitByIP},
+	"verify-email":    {MaxRequests: 5, Window: 1 * time.Minute, Scope: RateLimitByIP},
+	"2fa":             {MaxRequests: 5, Window: 1 * time.Minute, Scope: RateLimitByIP},
+	"auth":            {MaxRequests: 30, Window: 1 * time.Minute, Scope: RateLimitByIP},    // Logout, OAuth/SSO, JWKS
+	"contact":         {MaxRequests: 3, Window: 1 * time.Hour, Scope: RateLimitByIP},       // Contato com o admin
+	"public":          {MaxRequests: 60, Window: 1 * time.Minute, Scope: RateLimitByIP},    // Leituras públicas
+	"public-submit":   {MaxRequests: 30, Window: 10 * time.Minute, Scope: RateLimitByIP},   // Envio de prova por link (turmas atrás do mesmo NAT)
+	"public-events":   {MaxRequests: 600, Window: 1 * time.Minute, Scope: RateLimitByIP},   // Eventos de proctoring e TRI por link
+	"api":             {MaxRequests: 300, Window: 1 * time.Minute, Scope: RateLimitByUser}, // Rotas autenticadas
+}
+
+// ParseRateLimits aplica sobre as regras padrão as sobrescritas no formato "nome=requisições/janela,..."
+func ParseRateLimits(spec string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit, len(DefaultRateLimits))
+	for name, l := range DefaultRateLimits {
+		limits[name] = l
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		count, window, ok2 := strings.Cut(value, "/")
+		l, known := limits[strings.TrimSpace(name)]
+		if !ok || !ok2 || !known {
+			return nil, fmt.Errorf("regra de rate limit inválida: %s", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("regra de rate limit inválida: %s", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(window))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("regra de rate limit inválida: %s", entry)
+		}
+		l.MaxRequests, l.Window = n, d
+		limits[strings.TrimSpace(name)] = l
 	}
-	
-	// Limpar requisições antigas periodicamente
-	go rl.cleanup()
-	
-	return rl
-}
-
-// Allow verifica se uma requisição é permitida
-func (rl *RateLimiter) Allow(key, endpoint string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	
+	return limits, nil
+}
+
+// RateLimitStore guarda os baldes de tokens. A operação precisa ser atômica por chave para valer
+// entre réplicas (Postgres; Redis pode implementar o mesmo contrato com um script Lua).
+type RateLimitStore interface {
+	// TakeRateLimitToken reabastece o balde key até now e consome um token se houver;
+	// retorna os tokens restantes e se a requisição foi aceita
+	TakeRateLimitToken(key string, capacity int, window time.Duration, now time.Time) (float64, bool, error)
+}
+
+// RateLimitResult é o estado do balde após uma requisição (base dos headers RateLimit-*)
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Até o balde voltar a ficar cheio
+	RetryAfter time.Duration // Até o próximo token (requisições recusadas)
+}
+
+// RateLimiter aplica as regras de rate limit com token bucket sobre um RateLimitStore
+type RateLimiter struct {
+	store  RateLimitStore
+	limits map[string]RateLimit
+	audit  *AuditLogger
+}
+
+// NewRateLimiter cria o rate limiter; limits vem de ParseRateLimits (nil usa as regras padrão)
+func NewRateLimiter(store RateLimitStore, limits map[string]RateLimit, audit *AuditLogger) *RateLimiter {
+	if limits == nil {
+		limits = DefaultRateLimits
+	}
+	return &RateLimiter{store: store, limits: limits, audit: audit}
+}
+
+// Take consome um token do balde de subject na regra endpoint.
+// Endpoints sem regra não são limitados; com o armazenamento indisponível a requisição é aceita (a falha é registrada no log).
+func (rl *RateLimiter) Take(endpoint, subject string) (RateLimitResult, bool) {
 	limit, exists := rl.limits[endpoint]
 	if !exists {
-		return true // Sem limite para endpoints não configurados
+		return RateLimitResult{}, false
+	}
+
+	tokens, allowed, err := rl.store.TakeRateLimitToken(endpoint+":"+subject, limit.MaxRequests, limit.Window, time.Now())
+	if err != nil {
+		logger.Error("Erro no rate limit (%s): %v", endpoint, err)
+		return RateLimitResult{}, false
+	}
+
+	perToken := limit.Window / time.Duration(limit.MaxRequests)
+	res := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit.MaxRequests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.MaxRequests) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
 	}
-	
-	now := time.Now()
-	windowStart := now.Add(-limit.Window)
-	
-	// Limpar requisições antigas
-	requests := rl.requests[key]
-	validRequests := []time.Time{}
-	for _, reqTime := range requests {
-		if reqTime.After(windowStart) {
-			validRequests = append(validRequests, reqTime)
+	return res, true
+}
+
+// subject identifica o dono do balde conforme o escopo da regra
+func (rl *RateLimiter) subject(r *http.Request, endpoint string) string {
+	if rl.limits[endpoint].Scope == RateLimitByUser {
+		// userID é definido pelo AuthMiddleware
+		if id, ok := r.Context().Value("userID").(string); ok && id != "" {
+			return "user:" + id
 		}
 	}
-	
-	// Verificar limite
-	if len(validRequests) >= limit.MaxRequests {
-		return false
+	return "ip:" + ClientIP(r)
+}
+
+// MemoryRateLimitStore guarda os baldes em memória (uma única instância da API)
+type MemoryRateLimitStore struct {
+	buckets map[string]*memoryBucket
+	mu      sync.Mutex
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time // Quando o balde volta a ficar cheio (pode ser descartado)
+}
+
+// NewMemoryRateLimitStore cria o armazenamento em memória
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{buckets: make(map[string]*memoryBucket)}
+	go s.cleanup()
+	return s
+}
+
+// TakeRateLimitToken implementa RateLimitStore
+func (s *MemoryRateLimitStore) TakeRateLimitToken(key string, capacity int, window time.Duration, now time.Time) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rate := float64(capacity) / window.Seconds()
+	b, exists := s.buckets[key]
+	if !exists {
+		b = &memoryBucket{tokens: float64(capacity), updatedAt: now}
+		s.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(capacity), b.tokens+elapsed*rate)
+		b.updatedAt = now
 	}
-	
-	// Adicionar nova requisição
-	validRequests = append(validRequests, now)
-	rl.requests[key] = validRequests
-	
-	return true
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.fullAt = now.Add(time.Duration((float64(capacity) - b.tokens) / rate * float64(time.Second)))
+	return b.tokens, allowed, nil
 }
 
-// cleanup remove requisições antigas periodicamente
-func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(5 * time.Minute)
+// cleanup descarta periodicamente os baldes já cheios
+func (s *MemoryRateLimitStore) cleanup() {
+	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
-	
+
 	for range ticker.C {
-		rl.mu.Lock()
 		now := time.Now()
-		for key, requests := range rl.requests {
-			validRequests := []time.Time{}
-			for _, reqTime := range requests {
-				// Manter apenas requisições dos últimos 10 minutos
-				if reqTime.After(now.Add(-10 * time.Minute)) {
-					validRequests = append(validRequests, reqTime)
-				}
-			}
-			if len(validRequests) == 0 {
-				delete(rl.requests, key)
-			} else {
-				rl.requests[key] = validRequests
+		s.mu.Lock()
+		for key, b := range s.buckets {
+			if now.After(b.fullAt) {
+				delete(s.buckets, key)
 			}
 		}
-		rl.mu.Unlock()
+		s.mu.Unlock()
 	}
 }
 
-// RateLimitMiddleware cria um middleware de rate limiting
+// RateLimitMiddleware cria um middleware de rate limiting com os headers RateLimit-* (draft IETF)
 func RateLimitMiddleware(rl *RateLimiter, endpoint string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			// Usar IP como chave
-			key := getClientIP(r) + ":" + endpoint
-			
-			if !rl.Allow(key, endpoint) {
+			res, limited := rl.Take(endpoint, rl.subject(r, endpoint))
+			if !limited {
+				next(w, r)
+				return
+			}
+
+			limit := rl.limits[endpoint]
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.MaxRequests, ceilSeconds(limit.Window)))
+
+			if !res.Allowed {
+				if rl.audit != nil {
+					rl.audit.LogRateLimit(endpoint, ClientIP(r))
+				}
 				w.Header().Set("Content-Type", "application/json")
-				w.Header().Set("Retry-After", "60")
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
 				w.WriteHeader(http.StatusTooManyRequests)
 				w.Write([]byte(`{"error": "Muitas requisições. Tente novamente mais tarde."}`))
 				return
 			}
-			
+
 			next(w, r)
 		}
 	}
 }
 
-// getClientIP extrai o IP real do cliente
-func getClientIP(r *http.Request) string {
-	// Verificar X-Forwarded-For (proxy/load balancer)
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		return forwarded
-	}
-	
-	// Verificar X-Real-IP
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
+// ceilSeconds arredonda a duração para cima em segundos (mínimo 1)
+func ceilSeconds(d time.Duration) int {
+	if s := int(math.Ceil(d.Seconds())); s > 1 {
+		return s
 	}
-	
-	// Usar RemoteAddr como fallback
-	return r.RemoteAddr
+	return 1
 }
-
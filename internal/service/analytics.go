@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"sort"
+)
+
+const (
+	// weakTopicMinAnswers evita classificar como fraco um tópico com poucas respostas
+	weakTopicMinAnswers = 3
+	// weakTopicLimit é o número máximo de tópicos retornados em weakestTopics
+	weakTopicLimit = 5
+)
+
+// GetUserAnalytics agrega o desempenho do usuário por matéria e tópico
+func (s *Service) GetUserAnalytics(userID string, f domain.AnalyticsFilter) (domain.UserAnalytics, error) {
+	if f.Interval == "" {
+		f.Interval = "week"
+	}
+	if f.Interval != "day" && f.Interval != "week" && f.Interval != "month" {
+		return domain.UserAnalytics{}, errors.New("intervalo inválido (use day, week ou month)")
+	}
+	if f.From > 0 && f.To > 0 && f.From > f.To {
+		return domain.UserAnalytics{}, errors.New("período inválido")
+	}
+
+	overall, err := s.Repo.GetUserOverallStats(userID, f)
+	if err != nil {
+		return domain.UserAnalytics{}, err
+	}
+	subjects, err := s.Repo.GetUserSubjectStats(userID, f)
+	if err != nil {
+		return domain.UserAnalytics{}, err
+	}
+	topics, err := s.Repo.GetUserTopicStats(userID, f)
+	if err != nil {
+		return domain.UserAnalytics{}, err
+	}
+	trend, err := s.Repo.GetUserTrend(userID, f)
+	if err != nil {
+		return domain.UserAnalytics{}, err
+	}
+
+	overall.Accuracy = accuracy(overall.Correct, overall.Answered)
+	for i := range subjects {
+		subjects[i].Accuracy = accuracy(subjects[i].Correct, subjects[i].Answered)
+	}
+	for i := range topics {
+		topics[i].Accuracy = accuracy(topics[i].Correct, topics[i].Answered)
+	}
+	for i := range trend {
+		trend[i].Accuracy = accuracy(trend[i].Correct, trend[i].Answered)
+	}
+
+	return domain.UserAnalytics{
+		Overall:       overall,
+		Subjects:      subjects,
+		Topics:        topics,
+		Trend:         trend,
+		WeakestTopics: weakestTopics(topics),
+	}, nil
+}
+
+// weakestTopics retorna os tópicos com menor percentual de acerto (com amostra mínima)
+func weakestTopics(topics []domain.PerformanceStat) []domain.PerformanceStat {
+	candidates := []domain.PerformanceStat{}
+	for _, t := range topics {
+		if t.Answered >= weakTopicMinAnswers {
+			candidates = append(candidates, t)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Accuracy == candidates[j].Accuracy {
+			return candidates[i].Answered > candidates[j].Answered
+		}
+		return candidates[i].Accuracy < candidates[j].Accuracy
+	})
+	if len(candidates) > weakTopicLimit {
+		candidates = candidates[:weakTopicLimit]
+	}
+	return candidates
+}
+
+// accuracy calcula o percentual de acertos arredondado em duas casas
+func accuracy(correct, answered int) float64 {
+	if answered == 0 {
+		return 0
+	}
+	return float64(int(float64(correct)/float64(answered)*10000+0.5)) / 100
+}
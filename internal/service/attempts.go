@@ -0,0 +1,235 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/logger"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Códigos de erro retornados quando uma tentativa é bloqueada
+const (
+	AttemptLimitReached  = "ATTEMPT_LIMIT_REACHED"
+	AttemptCooldown      = "ATTEMPT_COOLDOWN"
+	AttemptEmailRequired = "CANDIDATE_EMAIL_REQUIRED"
+)
+
+// AttemptError descreve uma tentativa bloqueada pela política do exame ou do link
+type AttemptError struct {
+	Code       string `json:"code"`
+	Message    string `json:"error"`
+	RetryAfter int    `json:"retryAfter,omitempty"` // Segundos até a próxima tentativa (apenas cooldown)
+}
+
+func (e *AttemptError) Error() string {
+	return e.Message
+}
+
+// ValidateAttemptPolicy valida a política recebida na criação de exames e links
+func ValidateAttemptPolicy(p *domain.AttemptPolicy) error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxAttempts < 0 || p.CooldownMinutes < 0 {
+		return errors.New("política de tentativas inválida")
+	}
+	switch p.ScoringMode {
+	case "", domain.ScoringFirst, domain.ScoringBest, domain.ScoringLast:
+		return nil
+	}
+	return errors.New("política de tentativas inválida")
+}
+
+// attemptRule retorna a política aplicável e o escopo das tentativas que ela conta:
+// a política do link prevalece sobre a do exame e conta apenas as tentativas do link
+func attemptRule(exam domain.Exam, link domain.PublicLink, userID, email string) (*domain.AttemptPolicy, domain.AttemptScope) {
+	scope := domain.AttemptScope{ExamID: exam.ID, UserID: userID, Email: strings.TrimSpace(email)}
+	if link.AttemptPolicy != nil {
+		scope.LinkID = link.ID
+		return link.AttemptPolicy, scope
+	}
+	return exam.AttemptPolicy, scope
+}
+
+// assignmentAttemptRule retorna a política da entrega de atividade (a da atividade prevalece sobre a do exame),
+// que conta apenas as tentativas da atividade
+func assignmentAttemptRule(a domain.Assignment, exam domain.Exam, userID string) (*domain.AttemptPolicy, domain.AttemptScope) {
+	policy := a.AttemptPolicy
+	if policy == nil {
+		policy = exam.AttemptPolicy
+	}
+	return policy, domain.AttemptScope{ExamID: exam.ID, AssignmentID: a.ID, UserID: userID}
+}
+
+// limitsAttempts indica se a política restringe novas tentativas
+func limitsAttempts(policy *domain.AttemptPolicy) bool {
+	return policy != nil && (policy.MaxAttempts > 0 || policy.CooldownMinutes > 0)
+}
+
+// CheckAttemptAllowed verifica limite e cooldown antes de aceitar uma nova tentativa.
+// Para links públicos o candidato é identificado pelo email; para usuários, pelo userID.
+// Retorna *AttemptError quando a tentativa deve ser recusada.
+func (s *Service) CheckAttemptAllowed(exam domain.Exam, link domain.PublicLink, userID, email string) error {
+	policy, scope := attemptRule(exam, link, userID, email)
+	if !limitsAttempts(policy) {
+		return nil
+	}
+	if userID == "" && scope.Email == "" {
+		return &AttemptError{Code: AttemptEmailRequired, Message: "Email do candidato é obrigatório"}
+	}
+	count, last, err := s.Repo.CountAttempts(scope)
+	if err != nil {
+		return err
+	}
+	return enforceAttemptPolicy(policy, count, last)
+}
+
+// SubmitResult grava a tentativa e associa os eventos de proctoring. Limite e cooldown são conferidos de novo
+// na mesma transação da gravação, para que tentativas simultâneas não ultrapassem a política.
+// res.AttemptID é opcional (sem ele a tentativa fica sem eventos de proctoring); quando informado, precisa ter sido
+// emitido para o mesmo exame e candidato e é consumido na gravação.
+// Entregas de atividade (res.AssignmentID, já validada por CheckAssignmentAttempt) seguem a política da atividade.
+func (s *Service) SubmitResult(res domain.ExamResult, exam domain.Exam, link domain.PublicLink) error {
+	if res.AttemptID != "" {
+		if _, err := uuid.Parse(res.AttemptID); err != nil {
+			return errors.New("attemptId inválido")
+		}
+	}
+	policy, scope := attemptRule(exam, link, res.UserID, res.CandidateEmail)
+	if res.AssignmentID != "" {
+		a, err := s.Repo.GetAssignmentByID(res.AssignmentID)
+		if err != nil {
+			return errors.New("atividade não encontrada")
+		}
+		policy, scope = assignmentAttemptRule(a, exam, res.UserID)
+	}
+	check := func(count int, last time.Time) error {
+		if !limitsAttempts(policy) {
+			return nil
+		}
+		return enforceAttemptPolicy(policy, count, last)
+	}
+	if err := s.Repo.CreateResultWithinPolicy(res, scope, check); err != nil {
+		return err
+	}
+	if res.AttemptID != "" {
+		// O resultado já foi gravado: falhar aqui só deixa os eventos sem vínculo com ele
+		if err := s.AttachAttemptEvents(res.AttemptID, res.ExamID, res.ID); err != nil {
+			logger.Error("Erro ao associar eventos da tentativa %s ao resultado %s: %v", res.AttemptID, res.ID, err)
+		}
+	}
+	return nil
+}
+
+// enforceAttemptPolicy aplica limite e cooldown dado o histórico do candidato (quantidade e última tentativa)
+func enforceAttemptPolicy(policy *domain.AttemptPolicy, count int, last time.Time) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxAttempts > 0 && count >= policy.MaxAttempts {
+		return &AttemptError{Code: AttemptLimitReached, Message: "Limite de tentativas atingido"}
+	}
+	if policy.CooldownMinutes > 0 && count > 0 {
+		next := last.Add(time.Duration(policy.CooldownMinutes) * time.Minute)
+		if wait := time.Until(next); wait > 0 {
+			return &AttemptError{
+				Code:       AttemptCooldown,
+				Message:    "Aguarde para tentar novamente",
+				RetryAfter: int(wait.Seconds()) + 1,
+			}
+		}
+	}
+	return nil
+}
+
+// GetCompanyAttempts agrupa as tentativas por candidato (email) e exame,
+// indicando qual tentativa conta conforme o scoringMode
+func (s *Service) GetCompanyAttempts(orgID string) ([]domain.CandidateAttempts, error) {
+	results, err := s.Repo.GetCompanyAttemptResults(orgID)
+	if err != nil {
+		return nil, err
+	}
+	links, _ := s.Repo.GetLinks(orgID)
+	linkPolicies := map[string]*domain.AttemptPolicy{}
+	for _, l := range links {
+		linkPolicies[l.ID] = l.AttemptPolicy
+	}
+	examPolicies := map[string]*domain.AttemptPolicy{}
+
+	groups := []domain.CandidateAttempts{}
+	index := map[string]int{}
+	for _, res := range results {
+		key := res.ExamID + "|" + strings.ToLower(res.CandidateEmail)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, domain.CandidateAttempts{
+				ExamID:         res.ExamID,
+				ExamTitle:      res.ExamTitle,
+				CandidateEmail: res.CandidateEmail,
+				Attempts:       []domain.ExamResult{},
+			})
+		}
+		if res.CandidateName != "" {
+			groups[i].CandidateName = res.CandidateName
+		}
+		groups[i].Attempts = append(groups[i].Attempts, res)
+	}
+
+	for i := range groups {
+		g := &groups[i]
+		// Política do link da primeira tentativa, senão a do exame
+		var policy *domain.AttemptPolicy
+		if p := linkPolicies[g.Attempts[0].LinkID]; p != nil {
+			policy = p
+		} else {
+			p, cached := examPolicies[g.ExamID]
+			if !cached {
+				if exam, err := s.Repo.GetExamByID(g.ExamID); err == nil {
+					p = exam.AttemptPolicy
+				}
+				examPolicies[g.ExamID] = p
+			}
+			policy = p
+		}
+		g.ScoringMode = domain.ScoringBest
+		if policy != nil && policy.ScoringMode != "" {
+			g.ScoringMode = policy.ScoringMode
+		}
+
+		counted := countedAttempt(g.Attempts, g.ScoringMode)
+		g.CountedResultID = counted.ID
+		g.CountedScore = counted.Score
+		g.TotalQuestions = counted.TotalQuestions
+	}
+	return groups, nil
+}
+
+// countedAttempt escolhe a tentativa que conta conforme o scoringMode (attempts em ordem cronológica, não vazio).
+// Quem chama resolve o padrão (best) quando a política não define o modo.
+func countedAttempt(attempts []domain.ExamResult, mode string) domain.ExamResult {
+	counted := attempts[0]
+	switch mode {
+	case domain.ScoringLast:
+		counted = attempts[len(attempts)-1]
+	case domain.ScoringBest:
+		for _, a := range attempts {
+			if scoreRatio(a) > scoreRatio(counted) {
+				counted = a
+			}
+		}
+	}
+	return counted
+}
+
+// scoreRatio compara tentativas com totais diferentes (ex.: exames adaptativos)
+func scoreRatio(r domain.ExamResult) float64 {
+	if r.TotalQuestions == 0 {
+		return 0
+	}
+	return float64(r.Score) / float64(r.TotalQuestions)
+}
@@ -0,0 +1,38 @@
+package service
+
+import "esimulate-backend/internal/domain"
+
+// Paginação da trilha de auditoria
+const (
+	auditDefaultLimit = 50
+	auditMaxLimit     = 200
+)
+
+// AuditPage é uma página da trilha de auditoria; NextCursor é repassado como ?cursor= para a próxima (0: fim)
+type AuditPage struct {
+	Events     []domain.AuditEvent `json:"events"`
+	NextCursor int64               `json:"nextCursor,omitempty"`
+}
+
+// ListAuditEvents consulta a trilha de auditoria do mais recente para o mais antigo (uso do admin)
+func (s *Service) ListAuditEvents(f domain.AuditFilter) (AuditPage, error) {
+	if f.Limit <= 0 {
+		f.Limit = auditDefaultLimit
+	}
+	if f.Limit > auditMaxLimit {
+		f.Limit = auditMaxLimit
+	}
+	// Um evento a mais indica se há próxima página
+	want := f.Limit
+	f.Limit++
+	events, err := s.Repo.ListAuditEvents(f)
+	if err != nil {
+		return AuditPage{}, err
+	}
+	page := AuditPage{Events: events}
+	if len(events) > want {
+		page.Events = events[:want]
+		page.NextCursor = page.Events[want-1].ID
+	}
+	return page, nil
+}
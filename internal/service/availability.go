@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"math"
+	"time"
+	_ "time/tzdata" // Fusos IANA disponíveis mesmo em imagens sem tzdata
+)
+
+// Códigos de erro para acessos fora da janela de disponibilidade
+const (
+	ExamNotYetOpen   = "EXAM_NOT_YET_OPEN"
+	ExamWindowClosed = "EXAM_WINDOW_CLOSED"
+)
+
+// availabilitySubmitGrace tolera a latência de envio de quem terminou no limite da janela
+const availabilitySubmitGrace = 2 * time.Minute
+
+// Countdown é retornado no lugar do exame para candidatos que chegam antes da abertura
+type Countdown struct {
+	ExamTitle        string `json:"examTitle"`
+	OpensAt          int64  `json:"opensAt"`
+	ClosesAt         int64  `json:"closesAt,omitempty"`
+	Timezone         string `json:"timezone"`
+	OpensAtLocal     string `json:"opensAtLocal"`            // RFC 3339 no fuso da janela
+	ClosesAtLocal    string `json:"closesAtLocal,omitempty"` // RFC 3339 no fuso da janela
+	ServerTime       int64  `json:"serverTime"`
+	SecondsUntilOpen int    `json:"secondsUntilOpen"`
+}
+
+// AvailabilityError descreve um acesso fora da janela; antes da abertura inclui a contagem regressiva
+type AvailabilityError struct {
+	Code      string     `json:"code"`
+	Message   string     `json:"error"`
+	Countdown *Countdown `json:"countdown,omitempty"`
+}
+
+func (e *AvailabilityError) Error() string {
+	return e.Message
+}
+
+// defaultTimezone é usado quando a janela não informa o fuso (DEFAULT_TIMEZONE, padrão America/Sao_Paulo)
+func defaultTimezone() string {
+	return getEnv("DEFAULT_TIMEZONE", "America/Sao_Paulo")
+}
+
+// ValidateAvailability valida a janela recebida na criação de exames e links
+func ValidateAvailability(a *domain.Availability) error {
+	if a == nil {
+		return nil
+	}
+	if a.AvailableFrom < 0 || a.AvailableUntil < 0 || a.ReminderMinutes < 0 {
+		return errors.New("janela de disponibilidade inválida")
+	}
+	if a.AvailableFrom > 0 && a.AvailableUntil > 0 && a.AvailableUntil <= a.AvailableFrom {
+		return errors.New("janela de disponibilidade inválida: o encerramento deve ser após a abertura")
+	}
+	if a.Timezone != "" {
+		if _, err := time.LoadLocation(a.Timezone); err != nil {
+			return errors.New("janela de disponibilidade inválida: fuso horário desconhecido")
+		}
+	}
+	if a.ReminderMinutes > 0 && a.AvailableFrom == 0 {
+		return errors.New("janela de disponibilidade inválida: lembrete exige horário de abertura")
+	}
+	return nil
+}
+
+// effectiveAvailability combina as janelas do exame e do link: o link só pode restringir a do exame.
+// O fuso e o lembrete do link prevalecem sobre os do exame.
+func effectiveAvailability(exam, link *domain.Availability) domain.Availability {
+	var w domain.Availability
+	for _, a := range []*domain.Availability{exam, link} {
+		if a == nil {
+			continue
+		}
+		if a.AvailableFrom > w.AvailableFrom {
+			w.AvailableFrom = a.AvailableFrom
+		}
+		if a.AvailableUntil > 0 && (w.AvailableUntil == 0 || a.AvailableUntil < w.AvailableUntil) {
+			w.AvailableUntil = a.AvailableUntil
+		}
+		if a.Timezone != "" {
+			w.Timezone = a.Timezone
+		}
+		if a.ReminderMinutes > 0 {
+			w.ReminderMinutes = a.ReminderMinutes
+		}
+	}
+	if w.Timezone == "" {
+		w.Timezone = defaultTimezone()
+	}
+	return w
+}
+
+// windowLocation carrega o fuso da janela (UTC se inválido)
+func windowLocation(w domain.Availability) *time.Location {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// checkAvailability verifica se o instante está dentro da janela efetiva do exame e do link.
+// grace estende o encerramento (envio de respostas).
+func checkAvailability(exam domain.Exam, link domain.PublicLink, now time.Time, grace time.Duration) error {
+	if exam.Availability == nil && link.Availability == nil {
+		return nil
+	}
+	w := effectiveAvailability(exam.Availability, link.Availability)
+	loc := windowLocation(w)
+	nowMs := now.UnixMilli()
+
+	if w.AvailableFrom > 0 && nowMs < w.AvailableFrom {
+		opens := time.UnixMilli(w.AvailableFrom).In(loc)
+		countdown := &Countdown{
+			ExamTitle:        exam.Title,
+			OpensAt:          w.AvailableFrom,
+			ClosesAt:         w.AvailableUntil,
+			Timezone:         loc.String(),
+			OpensAtLocal:     opens.Format(time.RFC3339),
+			ServerTime:       nowMs,
+			SecondsUntilOpen: int(math.Ceil(opens.Sub(now).Seconds())),
+		}
+		if w.AvailableUntil > 0 {
+			countdown.ClosesAtLocal = time.UnixMilli(w.AvailableUntil).In(loc).Format(time.RFC3339)
+		}
+		return &AvailabilityError{Code: ExamNotYetOpen, Message: "A prova ainda não está disponível", Countdown: countdown}
+	}
+	if w.AvailableUntil > 0 && now.After(time.UnixMilli(w.AvailableUntil).Add(grace)) {
+		return &AvailabilityError{Code: ExamWindowClosed, Message: "O período de realização da prova foi encerrado"}
+	}
+	return nil
+}
+
+// CheckLinkAvailability valida a janela no envio de respostas via link público
+func (s *Service) CheckLinkAvailability(exam domain.Exam, link domain.PublicLink) error {
+	return checkAvailability(exam, link, time.Now(), availabilitySubmitGrace)
+}
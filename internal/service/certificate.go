@@ -0,0 +1,191 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/pdf"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// certificateAlphabet exclui caracteres ambíguos (0/O, 1/I/L)
+const certificateAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// newCertificateCode gera um código de verificação no formato XXXX-XXXX-XXXX
+func newCertificateCode() (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(certificateAlphabet)))
+	for i := 0; i < 12; i++ {
+		if i > 0 && i%4 == 0 {
+			sb.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(certificateAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// GetResultCertificate retorna o certificado do resultado, emitindo-o na primeira solicitação.
+// Podem obter o certificado o dono do resultado, os membros da organização do link e admins.
+// Retorna também o logo da empresa emissora (data URL ou "").
+func (s *Service) GetResultCertificate(resultID, userID, role string) (domain.Certificate, string, error) {
+	res, err := s.Repo.GetResultByID(resultID)
+	if err != nil {
+		return domain.Certificate{}, "", errors.New("resultado não encontrado")
+	}
+	companyID, orgID, err := s.Repo.GetResultCompanyID(resultID)
+	if err != nil {
+		return domain.Certificate{}, "", err
+	}
+	isOrgMember := false
+	if orgID != "" {
+		memberRole, _ := s.Repo.GetOrgMemberRole(orgID, userID)
+		isOrgMember = memberRole != ""
+	}
+	if role != "admin" && res.UserID != userID && !isOrgMember && (companyID == "" || companyID != userID) {
+		return domain.Certificate{}, "", errors.New("acesso negado")
+	}
+
+	// Emissor: organização do link; senão a organização dona do exame
+	issuerID := companyID
+	if orgID == "" {
+		if exam, err := s.Repo.GetExamByID(res.ExamID); err == nil && exam.OrgID != "" {
+			orgID, issuerID = exam.OrgID, exam.CreatedBy
+		}
+	}
+	issuerName, logo := "eSimulate", ""
+	if orgID != "" {
+		if org, err := s.Repo.GetOrganization(orgID); err == nil {
+			issuerName, logo = org.CommercialName, org.Logo
+		}
+	}
+
+	if cert, err := s.Repo.GetCertificateByResult(resultID); err == nil {
+		return cert, logo, nil
+	}
+
+	// Resultados com tentativa emitida foram pontuados pelo servidor; os demais (inclusive os anteriores à pontuação
+	// no servidor) só são certificados se a nota conferir com os acertos registrados na submissão, pelo gabarito da época.
+	// Resultados adaptativos já são pontuados pela sessão.
+	if res.AttemptID == "" && res.Ability == nil {
+		recorded, err := s.Repo.GetRecordedScore(resultID)
+		if err != nil {
+			return domain.Certificate{}, "", err
+		}
+		if recorded != res.Score {
+			return domain.Certificate{}, "", errors.New("pontuação não verificada")
+		}
+	}
+
+	candidateName := res.CandidateName
+	if res.UserID != "" {
+		if u, err := s.Repo.GetUserByID(res.UserID); err == nil {
+			candidateName = u.Name
+		}
+	}
+	code, err := newCertificateCode()
+	if err != nil {
+		return domain.Certificate{}, "", err
+	}
+	cert := domain.Certificate{
+		ID:             uuid.New().String(),
+		Code:           code,
+		ResultID:       res.ID,
+		CandidateName:  candidateName,
+		ExamTitle:      res.ExamTitle,
+		Score:          res.Score,
+		TotalQuestions: res.TotalQuestions,
+		CompletedAt:    res.Date,
+		IssuerID:       issuerID,
+		IssuerName:     issuerName,
+		IssuedAt:       time.Now().UnixMilli(),
+	}
+	if err := s.Repo.CreateCertificate(cert); err != nil {
+		return domain.Certificate{}, "", err
+	}
+	// Relê para obter o certificado vencedor em caso de emissão concorrente
+	cert, err = s.Repo.GetCertificateByResult(resultID)
+	return cert, logo, err
+}
+
+// VerifyCertificate confirma a autenticidade de um certificado pelo código
+func (s *Service) VerifyCertificate(code string) (domain.Certificate, error) {
+	cert, err := s.Repo.GetCertificateByCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return domain.Certificate{}, errors.New("certificado não encontrado")
+	}
+	return cert, nil
+}
+
+// RenderCertificatePDF gera o PDF do certificado (A4 paisagem)
+func RenderCertificatePDF(cert domain.Certificate, logo string) []byte {
+	doc := pdf.New(pdf.A4Height, pdf.A4Width)
+	doc.AddPage()
+
+	// Moldura
+	doc.SetStrokeColor(30, 58, 138)
+	doc.SetLineWidth(3)
+	doc.Rect(24, 24, doc.Width-48, doc.Height-48, false)
+	doc.SetLineWidth(0.8)
+	doc.Rect(34, 34, doc.Width-68, doc.Height-68, false)
+
+	y := 80.0
+	if img, err := pdf.ImageFromDataURL(logo); err == nil {
+		b := img.Bounds()
+		h := 50.0
+		w := h * float64(b.Dx()) / float64(b.Dy())
+		if w > 200 {
+			w, h = 200, 200*float64(b.Dy())/float64(b.Dx())
+		}
+		doc.Image(img, (doc.Width-w)/2, y-20, w, h)
+		y += h + 10
+	}
+
+	doc.SetFillColor(30, 58, 138)
+	doc.SetFont(true, 14)
+	doc.TextCentered(y, cert.IssuerName)
+
+	doc.SetFont(true, 30)
+	doc.TextCentered(y+55, "CERTIFICADO DE CONCLUSÃO")
+
+	doc.SetFillColor(40, 40, 40)
+	doc.SetFont(false, 14)
+	doc.TextCentered(y+100, "Certificamos que")
+	doc.SetFont(true, 26)
+	doc.TextCentered(y+138, cert.CandidateName)
+	doc.SetFont(false, 14)
+	doc.TextCentered(y+170, "concluiu o simulado")
+
+	doc.SetFont(true, 18)
+	lineY := y + 200
+	for _, line := range doc.WrapText(cert.ExamTitle, doc.Width-200) {
+		doc.TextCentered(lineY, line)
+		lineY += 24
+	}
+
+	percent := 0
+	if cert.TotalQuestions > 0 {
+		percent = cert.Score * 100 / cert.TotalQuestions
+	}
+	doc.SetFont(false, 14)
+	doc.TextCentered(lineY+10, fmt.Sprintf("com aproveitamento de %d de %d questões (%d%%), em %s.",
+		cert.Score, cert.TotalQuestions, percent, time.UnixMilli(cert.CompletedAt).Format("02/01/2006")))
+
+	// Rodapé com o código de verificação
+	doc.SetFillColor(90, 90, 90)
+	doc.SetFont(true, 11)
+	doc.TextCentered(doc.Height-80, "Código de verificação: "+cert.Code)
+	doc.SetFont(false, 9)
+	doc.TextCentered(doc.Height-64, fmt.Sprintf("Verifique a autenticidade em %s/#/certificates/%s",
+		getEnv("APP_URL", "http://localhost:3000"), cert.Code))
+
+	return doc.Bytes()
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Códigos de erro para tentativas fora da janela da atividade
+const (
+	AssignmentNotOpen = "ASSIGNMENT_NOT_OPEN"
+	AssignmentClosed  = "ASSIGNMENT_CLOSED"
+)
+
+// newClassInviteCode gera um código de convite de 8 caracteres (mesmo alfabeto dos certificados)
+func newClassInviteCode() (string, error) {
+	code := make([]byte, 8)
+	max := big.NewInt(int64(len(certificateAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = certificateAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// CreateClass cria uma turma; o usuário passa a ser o professor
+func (s *Service) CreateClass(teacherID, name, description string) (domain.Classroom, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return domain.Classroom{}, errors.New("nome da turma é obrigatório")
+	}
+	code, err := newClassInviteCode()
+	if err != nil {
+		return domain.Classroom{}, err
+	}
+	c := domain.Classroom{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		TeacherID:   teacherID,
+		InviteCode:  code,
+		CreatedAt:   time.Now().UnixMilli(),
+	}
+	if err := s.Repo.CreateClass(c); err != nil {
+		return domain.Classroom{}, err
+	}
+	return s.Repo.GetClassByID(c.ID)
+}
+
+// GetMyClasses lista as turmas do usuário; o código de convite só aparece para o professor
+func (s *Service) GetMyClasses(userID string) ([]domain.Classroom, error) {
+	classes, err := s.Repo.GetUserClasses(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range classes {
+		if classes[i].Role != domain.ClassTeacher {
+			classes[i].InviteCode = ""
+		}
+	}
+	return classes, nil
+}
+
+// GetClass retorna a turma para o professor, alunos matriculados e admins
+func (s *Service) GetClass(classID, userID, role string) (domain.Classroom, error) {
+	c, err := s.Repo.GetClassByID(classID)
+	if err != nil {
+		return domain.Classroom{}, errors.New("turma não encontrada")
+	}
+	if c.TeacherID == userID || role == "admin" {
+		c.Role = domain.ClassTeacher
+		return c, nil
+	}
+	if member, _ := s.Repo.IsClassMember(classID, userID); !member {
+		return domain.Classroom{}, errors.New("turma não encontrada")
+	}
+	c.Role = domain.ClassStudent
+	c.InviteCode = ""
+	return c, nil
+}
+
+// teacherClass carrega a turma e exige que o usuário seja o professor (ou admin)
+func (s *Service) teacherClass(classID, userID, role string) (domain.Classroom, error) {
+	c, err := s.Repo.GetClassByID(classID)
+	if err != nil {
+		return domain.Classroom{}, errors.New("turma não encontrada")
+	}
+	if c.TeacherID != userID && role != "admin" {
+		return domain.Classroom{}, errors.New("acesso negado")
+	}
+	c.Role = domain.ClassTeacher
+	return c, nil
+}
+
+// DeleteClass remove a turma (apenas o professor ou admin)
+func (s *Service) DeleteClass(classID, userID, role string) error {
+	if _, err := s.teacherClass(classID, userID, role); err != nil {
+		return err
+	}
+	err := s.Repo.DeleteClass(classID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("turma não encontrada")
+	}
+	return err
+}
+
+// RotateClassCode gera um novo código de convite; o anterior deixa de funcionar
+func (s *Service) RotateClassCode(classID, userID, role string) (domain.Classroom, error) {
+	c, err := s.teacherClass(classID, userID, role)
+	if err != nil {
+		return domain.Classroom{}, err
+	}
+	code, err := newClassInviteCode()
+	if err != nil {
+		return domain.Classroom{}, err
+	}
+	if err := s.Repo.UpdateClassInviteCode(classID, code); err != nil {
+		return domain.Classroom{}, err
+	}
+	c.InviteCode = code
+	return c, nil
+}
+
+// JoinClass matricula o usuário pela turma do código de convite
+func (s *Service) JoinClass(userID, code string) (domain.Classroom, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return domain.Classroom{}, errors.New("código de convite inválido")
+	}
+	c, err := s.Repo.GetClassByInviteCode(code)
+	if err != nil {
+		return domain.Classroom{}, errors.New("código de convite inválido")
+	}
+	if c.TeacherID == userID {
+		return domain.Classroom{}, errors.New("o professor não pode se matricular na própria turma")
+	}
+	if err := s.Repo.AddClassMember(c.ID, userID); err != nil {
+		return domain.Classroom{}, err
+	}
+	return s.GetClass(c.ID, userID, "")
+}
+
+// GetClassStudents lista os alunos da turma (apenas o professor ou admin)
+func (s *Service) GetClassStudents(classID, userID, role string) ([]domain.ClassMember, error) {
+	if _, err := s.teacherClass(classID, userID, role); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetClassMembers(classID)
+}
+
+// AddClassStudent matricula um usuário existente (por email) na turma
+func (s *Service) AddClassStudent(classID, userID, role, email string) ([]domain.ClassMember, error) {
+	c, err := s.teacherClass(classID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.Repo.GetUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	if u.ID == c.TeacherID {
+		return nil, errors.New("o professor não pode se matricular na própria turma")
+	}
+	if err := s.Repo.AddClassMember(classID, u.ID); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetClassMembers(classID)
+}
+
+// RemoveClassStudent remove um aluno; o professor remove qualquer um e cada aluno pode sair
+func (s *Service) RemoveClassStudent(classID, studentID, userID, role string) error {
+	if studentID != userID {
+		if _, err := s.teacherClass(classID, userID, role); err != nil {
+			return err
+		}
+	}
+	if err := s.Repo.RemoveClassMember(classID, studentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("aluno não encontrado")
+		}
+		return err
+	}
+	return nil
+}
+
+// --- Atividades ---
+
+// CreateAssignment atribui um exame publicado à turma. O professor precisa poder visualizar o exame;
+// sem opensAt a atividade abre imediatamente.
+func (s *Service) CreateAssignment(classID, userID, role string, a domain.Assignment) (domain.Assignment, error) {
+	if _, err := s.teacherClass(classID, userID, role); err != nil {
+		return domain.Assignment{}, err
+	}
+	exam, err := s.Exams.GetExamByID(a.ExamID)
+	if err != nil || exam.DeletedAt > 0 {
+		return domain.Assignment{}, errors.New("prova não encontrada")
+	}
+	if !s.CanViewExam(exam, userID) && role != "admin" {
+		return domain.Assignment{}, errors.New("acesso negado")
+	}
+	if exam.Status != domain.ExamPublished {
+		return domain.Assignment{}, errors.New("apenas exames publicados podem ser atribuídos")
+	}
+	// Sessões adaptativas não registram a atividade no resultado
+	if exam.Adaptive != nil && exam.Adaptive.Enabled {
+		return domain.Assignment{}, errors.New("exames adaptativos não podem ser atribuídos")
+	}
+	if err := ValidateAttemptPolicy(a.AttemptPolicy); err != nil {
+		return domain.Assignment{}, err
+	}
+
+	now := time.Now().UnixMilli()
+	if a.OpensAt == 0 {
+		a.OpensAt = now
+	}
+	if a.ClosesAt != 0 && (a.ClosesAt <= a.OpensAt || a.ClosesAt <= now) {
+		return domain.Assignment{}, errors.New("prazo de encerramento inválido")
+	}
+	a.ID = uuid.New().String()
+	a.ClassID = classID
+	a.Title = strings.TrimSpace(a.Title)
+	a.CreatedBy = userID
+	a.CreatedAt = now
+	if err := s.Repo.CreateAssignment(a); err != nil {
+		return domain.Assignment{}, err
+	}
+	return s.Repo.GetAssignmentByID(a.ID)
+}
+
+// GetClassAssignments lista as atividades da turma; para alunos inclui a situação de cada uma
+func (s *Service) GetClassAssignments(classID, userID, role string) ([]domain.Assignment, error) {
+	c, err := s.GetClass(classID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.Repo.GetClassAssignments(classID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Role == domain.ClassStudent {
+		results, err := s.Repo.GetUserAssignmentResults(userID)
+		if err != nil {
+			return nil, err
+		}
+		applyStudentStatus(assignments, results, time.Now())
+	}
+	return assignments, nil
+}
+
+// DeleteAssignment remove a atividade; os resultados entregues são mantidos sem o vínculo
+func (s *Service) DeleteAssignment(classID, assignmentID, userID, role string) error {
+	if _, err := s.teacherClass(classID, userID, role); err != nil {
+		return err
+	}
+	err := s.Repo.DeleteAssignment(classID, assignmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("atividade não encontrada")
+	}
+	return err
+}
+
+// GetMyAssignments lista as atividades do aluno em todas as turmas.
+// Por padrão retorna apenas as pendentes; com all=true, todas (incluindo futuras, entregues e perdidas).
+func (s *Service) GetMyAssignments(userID string, all bool) ([]domain.Assignment, error) {
+	assignments, err := s.Repo.GetStudentAssignments(userID)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.Repo.GetUserAssignmentResults(userID)
+	if err != nil {
+		return nil, err
+	}
+	applyStudentStatus(assignments, results, time.Now())
+	if all {
+		return assignments, nil
+	}
+	pending := []domain.Assignment{}
+	for _, a := range assignments {
+		if a.Status == domain.AssignmentPending {
+			pending = append(pending, a)
+		}
+	}
+	return pending, nil
+}
+
+// openAssignment retorna a atividade de uma turma do aluno, desde que esteja dentro da janela (opensAt..closesAt).
+// Fora da janela retorna *AttemptError (ASSIGNMENT_NOT_OPEN ou ASSIGNMENT_CLOSED).
+func (s *Service) openAssignment(assignmentID, userID string) (domain.Assignment, error) {
+	a, err := s.Repo.GetAssignmentByID(assignmentID)
+	if err != nil {
+		return domain.Assignment{}, errors.New("atividade não encontrada")
+	}
+	if member, _ := s.Repo.IsClassMember(a.ClassID, userID); !member {
+		return domain.Assignment{}, errors.New("atividade não encontrada")
+	}
+	now := time.Now().UnixMilli()
+	if now < a.OpensAt {
+		return domain.Assignment{}, &AttemptError{Code: AssignmentNotOpen, Message: "A atividade ainda não está aberta"}
+	}
+	if a.ClosesAt > 0 && now > a.ClosesAt {
+		return domain.Assignment{}, &AttemptError{Code: AssignmentClosed, Message: "O prazo da atividade foi encerrado"}
+	}
+	return a, nil
+}
+
+// GetAssignmentExam retorna ao aluno o exame da atividade, sem gabarito nem explicações.
+// A matrícula na turma não dá acesso ao exame fora desta rota: o acesso vale apenas dentro da janela da atividade.
+func (s *Service) GetAssignmentExam(assignmentID, userID string) (domain.Exam, error) {
+	a, err := s.openAssignment(assignmentID, userID)
+	if err != nil {
+		return domain.Exam{}, err
+	}
+	exam, err := s.Repo.GetExamByID(a.ExamID)
+	if err != nil || exam.DeletedAt > 0 {
+		return domain.Exam{}, errors.New("prova não encontrada")
+	}
+	if err := examOpenForLinks(exam); err != nil {
+		return domain.Exam{}, err
+	}
+	exam.IsVerified = calculateExamIsVerified(exam)
+	for i := range exam.Questions {
+		exam.Questions[i].CorrectIndex = -1
+		exam.Questions[i].Explanation = ""
+	}
+	return exam, nil
+}
+
+// CheckAssignmentAccess valida que o aluno pode responder agora a atividade do exame: matrícula, exame e janela
+func (s *Service) CheckAssignmentAccess(assignmentID string, exam domain.Exam, userID string) (domain.Assignment, error) {
+	a, err := s.openAssignment(assignmentID, userID)
+	if err != nil {
+		return domain.Assignment{}, err
+	}
+	if a.ExamID != exam.ID {
+		return domain.Assignment{}, errors.New("atividade não corresponde à prova")
+	}
+	return a, nil
+}
+
+// CheckAssignmentAttempt valida uma entrega para a atividade: matrícula, exame, janela e política de tentativas.
+// A política da atividade prevalece sobre a do exame e conta apenas as tentativas da atividade.
+func (s *Service) CheckAssignmentAttempt(assignmentID string, exam domain.Exam, userID string) error {
+	a, err := s.CheckAssignmentAccess(assignmentID, exam, userID)
+	if err != nil {
+		return err
+	}
+
+	policy, scope := assignmentAttemptRule(a, exam, userID)
+	if !limitsAttempts(policy) {
+		return nil
+	}
+	count, last, err := s.Repo.CountAttempts(scope)
+	if err != nil {
+		return err
+	}
+	return enforceAttemptPolicy(policy, count, last)
+}
+
+// GetGradebook monta o quadro de notas da turma: uma linha por aluno, uma coluna por atividade
+func (s *Service) GetGradebook(classID, userID, role string) (domain.Gradebook, error) {
+	c, err := s.teacherClass(classID, userID, role)
+	if err != nil {
+		return domain.Gradebook{}, err
+	}
+	students, err := s.Repo.GetClassMembers(classID)
+	if err != nil {
+		return domain.Gradebook{}, err
+	}
+	assignments, err := s.Repo.GetClassAssignments(classID)
+	if err != nil {
+		return domain.Gradebook{}, err
+	}
+	results, err := s.Repo.GetClassAssignmentResults(classID)
+	if err != nil {
+		return domain.Gradebook{}, err
+	}
+
+	// Tentativas por aluno e atividade, em ordem cronológica
+	attempts := map[string][]domain.ExamResult{}
+	for _, res := range results {
+		key := res.UserID + "|" + res.AssignmentID
+		attempts[key] = append(attempts[key], res)
+	}
+
+	now := time.Now()
+	rows := make([]domain.GradebookRow, len(students))
+	for i, st := range students {
+		grades := make([]domain.AssignmentGrade, len(assignments))
+		for j, a := range assignments {
+			grades[j] = assignmentGrade(a, attempts[st.UserID+"|"+a.ID], now)
+		}
+		rows[i] = domain.GradebookRow{Student: st, Grades: grades}
+	}
+	return domain.Gradebook{Class: c, Assignments: assignments, Rows: rows}, nil
+}
+
+// assignmentGrade calcula a situação e a nota que conta de um aluno na atividade
+func assignmentGrade(a domain.Assignment, attempts []domain.ExamResult, now time.Time) domain.AssignmentGrade {
+	g := domain.AssignmentGrade{AssignmentID: a.ID, Attempts: len(attempts)}
+	if len(attempts) == 0 {
+		g.Status = assignmentWindowStatus(a, now)
+		return g
+	}
+	// A política da atividade prevalece sobre a do exame (como em CheckAssignmentAttempt)
+	policy := a.AttemptPolicy
+	if policy == nil {
+		policy = a.ExamPolicy
+	}
+	mode := domain.ScoringBest
+	if policy != nil && policy.ScoringMode != "" {
+		mode = policy.ScoringMode
+	}
+	counted := countedAttempt(attempts, mode)
+	score := counted.Score
+	g.Status = domain.AssignmentSubmitted
+	g.Score = &score
+	g.TotalQuestions = counted.TotalQuestions
+	g.ResultID = counted.ID
+	g.SubmittedAt = counted.Date
+	return g
+}
+
+// assignmentWindowStatus retorna a situação de uma atividade sem entregas
+func assignmentWindowStatus(a domain.Assignment, now time.Time) string {
+	switch {
+	case now.UnixMilli() < a.OpensAt:
+		return domain.AssignmentUpcoming
+	case a.ClosesAt > 0 && now.UnixMilli() > a.ClosesAt:
+		return domain.AssignmentMissed
+	}
+	return domain.AssignmentPending
+}
+
+// applyStudentStatus preenche situação, tentativas e nota do aluno em cada atividade
+func applyStudentStatus(assignments []domain.Assignment, results []domain.ExamResult, now time.Time) {
+	attempts := map[string][]domain.ExamResult{}
+	for _, res := range results {
+		attempts[res.AssignmentID] = append(attempts[res.AssignmentID], res)
+	}
+	for i := range assignments {
+		g := assignmentGrade(assignments[i], attempts[assignments[i].ID], now)
+		assignments[i].Status = g.Status
+		assignments[i].Attempts = g.Attempts
+		assignments[i].Score = g.Score
+		assignments[i].TotalQuestions = g.TotalQuestions
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"esimulate-backend/internal/logger"
 	"esimulate-backend/internal/repository/postgres"
+	"strconv"
 	"time"
 )
 
@@ -90,5 +91,40 @@ func (c *CleanupService) runCleanup() {
 	} else {
 		logger.Info("Limpeza automática concluída com sucesso | Duração: %v", duration)
 	}
+
+	c.purgeDeletedExams()
+	c.purgeAuditEvents()
+}
+
+// purgeDeletedExams remove definitivamente exames excluídos há mais de EXAM_RETENTION_DAYS dias (padrão 30)
+func (c *CleanupService) purgeDeletedExams() {
+	days, err := strconv.Atoi(getEnv("EXAM_RETENTION_DAYS", "30"))
+	if err != nil || days < 0 {
+		days = 30
+	}
+	purged, err := c.repo.PurgeDeletedExams(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logger.Error("Erro ao purgar exames excluídos: %v", err)
+		return
+	}
+	if purged > 0 {
+		logger.Info("Exames excluídos purgados: %d (retenção de %d dias)", purged, days)
+	}
 }
 
+
+// purgeAuditEvents remove eventos de auditoria mais antigos que AUDIT_RETENTION_DAYS dias (padrão 365)
+func (c *CleanupService) purgeAuditEvents() {
+	days, err := strconv.Atoi(getEnv("AUDIT_RETENTION_DAYS", "365"))
+	if err != nil || days < 1 {
+		days = 365
+	}
+	purged, err := c.repo.DeleteAuditEventsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logger.Error("Erro ao remover eventos de auditoria: %v", err)
+		return
+	}
+	if purged > 0 {
+		logger.Info("Eventos de auditoria removidos: %d (retenção de %d dias)", purged, days)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CloneExam copia um exame para o usuário, que passa a ser o dono da cópia.
+// Sem DeepCopy as questões são compartilhadas via exam_questions (e continuam editáveis só pelos seus autores);
+// com DeepCopy são duplicadas com novos IDs como questões privadas e não verificadas do usuário.
+func (s *Service) CloneExam(sourceID, userID string, opts domain.CloneOptions) (domain.Exam, error) {
+	source, err := s.Exams.GetExamByID(sourceID)
+	if err != nil {
+		return domain.Exam{}, errors.New("prova não encontrada")
+	}
+	if !s.CanViewExam(source, userID) {
+		return domain.Exam{}, errors.New("acesso negado")
+	}
+
+	title := strings.TrimSpace(opts.Title)
+	if title == "" {
+		title = source.Title + " (cópia)"
+	}
+	clone := domain.Exam{
+		ID:            uuid.New().String(),
+		Title:         title,
+		Description:   source.Description,
+		Subjects:      source.Subjects,
+		TimeLimit:     source.TimeLimit,
+		IsPublic:      false,
+		CreatedBy:     userID,
+		CreatedAt:     time.Now().UnixMilli(),
+		Adaptive:      source.Adaptive,
+		AttemptPolicy: source.AttemptPolicy,
+		ClonedFrom:    source.ID,
+	}
+
+	if opts.DeepCopy {
+		clone.Questions = make([]domain.Question, len(source.Questions))
+		for i, q := range source.Questions {
+			q.ID = uuid.New().String()
+			q.IsPublic = false
+			q.IsVerified = false // A cópia pode ser editada: a verificação não é herdada
+			q.CreatedBy = userID
+			clone.Questions[i] = q
+		}
+		if err := s.Repo.CreateExam(clone, userID, false); err != nil {
+			return domain.Exam{}, err
+		}
+	} else {
+		questionIDs := make([]string, len(source.Questions))
+		for i, q := range source.Questions {
+			questionIDs[i] = q.ID
+		}
+		if err := s.Repo.CreateExamFromBank(clone, questionIDs); err != nil {
+			return domain.Exam{}, err
+		}
+	}
+
+	exam, err := s.Repo.GetExamByID(clone.ID)
+	if err != nil {
+		return domain.Exam{}, err
+	}
+	exam.IsVerified = calculateExamIsVerified(exam)
+	return exam, nil
+}
@@ -263,6 +263,29 @@ func (e *EmailService) SendPasswordResetEmail(to, name, token string) error {
 	return e.SendEmail(to, "Recuperação de Senha - eSimulate", body)
 }
 
+// SendAccountLockedEmail avisa o dono da conta sobre o bloqueio temporário por tentativas de login
+func (e *EmailService) SendAccountLockedEmail(to, name string, attempts int, lockedUntil string) error {
+	logger.Debug("[EMAIL] Tipo: Conta Bloqueada | Para: %s | Nome: %s", to, name)
+	forgotURL := fmt.Sprintf("%s/#/forgot-password", getEnv("APP_URL", "http://localhost:3000"))
+
+	body := fmt.Sprintf(`
+		<html>
+		<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
+			<h2>Conta Bloqueada Temporariamente</h2>
+			<p>Olá %s,</p>
+			<p>Registramos %d tentativas seguidas de login com senha incorreta na sua conta. Por segurança, o login está bloqueado até <strong>%s</strong>.</p>
+			<p>Se foi você, aguarde e tente novamente. Se não reconhece essas tentativas, redefina sua senha:</p>
+			<p><a href="%s" style="background-color: #2196F3; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px;">Redefinir Senha</a></p>
+			<p>Redefinir a senha também remove o bloqueio.</p>
+			<hr>
+			<p style="color: #666; font-size: 12px;">Powered by eSimulate</p>
+		</body>
+		</html>
+	`, name, attempts, lockedUntil, forgotURL)
+
+	return e.SendEmail(to, "Conta bloqueada temporariamente - eSimulate", body)
+}
+
 // SendCompanyInviteEmail envia convite da empresa para candidato
 func (e *EmailService) SendCompanyInviteEmail(to, candidateName, companyName, companyLogo, linkToken string) error {
 	logger.Debug("[EMAIL] Tipo: Convite Empresa | Para: %s | Candidato: %s | Empresa: %s", 
@@ -297,6 +320,75 @@ func (e *EmailService) SendCompanyInviteEmail(to, candidateName, companyName, co
 	return e.SendEmail(to, fmt.Sprintf("Convite para Teste Técnico - %s", companyName), body)
 }
 
+// SendExamReminderEmail lembra o candidato convidado de que a janela da prova vai abrir
+func (e *EmailService) SendExamReminderEmail(to, companyName, companyLogo, examTitle, linkToken, opensAt string) error {
+	logger.Debug("[EMAIL] Tipo: Lembrete de Prova | Para: %s | Empresa: %s | Abertura: %s", to, companyName, opensAt)
+	examURL := fmt.Sprintf("%s/#/eval/%s", getEnv("APP_URL", "http://localhost:3000"), linkToken)
+
+	logoHTML := ""
+	if companyLogo != "" {
+		logoHTML = fmt.Sprintf(`<img src="%s" alt="%s" style="max-width: 200px; margin: 20px 0;">`, companyLogo, companyName)
+	}
+
+	body := fmt.Sprintf(`
+		<html>
+		<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
+			<div style="text-align: center;">
+				%s
+				<h2>%s</h2>
+			</div>
+			<p>Lembrete: a prova <strong>%s</strong> estará disponível a partir de <strong>%s</strong>.</p>
+			<p style="text-align: center; margin: 30px 0;">
+				<a href="%s" style="background-color: #4CAF50; color: white; padding: 15px 30px; text-decoration: none; border-radius: 5px; font-size: 16px; display: inline-block;">Acessar Prova</a>
+			</p>
+			<p>Ou copie e cole este link no navegador:</p>
+			<p>%s</p>
+			<hr>
+			<p style="color: #666; font-size: 12px;">Powered by eSimulate</p>
+		</body>
+		</html>
+	`, logoHTML, companyName, examTitle, opensAt, examURL, examURL)
+
+	return e.SendEmail(to, fmt.Sprintf("Lembrete: %s - %s", examTitle, companyName), body)
+}
+
+// SendOrgInvitationEmail envia convite para ingressar em uma organização
+func (e *EmailService) SendOrgInvitationEmail(to, orgName, orgLogo, inviterName, role, token string) error {
+	logger.Debug("[EMAIL] Tipo: Convite Organização | Para: %s | Organização: %s | Papel: %s", to, orgName, role)
+	acceptURL := fmt.Sprintf("%s/#/orgs/invitations/%s", getEnv("APP_URL", "http://localhost:3000"), token)
+
+	logoHTML := ""
+	if orgLogo != "" {
+		logoHTML = fmt.Sprintf(`<img src="%s" alt="%s" style="max-width: 200px; margin: 20px 0;">`, orgLogo, orgName)
+	}
+	invitedBy := ""
+	if inviterName != "" {
+		invitedBy = fmt.Sprintf(" por <strong>%s</strong>", inviterName)
+	}
+
+	body := fmt.Sprintf(`
+		<html>
+		<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
+			<div style="text-align: center;">
+				%s
+				<h2>%s</h2>
+			</div>
+			<p>Você foi convidado%s para fazer parte da organização <strong>%s</strong> no eSimulate, com o papel <strong>%s</strong>.</p>
+			<p style="text-align: center; margin: 30px 0;">
+				<a href="%s" style="background-color: #4CAF50; color: white; padding: 15px 30px; text-decoration: none; border-radius: 5px; font-size: 16px; display: inline-block;">Aceitar Convite</a>
+			</p>
+			<p>Ou copie e cole este link no navegador:</p>
+			<p>%s</p>
+			<p>Este convite expira em 7 dias. Se você ainda não tem conta, cadastre-se com este email antes de aceitar.</p>
+			<hr>
+			<p style="color: #666; font-size: 12px;">Powered by eSimulate</p>
+		</body>
+		</html>
+	`, logoHTML, orgName, invitedBy, orgName, role, acceptURL, acceptURL)
+
+	return e.SendEmail(to, fmt.Sprintf("Convite para a organização %s - eSimulate", orgName), body)
+}
+
 // SendContactAdminEmail envia mensagem de contato para admin
 func (e *EmailService) SendContactAdminEmail(senderEmail, subject, message string) error {
 	adminEmail := getEnv("ADMIN_EMAIL", "admin@esimulate.com")
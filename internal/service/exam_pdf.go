@@ -0,0 +1,261 @@
+package service
+
+import (
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/pdf"
+	"fmt"
+	"strings"
+)
+
+// Layout das exportações de exame (A4 retrato, em pontos)
+const (
+	examPDFMargin    = 50.0
+	examPDFTop       = 60.0
+	examPDFBottom    = 780.0 // Limite inferior do conteúdo (o rodapé fica abaixo)
+	examPDFLineGap   = 1.35  // Entrelinha relativa ao tamanho da fonte
+	examPDFOptionGap = 4.0
+)
+
+// optionLetter converte o índice da alternativa em letra (0 -> A)
+func optionLetter(i int) string {
+	return string(rune('A' + i))
+}
+
+// examPDF acompanha a posição vertical e cria páginas conforme necessário
+type examPDF struct {
+	doc   *pdf.Document
+	title string
+	y     float64
+}
+
+func newExamPDF(title string) *examPDF {
+	p := &examPDF{doc: pdf.New(pdf.A4Width, pdf.A4Height), title: title}
+	p.newPage()
+	return p
+}
+
+func (p *examPDF) newPage() {
+	p.doc.AddPage()
+	p.y = examPDFTop
+}
+
+func (p *examPDF) contentWidth() float64 {
+	return p.doc.Width - 2*examPDFMargin
+}
+
+func (p *examPDF) lineHeight() float64 {
+	return p.doc.FontSize() * examPDFLineGap
+}
+
+// ensure abre nova página se a altura não couber na atual
+func (p *examPDF) ensure(height float64) {
+	if p.y+height > examPDFBottom && p.y > examPDFTop {
+		p.newPage()
+	}
+}
+
+// paragraph escreve texto quebrado em linhas a partir de x, avançando páginas se preciso
+func (p *examPDF) paragraph(x float64, text string, bold bool, size float64) {
+	p.doc.SetFont(bold, size)
+	for _, line := range p.doc.WrapText(text, p.doc.Width-examPDFMargin-x) {
+		p.ensure(p.lineHeight())
+		p.y += p.lineHeight()
+		p.doc.Text(x, p.y, line)
+	}
+}
+
+// textHeight calcula a altura que o texto ocuparia (para manter blocos na mesma página)
+func (p *examPDF) textHeight(x float64, text string, bold bool, size float64) float64 {
+	p.doc.SetFont(bold, size)
+	return float64(len(p.doc.WrapText(text, p.doc.Width-examPDFMargin-x))) * size * examPDFLineGap
+}
+
+// finish escreve o rodapé "Página X de Y" em todas as páginas e serializa o documento
+func (p *examPDF) finish() []byte {
+	total := p.doc.PageCount()
+	for i := 1; i <= total; i++ {
+		p.doc.SetPage(i)
+		p.doc.SetStrokeColor(180, 180, 180)
+		p.doc.SetLineWidth(0.5)
+		p.doc.Line(examPDFMargin, p.doc.Height-45, p.doc.Width-examPDFMargin, p.doc.Height-45)
+		p.doc.SetFillColor(110, 110, 110)
+		p.doc.SetFont(false, 8)
+		title := p.title
+		if lines := p.doc.WrapText(title, p.contentWidth()-100); len(lines) > 1 {
+			title = lines[0] + "…"
+		}
+		p.doc.Text(examPDFMargin, p.doc.Height-32, title)
+		p.doc.TextRight(p.doc.Width-examPDFMargin, p.doc.Height-32, fmt.Sprintf("Página %d de %d", i, total))
+	}
+	return p.doc.Bytes()
+}
+
+// header escreve título, descrição e informações gerais do exame
+func (p *examPDF) header(exam domain.Exam, subtitle string) {
+	p.doc.SetFillColor(0, 0, 0)
+	p.paragraph(examPDFMargin, exam.Title, true, 16)
+	if subtitle != "" {
+		p.paragraph(examPDFMargin, subtitle, true, 12)
+	}
+	if exam.Description != "" {
+		p.doc.SetFillColor(70, 70, 70)
+		p.paragraph(examPDFMargin, exam.Description, false, 10)
+		p.doc.SetFillColor(0, 0, 0)
+	}
+	info := fmt.Sprintf("%d questões", len(exam.Questions))
+	if exam.TimeLimit > 0 {
+		info += fmt.Sprintf(" • Tempo: %d min", exam.TimeLimit)
+	}
+	if len(exam.Subjects) > 0 {
+		info += " • " + strings.Join(exam.Subjects, ", ")
+	}
+	p.paragraph(examPDFMargin, info, false, 9)
+	p.y += 8
+	p.doc.SetStrokeColor(0, 0, 0)
+	p.doc.SetLineWidth(0.8)
+	p.doc.Line(examPDFMargin, p.y, p.doc.Width-examPDFMargin, p.y)
+	p.y += 10
+}
+
+// RenderExamBookletPDF gera o caderno de prova com questões numeradas e alternativas em letras.
+// Com answerSheet, acrescenta a folha de respostas para marcação.
+func RenderExamBookletPDF(exam domain.Exam, answerSheet bool) []byte {
+	p := newExamPDF(exam.Title)
+	p.header(exam, "")
+
+	p.doc.SetFont(false, 10)
+	p.y += p.lineHeight()
+	p.doc.Text(examPDFMargin, p.y, "Nome: ______________________________________________   Data: ____/____/______")
+	p.y += 16
+
+	numberX := examPDFMargin
+	textX := examPDFMargin + 22
+	optionX := textX + 20
+	for i, q := range exam.Questions {
+		// Mantém enunciado e alternativas juntos quando o bloco cabe em uma página
+		block := p.textHeight(textX, q.Text, false, 11)
+		for _, opt := range q.Options {
+			block += p.textHeight(optionX, opt, false, 10.5) + examPDFOptionGap
+		}
+		p.ensure(block + 14)
+
+		p.y += 10
+		p.doc.SetFont(true, 11)
+		p.doc.Text(numberX, p.y+p.lineHeight(), fmt.Sprintf("%d.", i+1))
+		p.paragraph(textX, q.Text, false, 11)
+		p.y += 3
+		for j, opt := range q.Options {
+			p.ensure(p.lineHeight() + examPDFOptionGap)
+			p.y += examPDFOptionGap
+			p.doc.SetFont(true, 10.5)
+			p.doc.Text(textX, p.y+p.lineHeight(), "("+optionLetter(j)+")")
+			p.paragraph(optionX, opt, false, 10.5)
+		}
+	}
+
+	if answerSheet {
+		p.answerSheet(exam)
+	}
+	return p.finish()
+}
+
+// answerSheet desenha a folha de respostas com bolhas em colunas
+func (p *examPDF) answerSheet(exam domain.Exam) {
+	const (
+		rowHeight     = 19.0
+		bubbleRadius  = 6.5
+		bubbleSpacing = 20.0
+		columnWidth   = 165.0
+	)
+	maxOptions := 0
+	for _, q := range exam.Questions {
+		if len(q.Options) > maxOptions {
+			maxOptions = len(q.Options)
+		}
+	}
+
+	p.newPage()
+	p.doc.SetFillColor(0, 0, 0)
+	p.paragraph(examPDFMargin, "Folha de Respostas", true, 16)
+	p.paragraph(examPDFMargin, exam.Title, false, 10)
+	p.doc.SetFont(false, 10)
+	p.y += 22
+	p.doc.Text(examPDFMargin, p.y, "Nome: ______________________________________________   Data: ____/____/______")
+	p.y += 8
+	p.paragraph(examPDFMargin, "Preencha completamente a bolha da alternativa escolhida, usando caneta azul ou preta.", false, 9)
+	p.y += 16
+
+	top := p.y
+	rowsPerColumn := int((examPDFBottom - top) / rowHeight)
+	columns := int(p.contentWidth() / columnWidth)
+	p.doc.SetStrokeColor(0, 0, 0)
+	p.doc.SetLineWidth(0.8)
+	for i := range exam.Questions {
+		slot := i % (rowsPerColumn * columns)
+		if i > 0 && slot == 0 {
+			p.newPage()
+			top = p.y
+			rowsPerColumn = int((examPDFBottom - top) / rowHeight)
+			p.doc.SetStrokeColor(0, 0, 0)
+			p.doc.SetLineWidth(0.8)
+		}
+		x := examPDFMargin + float64(slot/rowsPerColumn)*columnWidth
+		y := top + float64(slot%rowsPerColumn)*rowHeight
+
+		p.doc.SetFillColor(0, 0, 0)
+		p.doc.SetFont(true, 10)
+		p.doc.TextRight(x+22, y+bubbleRadius/2+1, fmt.Sprintf("%d", i+1))
+		p.doc.SetFont(false, 7)
+		for j := 0; j < maxOptions; j++ {
+			cx := x + 40 + float64(j)*bubbleSpacing
+			p.doc.Circle(cx, y, bubbleRadius, false)
+			letter := optionLetter(j)
+			p.doc.Text(cx-p.doc.StringWidth(letter)/2, y+2.5, letter)
+		}
+	}
+}
+
+// RenderAnswerKeyPDF gera o gabarito em separado: tabela de respostas e comentários
+func RenderAnswerKeyPDF(exam domain.Exam) []byte {
+	p := newExamPDF(exam.Title + " — Gabarito")
+	p.header(exam, "Gabarito")
+
+	// Tabela compacta: 5 colunas de "N - Letra"
+	const columns = 5
+	colWidth := p.contentWidth() / columns
+	p.doc.SetFont(false, 11)
+	for i, q := range exam.Questions {
+		if i%columns == 0 {
+			p.ensure(p.lineHeight())
+			p.y += p.lineHeight()
+		}
+		answer := "—"
+		if q.CorrectIndex >= 0 && q.CorrectIndex < len(q.Options) {
+			answer = optionLetter(q.CorrectIndex)
+		}
+		x := examPDFMargin + float64(i%columns)*colWidth
+		p.doc.SetFont(true, 11)
+		p.doc.Text(x, p.y, fmt.Sprintf("%d.", i+1))
+		p.doc.SetFont(false, 11)
+		p.doc.Text(x+28, p.y, answer)
+	}
+
+	p.y += 24
+	p.ensure(40)
+	p.paragraph(examPDFMargin, "Comentários", true, 13)
+	for i, q := range exam.Questions {
+		answer := "—"
+		if q.CorrectIndex >= 0 && q.CorrectIndex < len(q.Options) {
+			answer = "(" + optionLetter(q.CorrectIndex) + ") " + q.Options[q.CorrectIndex]
+		}
+		p.ensure(p.textHeight(examPDFMargin, answer, true, 10.5) + 24)
+		p.y += 10
+		p.paragraph(examPDFMargin, fmt.Sprintf("%d. Resposta: %s", i+1, answer), true, 10.5)
+		if q.Explanation != "" {
+			p.doc.SetFillColor(60, 60, 60)
+			p.paragraph(examPDFMargin+14, q.Explanation, false, 10)
+			p.doc.SetFillColor(0, 0, 0)
+		}
+	}
+	return p.finish()
+}
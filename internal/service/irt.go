@@ -0,0 +1,528 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/logger"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- Teoria de Resposta ao Item (TRI) ---
+// Modelo logístico: P(acerto | theta) = 1 / (1 + exp(-a (theta - b)))
+
+const (
+	irtMinResponses      = 20  // Respostas mínimas para calibrar uma questão
+	irtMaxIterations     = 100 // Iterações do algoritmo EM
+	irtTolerance         = 1e-3
+	irtThetaBound        = 4.0 // Theta e b são limitados a [-4, 4]
+	irtMinDiscrimination = 0.2
+	irtMaxDiscrimination = 3.0
+
+	adaptiveDefaultMaxItems = 20
+	adaptiveDefaultMinItems = 5
+	adaptiveDefaultSE       = 0.3
+)
+
+// irtProbability retorna a probabilidade de acerto no modelo logístico
+func irtProbability(theta, a, b float64) float64 {
+	return 1 / (1 + math.Exp(-a*(theta-b)))
+}
+
+// itemInformation é a informação de Fisher da questão em theta (a² P (1-P))
+func itemInformation(theta float64, p domain.ItemParams) float64 {
+	prob := irtProbability(theta, p.Discrimination, p.Difficulty)
+	return p.Discrimination * p.Discrimination * prob * (1 - prob)
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// defaultItemParams é usado para questões ainda não calibradas
+func defaultItemParams(questionID string) domain.ItemParams {
+	return domain.ItemParams{QuestionID: questionID, Model: domain.IRTModel1PL, Discrimination: 1, Difficulty: 0}
+}
+
+// EstimateAbility calcula a habilidade por EAP (esperança a posteriori) com priori N(0,1).
+// Diferente da máxima verossimilhança, o EAP é finito mesmo quando todas as respostas
+// estão corretas (ou erradas), o que é comum nas primeiras questões de um teste adaptativo.
+func EstimateAbility(answers []domain.AdaptiveAnswer, params map[string]domain.ItemParams) domain.AbilityEstimate {
+	const points = 81
+	var sumW, sumWT, sumWT2 float64
+	for i := 0; i < points; i++ {
+		theta := -irtThetaBound + 2*irtThetaBound*float64(i)/float64(points-1)
+		logW := -theta * theta / 2 // Priori normal padrão (constante omitida)
+		for _, ans := range answers {
+			p, ok := params[ans.QuestionID]
+			if !ok {
+				p = defaultItemParams(ans.QuestionID)
+			}
+			prob := irtProbability(theta, p.Discrimination, p.Difficulty)
+			if ans.IsCorrect {
+				logW += math.Log(prob)
+			} else {
+				logW += math.Log(1 - prob)
+			}
+		}
+		w := math.Exp(logW)
+		sumW += w
+		sumWT += w * theta
+		sumWT2 += w * theta * theta
+	}
+
+	est := domain.AbilityEstimate{Items: len(answers), SE: 1}
+	if sumW > 0 {
+		est.Theta = sumWT / sumW
+		est.SE = math.Sqrt(math.Max(sumWT2/sumW-est.Theta*est.Theta, 0))
+	}
+	est.CILower = est.Theta - 1.96*est.SE
+	est.CIUpper = est.Theta + 1.96*est.SE
+	return est
+}
+
+// CalibrateItems estima os parâmetros das questões por máxima verossimilhança marginal
+// (algoritmo EM de Bock-Aitkin com quadratura sobre theta ~ N(0,1)), com prioris fracas
+// nos parâmetros. Cada tentativa (result) é tratada como um respondente.
+func CalibrateItems(responses []domain.ItemResponse, model string) []domain.ItemParams {
+	// Contar respostas por questão e descartar questões com amostra insuficiente
+	counts := map[string]int{}
+	for _, r := range responses {
+		counts[r.QuestionID]++
+	}
+	type answer struct {
+		item    int
+		correct bool
+	}
+	itemIndex := map[string]int{}
+	personIndex := map[string]int{}
+	itemIDs := []string{}
+	persons := [][]answer{}
+	for _, r := range responses {
+		if counts[r.QuestionID] < irtMinResponses {
+			continue
+		}
+		i, ok := itemIndex[r.QuestionID]
+		if !ok {
+			i = len(itemIDs)
+			itemIndex[r.QuestionID] = i
+			itemIDs = append(itemIDs, r.QuestionID)
+		}
+		p, ok := personIndex[r.PersonID]
+		if !ok {
+			p = len(persons)
+			personIndex[r.PersonID] = p
+			persons = append(persons, nil)
+		}
+		persons[p] = append(persons[p], answer{item: i, correct: r.Correct})
+	}
+	if len(itemIDs) == 0 {
+		return nil
+	}
+
+	// Quadratura: pontos igualmente espaçados com pesos da normal padrão
+	const nodes = 41
+	x := make([]float64, nodes)
+	prior := make([]float64, nodes)
+	for k := range x {
+		x[k] = -irtThetaBound + 2*irtThetaBound*float64(k)/float64(nodes-1)
+		prior[k] = math.Exp(-x[k] * x[k] / 2)
+	}
+
+	// Valores iniciais: a = 1 e b pelo logito da proporção de erros (com suavização)
+	a := make([]float64, len(itemIDs))
+	b := make([]float64, len(itemIDs))
+	iCorrect, iTotal := make([]float64, len(itemIDs)), make([]float64, len(itemIDs))
+	for _, answers := range persons {
+		for _, ans := range answers {
+			iTotal[ans.item]++
+			if ans.correct {
+				iCorrect[ans.item]++
+			}
+		}
+	}
+	for i := range b {
+		rate := (iCorrect[i] + 0.5) / (iTotal[i] + 1)
+		b[i] = clamp(-math.Log(rate/(1-rate)), -irtThetaBound, irtThetaBound)
+		a[i] = 1
+	}
+
+	n := make([][]float64, len(itemIDs)) // Respostas esperadas por questão em cada ponto
+	r := make([][]float64, len(itemIDs)) // Acertos esperados por questão em cada ponto
+	for i := range n {
+		n[i] = make([]float64, nodes)
+		r[i] = make([]float64, nodes)
+	}
+	post := make([]float64, nodes)
+
+	for iter := 0; iter < irtMaxIterations; iter++ {
+		// E-step: distribuição a posteriori de cada respondente sobre os pontos
+		for i := range n {
+			for k := 0; k < nodes; k++ {
+				n[i][k], r[i][k] = 0, 0
+			}
+		}
+		for _, answers := range persons {
+			total := 0.0
+			for k := 0; k < nodes; k++ {
+				logL := 0.0
+				for _, ans := range answers {
+					prob := irtProbability(x[k], a[ans.item], b[ans.item])
+					if ans.correct {
+						logL += math.Log(prob)
+					} else {
+						logL += math.Log(1 - prob)
+					}
+				}
+				post[k] = prior[k] * math.Exp(logL)
+				total += post[k]
+			}
+			if total == 0 {
+				continue
+			}
+			for _, ans := range answers {
+				for k := 0; k < nodes; k++ {
+					w := post[k] / total
+					n[ans.item][k] += w
+					if ans.correct {
+						r[ans.item][k] += w
+					}
+				}
+			}
+		}
+
+		// M-step: passos de Newton por questão
+		// Prioris: b ~ N(0, 2²); a ~ N(1, 0.5²) no 2PL
+		maxChange := 0.0
+		for i := range b {
+			for step := 0; step < 5; step++ {
+				var gB, hB, gA, hA float64
+				for k := 0; k < nodes; k++ {
+					prob := irtProbability(x[k], a[i], b[i])
+					resid := r[i][k] - n[i][k]*prob
+					info := n[i][k] * prob * (1 - prob)
+					gB -= a[i] * resid
+					hB -= a[i] * a[i] * info
+					d := x[k] - b[i]
+					gA += d * resid
+					hA -= d * d * info
+				}
+				nextB := clamp(b[i]-(gB-b[i]/4)/(hB-0.25), -irtThetaBound, irtThetaBound)
+				maxChange = math.Max(maxChange, math.Abs(nextB-b[i]))
+				b[i] = nextB
+				if model == domain.IRTModel2PL {
+					nextA := clamp(a[i]-(gA-(a[i]-1)*4)/(hA-4), irtMinDiscrimination, irtMaxDiscrimination)
+					maxChange = math.Max(maxChange, math.Abs(nextA-a[i]))
+					a[i] = nextA
+				}
+			}
+		}
+
+		if maxChange < irtTolerance {
+			break
+		}
+	}
+
+	params := make([]domain.ItemParams, len(itemIDs))
+	for i, id := range itemIDs {
+		params[i] = domain.ItemParams{
+			QuestionID:     id,
+			Model:          model,
+			Discrimination: a[i],
+			Difficulty:     b[i],
+			Responses:      counts[id],
+		}
+	}
+	return params
+}
+
+// CalibrateQuestionBank recalibra todas as questões com respostas suficientes
+func (s *Service) CalibrateQuestionBank(model string) (int, error) {
+	if model == "" {
+		model = domain.IRTModel1PL
+	}
+	if model != domain.IRTModel1PL && model != domain.IRTModel2PL {
+		return 0, errors.New("modelo inválido (use 1PL ou 2PL)")
+	}
+	responses, err := s.Repo.GetItemResponses()
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	params := CalibrateItems(responses, model)
+	if err := s.Repo.SaveItemParams(params); err != nil {
+		return 0, err
+	}
+	logger.Info("Calibração TRI (%s) concluída | Questões: %d | Respostas: %d | Duração: %v", model, len(params), len(responses), time.Since(start))
+	return len(params), nil
+}
+
+// --- Sessões Adaptativas ---
+
+// AdaptiveStep é a resposta de cada passo do teste adaptativo
+type AdaptiveStep struct {
+	SessionID  string                  `json:"sessionId"`
+	Finished   bool                    `json:"finished"`
+	Question   *domain.Question        `json:"question,omitempty"` // Próxima questão (sem gabarito)
+	ItemNumber int                     `json:"itemNumber,omitempty"`
+	MaxItems   int                     `json:"maxItems"`
+	Ability    *domain.AbilityEstimate `json:"ability,omitempty"` // Apenas ao final
+	ResultID   string                  `json:"resultId,omitempty"`
+}
+
+// adaptiveConfig aplica os valores padrão à configuração do exame
+func adaptiveConfig(exam domain.Exam) domain.AdaptiveConfig {
+	cfg := domain.AdaptiveConfig{}
+	if exam.Adaptive != nil {
+		cfg = *exam.Adaptive
+	}
+	if cfg.MaxItems <= 0 {
+		cfg.MaxItems = adaptiveDefaultMaxItems
+	}
+	if cfg.MaxItems > len(exam.Questions) {
+		cfg.MaxItems = len(exam.Questions)
+	}
+	if cfg.MinItems <= 0 {
+		cfg.MinItems = adaptiveDefaultMinItems
+	}
+	if cfg.MinItems > cfg.MaxItems {
+		cfg.MinItems = cfg.MaxItems
+	}
+	if cfg.SEThreshold <= 0 {
+		cfg.SEThreshold = adaptiveDefaultSE
+	}
+	return cfg
+}
+
+// examItemParams carrega os parâmetros das questões do exame (padrão para não calibradas)
+func (s *Service) examItemParams(exam domain.Exam) (map[string]domain.ItemParams, error) {
+	ids := make([]string, len(exam.Questions))
+	for i, q := range exam.Questions {
+		ids[i] = q.ID
+	}
+	params, err := s.Repo.GetItemParams(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, ok := params[id]; !ok {
+			params[id] = defaultItemParams(id)
+		}
+	}
+	return params, nil
+}
+
+// selectNextItem escolhe a questão não aplicada de maior informação na habilidade atual
+func selectNextItem(exam domain.Exam, params map[string]domain.ItemParams, answered []domain.AdaptiveAnswer, theta float64) *domain.Question {
+	used := map[string]bool{}
+	for _, a := range answered {
+		used[a.QuestionID] = true
+	}
+	var best *domain.Question
+	bestInfo := -1.0
+	for i := range exam.Questions {
+		q := &exam.Questions[i]
+		if used[q.ID] {
+			continue
+		}
+		if info := itemInformation(theta, params[q.ID]); info > bestInfo {
+			bestInfo = info
+			best = q
+		}
+	}
+	return best
+}
+
+// sanitizedQuestion remove o gabarito antes de enviar ao candidato
+func sanitizedQuestion(q domain.Question) *domain.Question {
+	q.CorrectIndex = -1
+	q.Explanation = ""
+	return &q
+}
+
+// StartAdaptiveSession inicia uma aplicação adaptativa e retorna a primeira questão
+func (s *Service) StartAdaptiveSession(exam domain.Exam, session domain.AdaptiveSession) (AdaptiveStep, error) {
+	if exam.Adaptive == nil || !exam.Adaptive.Enabled {
+		return AdaptiveStep{}, errors.New("exame não está no modo adaptativo")
+	}
+	if len(exam.Questions) == 0 {
+		return AdaptiveStep{}, errors.New("exame sem questões")
+	}
+	params, err := s.examItemParams(exam)
+	if err != nil {
+		return AdaptiveStep{}, err
+	}
+	cfg := adaptiveConfig(exam)
+
+	first := selectNextItem(exam, params, nil, 0)
+	session.ID = uuid.New().String()
+	session.ExamID = exam.ID
+	session.Theta = 0
+	session.SE = 1
+	session.Responses = []domain.AdaptiveAnswer{}
+	session.CurrentQuestionID = first.ID
+	session.Status = "active"
+	session.CreatedAt = time.Now().UnixMilli()
+	if err := s.Repo.CreateAdaptiveSession(session); err != nil {
+		return AdaptiveStep{}, err
+	}
+	// O ID da sessão é também a tentativa (eventos de proctoring e resultado)
+	if err := s.Repo.CreateExamAttempt(domain.ExamAttempt{
+		ID: session.ID, ExamID: exam.ID, UserID: session.UserID, LinkID: session.LinkID, StartedAt: session.CreatedAt,
+	}); err != nil {
+		return AdaptiveStep{}, err
+	}
+	return AdaptiveStep{
+		SessionID:  session.ID,
+		Question:   sanitizedQuestion(*first),
+		ItemNumber: 1,
+		MaxItems:   cfg.MaxItems,
+	}, nil
+}
+
+// AnswerAdaptiveSession registra a resposta da questão atual e decide entre a próxima questão ou o fim.
+// userID deve ser o dono da sessão ("" para sessões de link público).
+func (s *Service) AnswerAdaptiveSession(sessionID, userID, questionID string, selectedIndex int) (AdaptiveStep, error) {
+	session, err := s.Repo.GetAdaptiveSession(sessionID)
+	if err != nil || session.UserID != userID {
+		return AdaptiveStep{}, errors.New("sessão não encontrada")
+	}
+	if session.Status != "active" {
+		return AdaptiveStep{}, errors.New("sessão já finalizada")
+	}
+	if session.CurrentQuestionID != questionID {
+		return AdaptiveStep{}, errors.New("questão não corresponde à questão atual")
+	}
+	exam, err := s.Repo.GetExamByID(session.ExamID)
+	if err != nil {
+		return AdaptiveStep{}, errors.New("prova não encontrada")
+	}
+	params, err := s.examItemParams(exam)
+	if err != nil {
+		return AdaptiveStep{}, err
+	}
+	cfg := adaptiveConfig(exam)
+
+	correct := false
+	for _, q := range exam.Questions {
+		if q.ID == questionID {
+			correct = q.CorrectIndex == selectedIndex
+			break
+		}
+	}
+	session.Responses = append(session.Responses, domain.AdaptiveAnswer{
+		QuestionID:    questionID,
+		SelectedIndex: selectedIndex,
+		IsCorrect:     correct,
+	})
+	est := EstimateAbility(session.Responses, params)
+	session.Theta = est.Theta
+	session.SE = est.SE
+
+	// Critério de parada: erro padrão abaixo do limiar (após o mínimo) ou comprimento máximo
+	n := len(session.Responses)
+	next := selectNextItem(exam, params, session.Responses, est.Theta)
+	stop := n >= cfg.MaxItems || next == nil || (n >= cfg.MinItems && est.SE <= cfg.SEThreshold)
+
+	if !stop {
+		session.CurrentQuestionID = next.ID
+		if err := s.Repo.UpdateAdaptiveSession(session, questionID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return AdaptiveStep{}, errors.New("questão já respondida")
+			}
+			return AdaptiveStep{}, err
+		}
+		return AdaptiveStep{
+			SessionID:  session.ID,
+			Question:   sanitizedQuestion(*next),
+			ItemNumber: n + 1,
+			MaxItems:   cfg.MaxItems,
+		}, nil
+	}
+
+	// Finalizar: encerrar a sessão (apenas uma requisição consegue) e registrar o resultado com a estimativa de habilidade
+	now := time.Now()
+	session.FinishedAt = now.UnixMilli()
+	if err := s.Repo.FinishAdaptiveSession(session, questionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return AdaptiveStep{}, errors.New("sessão já finalizada")
+		}
+		return AdaptiveStep{}, err
+	}
+	score := 0
+	for _, r := range session.Responses {
+		if r.IsCorrect {
+			score++
+		}
+	}
+	result := domain.ExamResult{
+		ID:               uuid.New().String(),
+		ExamID:           session.ExamID,
+		UserID:           session.UserID,
+		LinkID:           session.LinkID,
+		AttemptID:        session.ID, // Eventos de proctoring usam o ID da sessão como tentativa
+		CandidateName:    session.CandidateName,
+		CandidateEmail:   session.CandidateEmail,
+		Score:            score,
+		TotalQuestions:   n,
+		Answers:          session.Responses,
+		TimeSpentSeconds: int(now.Sub(time.UnixMilli(session.CreatedAt)).Seconds()),
+		Date:             now.UnixMilli(),
+		Ability:          &est,
+	}
+	// O limite de tentativas é conferido de novo ao gravar (sessões simultâneas passam pela checagem do início)
+	var link domain.PublicLink
+	if session.LinkID != "" {
+		if link, err = s.Repo.GetLinkByID(session.LinkID); err != nil {
+			return AdaptiveStep{}, errors.New("link inválido")
+		}
+	}
+	if err := s.SubmitResult(result, exam, link); err != nil {
+		return AdaptiveStep{}, err
+	}
+	if err := s.Repo.SetAdaptiveSessionResult(session.ID, result.ID); err != nil {
+		return AdaptiveStep{}, err
+	}
+	return AdaptiveStep{
+		SessionID: session.ID,
+		Finished:  true,
+		MaxItems:  cfg.MaxItems,
+		Ability:   &est,
+		ResultID:  result.ID,
+	}, nil
+}
+
+// StartPublicAdaptiveSession inicia uma sessão adaptativa para um candidato via link público
+func (s *Service) StartPublicAdaptiveSession(token, candidateName, candidateEmail string) (AdaptiveStep, error) {
+	link, err := s.Repo.GetLinkByToken(token)
+	if err != nil {
+		return AdaptiveStep{}, errors.New("link inválido")
+	}
+	if !link.Active {
+		return AdaptiveStep{}, errors.New("link inativo")
+	}
+	if link.ExpiresAt > 0 && time.Now().UnixMilli() > link.ExpiresAt {
+		return AdaptiveStep{}, errors.New("link expirado")
+	}
+	exam, err := s.Repo.GetExamByID(link.ExamID)
+	if err != nil {
+		return AdaptiveStep{}, errors.New("prova não encontrada")
+	}
+	if err := examOpenForLinks(exam); err != nil {
+		return AdaptiveStep{}, err
+	}
+	if err := checkAvailability(exam, link, time.Now(), 0); err != nil {
+		return AdaptiveStep{}, err
+	}
+	if err := s.CheckAttemptAllowed(exam, link, "", candidateEmail); err != nil {
+		return AdaptiveStep{}, err
+	}
+	return s.StartAdaptiveSession(exam, domain.AdaptiveSession{
+		LinkID:         link.ID,
+		CandidateName:  candidateName,
+		CandidateEmail: candidateEmail,
+	})
+}
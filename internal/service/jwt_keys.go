@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/logger"
+	"esimulate-backend/internal/security"
+	"strconv"
+	"time"
+)
+
+// Ciclo de vida das chaves de assinatura JWT
+const (
+	jwtKeyPrePublish = 24 * time.Hour // Antecedência com que a próxima chave aparece no JWKS
+	jwtKeyRetention  = time.Hour      // Tempo que a chave substituída segue publicada (access token: 15 min + tolerância)
+)
+
+// jwtAlgorithm é o algoritmo das novas chaves (JWT_ALGORITHM: RS256 ou EdDSA)
+func jwtAlgorithm() string {
+	if alg := getEnv("JWT_ALGORITHM", security.JWTAlgRS256); alg == security.JWTAlgEdDSA {
+		return alg
+	}
+	return security.JWTAlgRS256
+}
+
+// jwtKeyRotation é o período de uso de cada chave (JWT_KEY_ROTATION_DAYS, padrão 30)
+func jwtKeyRotation() time.Duration {
+	days, err := strconv.Atoi(getEnv("JWT_KEY_ROTATION_DAYS", "30"))
+	if err != nil || days < 1 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// loadSigningKeys carrega as chaves publicadas do banco (carregador do JWTKeySet), decifrando a chave privada
+func (s *Service) loadSigningKeys() ([]security.SigningKey, error) {
+	if s.KeyCipher == nil {
+		return nil, errors.New("JWT_KEY_ENCRYPTION_KEY não configurada")
+	}
+	stored, err := s.Repo.GetSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]security.SigningKey, 0, len(stored))
+	for _, k := range stored {
+		privatePEM := k.PrivateKey
+		if security.IsEncryptedKey(privatePEM) {
+			if privatePEM, err = s.KeyCipher.Decrypt(privatePEM, k.ID); err != nil {
+				logger.Error("Chave JWT %s ignorada: %v", k.ID, err)
+				continue
+			}
+		}
+		key, err := security.ParseSigningKey(k.ID, k.Algorithm, privatePEM, time.UnixMilli(k.ActivatesAt))
+		if err != nil {
+			logger.Error("Chave JWT %s ignorada: %v", k.ID, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// encryptLegacySigningKeys cifra as chaves gravadas em texto puro antes da criptografia das chaves privadas
+func (s *Service) encryptLegacySigningKeys(stored []domain.JWTSigningKey) error {
+	for _, k := range stored {
+		if security.IsEncryptedKey(k.PrivateKey) {
+			continue
+		}
+		encrypted, err := s.KeyCipher.Encrypt(k.PrivateKey, k.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.Repo.EncryptSigningKey(k.ID, k.PrivateKey, encrypted); err != nil {
+			return err
+		}
+		logger.Info("Chave JWT %s cifrada no banco", k.ID)
+	}
+	return nil
+}
+
+// RotateSigningKeys agenda a próxima chave quando a atual se aproxima do fim do período,
+// exclui as chaves cujos tokens já expiraram e recarrega o JWTKeySet.
+// Sem chave ativa (primeira execução) ou com JWT_ALGORITHM alterado, a nova chave ativa imediatamente.
+// As chaves privadas são gravadas cifradas com KeyCipher (chaves antigas em texto puro são cifradas aqui).
+func (s *Service) RotateSigningKeys() error {
+	if s.KeyCipher == nil {
+		return errors.New("JWT_KEY_ENCRYPTION_KEY não configurada")
+	}
+	now := time.Now()
+	stored, err := s.Repo.GetSigningKeys()
+	if err != nil {
+		return err
+	}
+	if err := s.encryptLegacySigningKeys(stored); err != nil {
+		return err
+	}
+
+	var current *domain.JWTSigningKey
+	pending := false
+	for i := range stored {
+		if time.UnixMilli(stored[i].ActivatesAt).After(now) {
+			pending = true
+		} else {
+			current = &stored[i]
+		}
+	}
+
+	rotation := jwtKeyRotation()
+	prePublish := jwtKeyPrePublish
+	if prePublish > rotation/2 {
+		prePublish = rotation / 2
+	}
+	alg := jwtAlgorithm()
+
+	var activatesAt, after time.Time
+	switch {
+	case current == nil:
+		activatesAt = now
+	case pending:
+		// Próxima chave já agendada
+	case current.Algorithm != alg:
+		activatesAt, after = now, time.UnixMilli(current.ActivatesAt)
+	case now.After(time.UnixMilli(current.ActivatesAt).Add(rotation - prePublish)):
+		activatesAt, after = time.UnixMilli(current.ActivatesAt).Add(rotation), time.UnixMilli(current.ActivatesAt)
+		if activatesAt.Before(now) {
+			activatesAt = now
+		}
+	}
+
+	if !activatesAt.IsZero() {
+		key, err := security.GenerateSigningKey(alg, time.UnixMilli(activatesAt.UnixMilli()))
+		if err != nil {
+			return err
+		}
+		privatePEM, err := key.EncodePrivateKey()
+		if err != nil {
+			return err
+		}
+		encrypted, err := s.KeyCipher.Encrypt(privatePEM, key.ID)
+		if err != nil {
+			return err
+		}
+		created, err := s.Repo.CreateSigningKey(domain.JWTSigningKey{
+			ID:          key.ID,
+			Algorithm:   key.Algorithm,
+			PrivateKey:  encrypted,
+			ActivatesAt: activatesAt.UnixMilli(),
+		}, after)
+		if err != nil {
+			return err
+		}
+		if created {
+			logger.Info("Nova chave JWT %s (%s) ativa em %s", key.ID, key.Algorithm, activatesAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	if _, err := s.Repo.DeleteRetiredSigningKeys(now.Add(-jwtKeyRetention)); err != nil {
+		return err
+	}
+	return s.JWTKeys.Reload()
+}
+
+// KeyRotationService verifica periodicamente a rotação das chaves JWT e recarrega as chaves criadas por outras réplicas
+type KeyRotationService struct {
+	svc      *Service
+	interval time.Duration
+	stopChan chan bool
+	running  bool
+}
+
+// NewKeyRotationService cria o serviço de rotação; interval é a frequência de verificação (padrão 1 minuto)
+func NewKeyRotationService(svc *Service, interval time.Duration) *KeyRotationService {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &KeyRotationService{
+		svc:      svc,
+		interval: interval,
+		stopChan: make(chan bool),
+	}
+}
+
+// Start inicia a verificação periódica em background
+func (ks *KeyRotationService) Start() {
+	if ks.running {
+		logger.Warn("KeyRotationService já está em execução")
+		return
+	}
+	ks.running = true
+	logger.Info("KeyRotationService iniciado | Rotação a cada %v", jwtKeyRotation())
+
+	go func() {
+		ticker := time.NewTicker(ks.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := ks.svc.RotateSigningKeys(); err != nil {
+					logger.Error("Erro na rotação das chaves JWT: %v", err)
+				}
+			case <-ks.stopChan:
+				logger.Info("KeyRotationService parado")
+				return
+			}
+		}
+	}()
+}
+
+// Stop para o serviço de rotação
+func (ks *KeyRotationService) Stop() {
+	if !ks.running {
+		return
+	}
+	ks.running = false
+	close(ks.stopChan)
+}
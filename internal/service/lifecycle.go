@@ -0,0 +1,112 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"esimulate-backend/internal/domain"
+)
+
+// examTransitions mapeia cada ação para os estados de origem permitidos e o estado de destino
+var examTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	"publish":   {from: []string{domain.ExamDraft}, to: domain.ExamPublished},
+	"unpublish": {from: []string{domain.ExamPublished}, to: domain.ExamDraft},
+	"archive":   {from: []string{domain.ExamDraft, domain.ExamPublished}, to: domain.ExamArchived},
+	"unarchive": {from: []string{domain.ExamArchived}, to: domain.ExamPublished},
+}
+
+// examOpenForLinks verifica se o exame pode ser acessado por links públicos
+func examOpenForLinks(exam domain.Exam) error {
+	if exam.DeletedAt > 0 || exam.Status == domain.ExamDraft || exam.Status == domain.ExamArchived {
+		return errors.New("prova não disponível")
+	}
+	return nil
+}
+
+// CanLinkExam valida a criação de um link público para o exame (que o usuário precisa poder visualizar)
+func (s *Service) CanLinkExam(examID, userID string) error {
+	exam, err := s.Exams.GetExamByID(examID)
+	if err != nil || exam.DeletedAt > 0 {
+		return errors.New("prova não encontrada")
+	}
+	if !s.CanViewExam(exam, userID) {
+		return errors.New("acesso negado")
+	}
+	if exam.Status != domain.ExamPublished {
+		return errors.New("apenas exames publicados podem ter links públicos")
+	}
+	return nil
+}
+
+// TransitionExam aplica uma ação do ciclo de vida (publish, unpublish, archive, unarchive)
+func (s *Service) TransitionExam(examID, userID, role, action string) (domain.Exam, error) {
+	t, ok := examTransitions[action]
+	if !ok {
+		return domain.Exam{}, errors.New("ação inválida")
+	}
+	exam, err := s.Exams.GetExamByID(examID)
+	if err != nil || exam.DeletedAt > 0 {
+		return domain.Exam{}, errors.New("prova não encontrada")
+	}
+	if !s.CanModifyExam(exam, userID, role) {
+		return domain.Exam{}, errors.New("acesso negado")
+	}
+	allowed := false
+	for _, from := range t.from {
+		if exam.Status == from {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return domain.Exam{}, errors.New("transição de estado inválida")
+	}
+	if err := s.Exams.UpdateExamStatus(examID, t.to); err != nil {
+		return domain.Exam{}, err
+	}
+	exam.Status = t.to
+	exam.IsVerified = calculateExamIsVerified(exam)
+	return exam, nil
+}
+
+// DeleteExam exclui logicamente o exame; resultados são mantidos até a purga
+func (s *Service) DeleteExam(examID, userID, role string) error {
+	exam, err := s.Exams.GetExamByID(examID)
+	if err != nil || exam.DeletedAt > 0 {
+		return errors.New("prova não encontrada")
+	}
+	if !s.CanModifyExam(exam, userID, role) {
+		return errors.New("acesso negado")
+	}
+	err = s.Exams.SoftDeleteExam(examID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("prova não encontrada")
+	}
+	return err
+}
+
+// RestoreExam desfaz a exclusão lógica de um exame ainda não purgado
+func (s *Service) RestoreExam(examID, userID, role string) (domain.Exam, error) {
+	exam, err := s.Exams.GetExamByID(examID)
+	if err != nil || exam.DeletedAt == 0 {
+		return domain.Exam{}, errors.New("prova não encontrada")
+	}
+	if !s.CanModifyExam(exam, userID, role) {
+		return domain.Exam{}, errors.New("acesso negado")
+	}
+	if err := s.Exams.RestoreExam(examID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Exam{}, errors.New("prova não encontrada")
+		}
+		return domain.Exam{}, err
+	}
+	exam.DeletedAt = 0
+	exam.IsVerified = calculateExamIsVerified(exam)
+	return exam, nil
+}
+
+// IsExamOpenForLinks indica se o exame aceita acessos por link público e submissões (publicado e não excluído)
+func (s *Service) IsExamOpenForLinks(exam domain.Exam) bool {
+	return examOpenForLinks(exam) == nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/logger"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Atraso progressivo entre tentativas de login da mesma conta
+const (
+	loginBackoffFreeAttempts = 3               // Falhas sem atraso
+	loginBackoffMax          = 5 * time.Minute // Atraso máximo entre tentativas
+)
+
+// loginLockoutPolicy retorna quantas falhas seguidas bloqueiam a conta (LOGIN_MAX_FAILURES, padrão 10)
+// e por quanto tempo (LOGIN_LOCKOUT_MINUTES, padrão 15)
+func loginLockoutPolicy() (int, time.Duration) {
+	maxFailures, err := strconv.Atoi(getEnv("LOGIN_MAX_FAILURES", "10"))
+	if err != nil || maxFailures < 1 {
+		maxFailures = 10
+	}
+	minutes, err := strconv.Atoi(getEnv("LOGIN_LOCKOUT_MINUTES", "15"))
+	if err != nil || minutes < 1 {
+		minutes = 15
+	}
+	return maxFailures, time.Duration(minutes) * time.Minute
+}
+
+// loginBackoff é o intervalo exigido após n falhas seguidas: 1s, 2s, 4s... a partir da 4ª falha
+func loginBackoff(failures int) time.Duration {
+	if failures <= loginBackoffFreeAttempts {
+		return 0
+	}
+	exp := failures - loginBackoffFreeAttempts - 1
+	if exp > 16 {
+		return loginBackoffMax
+	}
+	if d := time.Second << exp; d < loginBackoffMax {
+		return d
+	}
+	return loginBackoffMax
+}
+
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// spendPasswordCheck executa uma comparação bcrypt descartável, para que contas inexistentes
+// ou bloqueadas respondam no mesmo tempo que uma senha incorreta
+func spendPasswordCheck(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("senha-inexistente"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
+
+// verifyPassword confere a senha aplicando atraso progressivo e bloqueio temporário por conta.
+// Toda recusa retorna "senha incorreta", sem indicar bloqueio (não revela a existência da conta).
+func (s *Service) verifyPassword(u domain.User, password string) error {
+	lockout, err := s.Repo.GetLoginLockout(u.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if lockout.LockedUntil > now.UnixMilli() {
+		logger.Debug("Login recusado: conta %s bloqueada até %s", u.ID, time.UnixMilli(lockout.LockedUntil).Format(time.RFC3339))
+		spendPasswordCheck(password)
+		return errors.New("senha incorreta")
+	}
+	if wait := loginBackoff(lockout.FailedAttempts); wait > 0 && now.Before(time.UnixMilli(lockout.LastFailedAt).Add(wait)) {
+		// Tentativa antes do fim do atraso: recusada sem avaliar a senha e sem contar como falha
+		spendPasswordCheck(password)
+		return errors.New("senha incorreta")
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+		s.recordLoginFailure(u)
+		return errors.New("senha incorreta")
+	}
+	if lockout.FailedAttempts > 0 || lockout.LockedUntil > 0 {
+		s.Repo.ClearLoginFailures(u.ID)
+	}
+	return nil
+}
+
+// recordLoginFailure conta a falha e bloqueia a conta ao atingir o limite, avisando o dono por email
+func (s *Service) recordLoginFailure(u domain.User) {
+	failures, err := s.Repo.IncrementLoginFailures(u.ID)
+	if err != nil {
+		logger.Error("Erro ao registrar falha de login de %s: %v", u.ID, err)
+		return
+	}
+	maxFailures, lockFor := loginLockoutPolicy()
+	if failures < maxFailures {
+		return
+	}
+	until := time.Now().Add(lockFor)
+	locked, err := s.Repo.LockAccount(u.ID, maxFailures, until)
+	if err != nil || !locked {
+		return
+	}
+	logger.Warn("[SECURITY] Conta %s bloqueada até %s após %d tentativas de login", u.ID, until.Format(time.RFC3339), failures)
+	go s.EmailService.SendAccountLockedEmail(u.Email, u.Name, failures, until.In(windowLocation(domain.Availability{Timezone: defaultTimezone()})).Format("02/01/2006 15:04 MST"))
+}
+
+// GetLoginLockout retorna a situação de bloqueio de login de um usuário (uso do admin)
+func (s *Service) GetLoginLockout(userID string) (domain.LoginLockout, error) {
+	if _, err := s.Repo.GetUserByID(userID); err != nil {
+		return domain.LoginLockout{}, errors.New("usuário não encontrado")
+	}
+	l, err := s.Repo.GetLoginLockout(userID)
+	if err != nil {
+		return l, err
+	}
+	if l.LockedUntil <= time.Now().UnixMilli() {
+		l.LockedUntil = 0
+	}
+	return l, nil
+}
+
+// UnlockAccount remove o bloqueio e zera as falhas de login de um usuário (uso do admin)
+func (s *Service) UnlockAccount(userID string) error {
+	if _, err := s.Repo.GetUserByID(userID); err != nil {
+		return errors.New("usuário não encontrado")
+	}
+	return s.Repo.ClearLoginFailures(userID)
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/logger"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// orgRank ordena os papéis da organização
+var orgRank = map[string]int{domain.OrgViewer: 1, domain.OrgRecruiter: 2, domain.OrgOwner: 3}
+
+// orgInvitationTTL é a validade dos convites para ingressar na organização
+const orgInvitationTTL = 7 * 24 * time.Hour
+
+// validateOrgProfile normaliza e valida o perfil da organização
+func validateOrgProfile(o *domain.Organization) error {
+	o.CommercialName = strings.TrimSpace(o.CommercialName)
+	o.TaxID = strings.TrimSpace(o.TaxID)
+	if o.CommercialName == "" {
+		return errors.New("nome comercial é obrigatório")
+	}
+	return nil
+}
+
+// CreateOrganization cria uma organização com o usuário como owner
+func (s *Service) CreateOrganization(userID string, o domain.Organization) (domain.Organization, error) {
+	if err := validateOrgProfile(&o); err != nil {
+		return domain.Organization{}, err
+	}
+	o.ID = uuid.New().String()
+	o.CreatedBy = userID
+	o.CreatedAt = time.Now().UnixMilli()
+	o.UpdatedAt = o.CreatedAt
+	if err := s.Repo.CreateOrganization(o, userID); err != nil {
+		return domain.Organization{}, err
+	}
+	o.Role = domain.OrgOwner
+	return o, nil
+}
+
+// createCompanyOrganization cria a organização de um membro de uma conta company recém-registrada
+// (mesmo id do usuário, como na migração das contas existentes)
+func (s *Service) createCompanyOrganization(u domain.User) {
+	name, logo, taxID := u.Name, "", ""
+	if profileMap, ok := u.Profile.(map[string]interface{}); ok {
+		if n, ok := profileMap["commercialName"].(string); ok && n != "" {
+			name = n
+		}
+		logo, _ = profileMap["companyLogo"].(string)
+		if t, ok := profileMap["taxId"].(string); ok {
+			taxID = t
+		} else if t, ok := profileMap["cnpj"].(string); ok {
+			taxID = t
+		}
+	}
+	org := domain.Organization{
+		ID: u.ID, CommercialName: name, Logo: logo, TaxID: taxID,
+		CreatedBy: u.ID, CreatedAt: time.Now().UnixMilli(),
+	}
+	if err := s.Repo.CreateOrganization(org, u.ID); err != nil {
+		logger.Error("Erro ao criar organização da empresa %s: %v", u.ID, err)
+	}
+}
+
+// OrgMembership carrega a organização e exige que o usuário tenha ao menos o papel minRole
+func (s *Service) OrgMembership(orgID, userID, minRole string) (domain.Organization, error) {
+	org, err := s.Repo.GetOrganization(orgID)
+	if err != nil {
+		return domain.Organization{}, errors.New("organização não encontrada")
+	}
+	role, err := s.Repo.GetOrgMemberRole(orgID, userID)
+	if err != nil {
+		return domain.Organization{}, err
+	}
+	if role == "" {
+		return domain.Organization{}, errors.New("organização não encontrada")
+	}
+	if orgRank[role] < orgRank[minRole] {
+		return domain.Organization{}, errors.New("acesso negado")
+	}
+	org.Role = role
+	return org, nil
+}
+
+// ResolveOrg define a organização de uma requisição da área da empresa: a informada ou,
+// sem escolha explícita, a primeira organização do usuário
+func (s *Service) ResolveOrg(userID, requestedOrgID, minRole string) (domain.Organization, error) {
+	if requestedOrgID != "" {
+		return s.OrgMembership(requestedOrgID, userID, minRole)
+	}
+	orgs, err := s.Repo.GetUserOrganizations(userID)
+	if err != nil {
+		return domain.Organization{}, err
+	}
+	if len(orgs) == 0 {
+		return domain.Organization{}, errors.New("usuário não pertence a uma organização")
+	}
+	if orgRank[orgs[0].Role] < orgRank[minRole] {
+		return domain.Organization{}, errors.New("acesso negado")
+	}
+	return orgs[0], nil
+}
+
+// UpdateOrganization atualiza o perfil (apenas owner)
+func (s *Service) UpdateOrganization(orgID, userID string, o domain.Organization) (domain.Organization, error) {
+	org, err := s.OrgMembership(orgID, userID, domain.OrgOwner)
+	if err != nil {
+		return domain.Organization{}, err
+	}
+	if err := validateOrgProfile(&o); err != nil {
+		return domain.Organization{}, err
+	}
+	org.CommercialName, org.Logo, org.TaxID = o.CommercialName, o.Logo, o.TaxID
+	if err := s.Repo.UpdateOrganization(org); err != nil {
+		return domain.Organization{}, err
+	}
+	org.UpdatedAt = time.Now().UnixMilli()
+	return org, nil
+}
+
+// GetOrgMembers lista os membros (qualquer membro pode consultar)
+func (s *Service) GetOrgMembers(orgID, userID string) ([]domain.OrgMember, error) {
+	if _, err := s.OrgMembership(orgID, userID, domain.OrgViewer); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetOrgMembers(orgID)
+}
+
+// ensureOtherOwner impede que a organização fique sem owner
+func (s *Service) ensureOtherOwner(orgID, memberID string) error {
+	role, err := s.Repo.GetOrgMemberRole(orgID, memberID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return errors.New("membro não encontrado")
+	}
+	if role != domain.OrgOwner {
+		return nil
+	}
+	n, err := s.Repo.CountOrgOwners(orgID)
+	if err != nil {
+		return err
+	}
+	if n <= 1 {
+		return errors.New("a organização precisa de ao menos um owner")
+	}
+	return nil
+}
+
+// UpdateOrgMemberRole altera o papel de um membro (apenas owner)
+func (s *Service) UpdateOrgMemberRole(orgID, userID, memberID, role string) error {
+	if _, ok := orgRank[role]; !ok {
+		return errors.New("papel inválido (use owner, recruiter ou viewer)")
+	}
+	if _, err := s.OrgMembership(orgID, userID, domain.OrgOwner); err != nil {
+		return err
+	}
+	if role != domain.OrgOwner {
+		if err := s.ensureOtherOwner(orgID, memberID); err != nil {
+			return err
+		}
+	}
+	if err := s.Repo.UpdateOrgMemberRole(orgID, memberID, role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("membro não encontrado")
+		}
+		return err
+	}
+	return nil
+}
+
+// RemoveOrgMember remove um membro; owners removem qualquer um e cada membro pode sair
+func (s *Service) RemoveOrgMember(orgID, userID, memberID string) error {
+	minRole := domain.OrgOwner
+	if memberID == userID {
+		minRole = domain.OrgViewer
+	}
+	if _, err := s.OrgMembership(orgID, userID, minRole); err != nil {
+		return err
+	}
+	if err := s.ensureOtherOwner(orgID, memberID); err != nil {
+		return err
+	}
+	if err := s.Repo.RemoveOrgMember(orgID, memberID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("membro não encontrado")
+		}
+		return err
+	}
+	return nil
+}
+
+// InviteOrgMember convida um usuário (existente ou não) por email (apenas owner)
+func (s *Service) InviteOrgMember(orgID, userID, email, role string) (domain.OrgInvitation, error) {
+	if _, ok := orgRank[role]; !ok {
+		return domain.OrgInvitation{}, errors.New("papel inválido (use owner, recruiter ou viewer)")
+	}
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return domain.OrgInvitation{}, errors.New("email é obrigatório")
+	}
+	org, err := s.OrgMembership(orgID, userID, domain.OrgOwner)
+	if err != nil {
+		return domain.OrgInvitation{}, err
+	}
+	if u, err := s.Repo.GetUserByEmail(email); err == nil {
+		if r, _ := s.Repo.GetOrgMemberRole(orgID, u.ID); r != "" {
+			return domain.OrgInvitation{}, errors.New("usuário já é membro da organização")
+		}
+	}
+
+	now := time.Now()
+	inv := domain.OrgInvitation{
+		ID:        uuid.New().String(),
+		OrgID:     org.ID,
+		OrgName:   org.CommercialName,
+		Email:     email,
+		Role:      role,
+		Token:     uuid.New().String(),
+		InvitedBy: userID,
+		CreatedAt: now.UnixMilli(),
+		ExpiresAt: now.Add(orgInvitationTTL).UnixMilli(),
+	}
+	if err := s.Repo.CreateOrgInvitation(inv); err != nil {
+		return domain.OrgInvitation{}, err
+	}
+	inviterName := ""
+	if inviter, err := s.Repo.GetUserByID(userID); err == nil {
+		inviterName = inviter.Name
+	}
+	go s.EmailService.SendOrgInvitationEmail(inv.Email, org.CommercialName, org.Logo, inviterName, inv.Role, inv.Token)
+	return inv, nil
+}
+
+// GetOrgInvitations lista os convites pendentes (apenas owner)
+func (s *Service) GetOrgInvitations(orgID, userID string) ([]domain.OrgInvitation, error) {
+	if _, err := s.OrgMembership(orgID, userID, domain.OrgOwner); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetOrgInvitations(orgID)
+}
+
+// CancelOrgInvitation cancela um convite pendente (apenas owner)
+func (s *Service) CancelOrgInvitation(orgID, userID, invitationID string) error {
+	if _, err := s.OrgMembership(orgID, userID, domain.OrgOwner); err != nil {
+		return err
+	}
+	if err := s.Repo.DeleteOrgInvitation(orgID, invitationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("convite não encontrado")
+		}
+		return err
+	}
+	return nil
+}
+
+// AcceptOrgInvitation inclui o usuário autenticado na organização do convite.
+// O convite só vale para o email para o qual foi enviado.
+func (s *Service) AcceptOrgInvitation(token, userID string) (domain.Organization, error) {
+	inv, err := s.Repo.GetOrgInvitationByToken(token)
+	if err != nil || inv.AcceptedAt > 0 {
+		return domain.Organization{}, errors.New("convite não encontrado")
+	}
+	if time.Now().UnixMilli() > inv.ExpiresAt {
+		return domain.Organization{}, errors.New("convite expirado")
+	}
+	u, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return domain.Organization{}, errors.New("usuário não encontrado")
+	}
+	if !strings.EqualFold(u.Email, inv.Email) {
+		return domain.Organization{}, errors.New("convite enviado para outro email")
+	}
+	if err := s.Repo.AcceptOrgInvitation(inv, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Organization{}, errors.New("convite não encontrado")
+		}
+		return domain.Organization{}, err
+	}
+	return s.OrgMembership(inv.OrgID, userID, domain.OrgViewer)
+}
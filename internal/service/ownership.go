@@ -0,0 +1,135 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/repository/postgres"
+	"strings"
+)
+
+// shareRank ordena os papéis de compartilhamento
+var shareRank = map[string]int{domain.ShareViewer: 1, domain.ShareEditor: 2, domain.ShareOwner: 3}
+
+// ExamAccessRepo é a parte do repositório usada pelas regras de acesso e pelas operações de gestão de exames
+// (exclusão, restauração, mudança de estado e transferência)
+type ExamAccessRepo interface {
+	GetExamByID(id string) (domain.Exam, error)
+	GetExamShareRole(examID, userID string) (string, error)
+	GetUserByEmail(email string) (domain.User, error)
+	UpdateExamStatus(id, status string) error
+	SoftDeleteExam(id string) error
+	RestoreExam(id string) error
+	TransferExamOwnership(id, currentOwner, newOwner string) error
+}
+
+// ExamAccessRole resolve o papel do usuário no exame: o autor é owner; os demais, o maior papel concedido
+func (s *Service) ExamAccessRole(exam domain.Exam, userID string) string {
+	if exam.CreatedBy != "" && exam.CreatedBy == userID {
+		return domain.ShareOwner
+	}
+	role, err := s.Exams.GetExamShareRole(exam.ID, userID)
+	if err != nil {
+		return ""
+	}
+	return role
+}
+
+// CanViewExam aplica a regra de acesso de leitura: exame público, modelo do catálogo, do próprio usuário ou compartilhado.
+// Exames excluídos não são visíveis; rascunhos, apenas a autores e convidados.
+func (s *Service) CanViewExam(exam domain.Exam, userID string) bool {
+	if exam.DeletedAt > 0 {
+		return false
+	}
+	if s.ExamAccessRole(exam, userID) != "" {
+		return true
+	}
+	if exam.Status == domain.ExamDraft {
+		return false
+	}
+	return exam.IsPublic || exam.IsTemplate
+}
+
+// CanEditExam permite alterar o conteúdo: editor, owner ou admin
+func (s *Service) CanEditExam(exam domain.Exam, userID, role string) bool {
+	return role == "admin" || shareRank[s.ExamAccessRole(exam, userID)] >= shareRank[domain.ShareEditor]
+}
+
+// CanModifyExam aplica a regra de gestão (excluir, mudar estado, compartilhar): owner ou admin
+func (s *Service) CanModifyExam(exam domain.Exam, userID, role string) bool {
+	return role == "admin" || s.ExamAccessRole(exam, userID) == domain.ShareOwner
+}
+
+// AuthorizeExamUpdate valida a atualização de um exame existente.
+// Retorna o exame atual; se ele não existir, a requisição é tratada como criação.
+// Qualquer outra falha ao buscar o exame é retornada (a requisição não vira criação).
+func (s *Service) AuthorizeExamUpdate(examID, userID, role string) (domain.Exam, bool, error) {
+	exam, err := s.Exams.GetExamByID(examID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Exam{}, false, nil
+	}
+	if err != nil {
+		return domain.Exam{}, false, err
+	}
+	if exam.DeletedAt > 0 {
+		return exam, true, errors.New("prova não encontrada")
+	}
+	if !s.CanEditExam(exam, userID, role) {
+		return exam, true, errors.New("acesso negado")
+	}
+	return exam, true, nil
+}
+
+// TransferExamOwnership transfere o exame para outro usuário, identificado pelo email.
+// Só o dono atual (createdBy) ou um admin transfere; coautores compartilhados não.
+// Destinatário inexistente ou já dono geram o mesmo erro, para não revelar quais emails têm conta.
+func (s *Service) TransferExamOwnership(examID, userID, role, newOwnerEmail string) (domain.Exam, error) {
+	exam, err := s.Exams.GetExamByID(examID)
+	if err != nil || exam.DeletedAt > 0 {
+		return domain.Exam{}, errors.New("prova não encontrada")
+	}
+	if role != "admin" && exam.CreatedBy != userID {
+		return domain.Exam{}, errors.New("acesso negado")
+	}
+	newOwner, err := s.Exams.GetUserByEmail(strings.TrimSpace(newOwnerEmail))
+	if err != nil || newOwner.ID == exam.CreatedBy {
+		return domain.Exam{}, errors.New("transferência não permitida")
+	}
+	if err := s.Exams.TransferExamOwnership(examID, exam.CreatedBy, newOwner.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Exam{}, errors.New("prova não encontrada")
+		}
+		return domain.Exam{}, err
+	}
+	exam.CreatedBy = newOwner.ID
+	exam.IsVerified = calculateExamIsVerified(exam)
+	return exam, nil
+}
+
+// SaveQuestion cria a questão em nome de userID ou, se ela já existir, a atualiza.
+// Questões existentes só são alteradas pelo autor ou por admin.
+func (s *Service) SaveQuestion(q domain.Question, userID, role string) error {
+	q.CreatedBy = userID
+	if err := s.Repo.CreateQuestion(q, role == "admin"); err != nil {
+		if errors.Is(err, postgres.ErrQuestionNotOwned) {
+			return errors.New("acesso negado")
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteQuestion exclui a questão, apenas pelo autor ou por admin
+func (s *Service) DeleteQuestion(id, userID, role string) error {
+	owner, err := s.Repo.GetQuestionOwner(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("questão não encontrada")
+	}
+	if err != nil {
+		return err
+	}
+	if role != "admin" && owner != userID {
+		return errors.New("acesso negado")
+	}
+	return s.Repo.DeleteQuestion(id)
+}
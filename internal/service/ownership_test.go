@@ -0,0 +1,126 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"testing"
+)
+
+// fakeExamRepo guarda exames e papéis de compartilhamento em memória
+type fakeExamRepo struct {
+	exams  map[string]domain.Exam
+	shares map[string]string // examID + ":" + userID -> papel
+	err    error             // Falha retornada por GetExamByID
+}
+
+func (f fakeExamRepo) GetExamByID(id string) (domain.Exam, error) {
+	if f.err != nil {
+		return domain.Exam{}, f.err
+	}
+	exam, ok := f.exams[id]
+	if !ok {
+		return domain.Exam{}, sql.ErrNoRows
+	}
+	return exam, nil
+}
+
+func (f fakeExamRepo) GetExamShareRole(examID, userID string) (string, error) {
+	return f.shares[examID+":"+userID], nil
+}
+
+func (f fakeExamRepo) GetUserByEmail(email string) (domain.User, error) {
+	return domain.User{}, sql.ErrNoRows
+}
+
+func (f fakeExamRepo) UpdateExamStatus(id, status string) error                      { return nil }
+func (f fakeExamRepo) SoftDeleteExam(id string) error                                { return nil }
+func (f fakeExamRepo) RestoreExam(id string) error                                   { return nil }
+func (f fakeExamRepo) TransferExamOwnership(id, currentOwner, newOwner string) error { return nil }
+
+func newOwnershipTestService() *Service {
+	return &Service{Exams: fakeExamRepo{
+		exams: map[string]domain.Exam{
+			"exam-1": {ID: "exam-1", CreatedBy: "creator", Status: domain.ExamPublished},
+		},
+		shares: map[string]string{
+			"exam-1:viewer":   domain.ShareViewer,
+			"exam-1:editor":   domain.ShareEditor,
+			"exam-1:coauthor": domain.ShareOwner,
+		},
+	}}
+}
+
+func TestAuthorizeExamUpdate(t *testing.T) {
+	s := newOwnershipTestService()
+	tests := []struct {
+		name       string
+		examID     string
+		userID     string
+		role       string
+		wantExists bool
+		wantErr    bool
+	}{
+		{name: "criador", examID: "exam-1", userID: "creator", role: "user", wantExists: true},
+		{name: "owner compartilhado", examID: "exam-1", userID: "coauthor", role: "user", wantExists: true},
+		{name: "editor", examID: "exam-1", userID: "editor", role: "user", wantExists: true},
+		{name: "viewer", examID: "exam-1", userID: "viewer", role: "user", wantExists: true, wantErr: true},
+		{name: "estranho", examID: "exam-1", userID: "stranger", role: "user", wantExists: true, wantErr: true},
+		{name: "estranho specialist", examID: "exam-1", userID: "stranger", role: "specialist", wantExists: true, wantErr: true},
+		{name: "admin", examID: "exam-1", userID: "stranger", role: "admin", wantExists: true},
+		{name: "exame inexistente vira criação", examID: "exam-2", userID: "stranger", role: "user", wantExists: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exam, exists, err := s.AuthorizeExamUpdate(tt.examID, tt.userID, tt.role)
+			if exists != tt.wantExists {
+				t.Errorf("exists = %v, esperado %v", exists, tt.wantExists)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, esperado erro: %v", err, tt.wantErr)
+			}
+			if err != nil && err.Error() != "acesso negado" {
+				t.Errorf("err = %q, esperado \"acesso negado\"", err)
+			}
+			if tt.wantExists && exam.CreatedBy != "creator" {
+				t.Errorf("CreatedBy = %q, esperado o exame atual", exam.CreatedBy)
+			}
+		})
+	}
+}
+
+func TestAuthorizeExamUpdateLookupError(t *testing.T) {
+	s := &Service{Exams: fakeExamRepo{err: errors.New("conexão recusada")}}
+	_, exists, err := s.AuthorizeExamUpdate("exam-1", "creator", "user")
+	if err == nil {
+		t.Fatal("falha na busca deveria ser retornada, não tratada como criação")
+	}
+	if exists {
+		t.Error("exists deveria ser false")
+	}
+	if err.Error() == "acesso negado" {
+		t.Error("falha na busca não deveria ser tratada como acesso negado")
+	}
+}
+
+func TestCanModifyExam(t *testing.T) {
+	s := newOwnershipTestService()
+	exam := domain.Exam{ID: "exam-1", CreatedBy: "creator"}
+	tests := []struct {
+		userID string
+		role   string
+		want   bool
+	}{
+		{userID: "creator", role: "user", want: true},
+		{userID: "coauthor", role: "user", want: true},
+		{userID: "editor", role: "user", want: false},
+		{userID: "viewer", role: "user", want: false},
+		{userID: "stranger", role: "user", want: false},
+		{userID: "stranger", role: "admin", want: true},
+	}
+	for _, tt := range tests {
+		if got := s.CanModifyExam(exam, tt.userID, tt.role); got != tt.want {
+			t.Errorf("CanModifyExam(%s, %s) = %v, esperado %v", tt.userID, tt.role, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	practiceDefaultCount = 10
+	practiceMaxCount     = 100
+)
+
+// GeneratePracticeExam monta um simulado privado do usuário a partir do banco de questões
+func (s *Service) GeneratePracticeExam(userID string, opts domain.PracticeOptions) (domain.Exam, error) {
+	if opts.Count == 0 {
+		opts.Count = practiceDefaultCount
+	}
+	if opts.Count < 0 || opts.Count > practiceMaxCount {
+		return domain.Exam{}, errors.New("quantidade de questões inválida")
+	}
+	if opts.AvoidLastN < 0 {
+		return domain.Exam{}, errors.New("quantidade de tentativas inválida")
+	}
+	if opts.Strategy == "" {
+		opts.Strategy = domain.PracticeRandom
+	}
+	switch opts.Strategy {
+	case domain.PracticeRandom, domain.PracticeWeakest, domain.PracticeUnseen, domain.PracticeWrong:
+	default:
+		return domain.Exam{}, errors.New("estratégia inválida")
+	}
+
+	questions, err := s.Repo.GetPracticeCandidates(userID, opts)
+	if err != nil {
+		return domain.Exam{}, err
+	}
+	if len(questions) == 0 {
+		return domain.Exam{}, errors.New("nenhuma questão disponível para os critérios informados")
+	}
+
+	// Nomes das matérias (exams.subjects guarda nomes, não IDs)
+	subjectNames := map[string]string{}
+	if subs, err := s.Repo.GetSubjects(); err == nil {
+		for _, sub := range subs {
+			subjectNames[sub.ID] = sub.Name
+		}
+	}
+	subjects := []string{}
+	seen := map[string]bool{}
+	questionIDs := make([]string, 0, len(questions))
+	for _, q := range questions {
+		questionIDs = append(questionIDs, q.ID)
+		if name, ok := subjectNames[q.SubjectID]; ok && !seen[name] {
+			seen[name] = true
+			subjects = append(subjects, name)
+		}
+	}
+
+	now := time.Now()
+	title := opts.Title
+	if title == "" {
+		title = "Simulado de prática - " + now.Format("02/01/2006 15:04")
+	}
+	exam := domain.Exam{
+		ID:          uuid.New().String(),
+		Title:       title,
+		Description: "Gerado automaticamente (estratégia: " + opts.Strategy + ")",
+		Questions:   questions,
+		Subjects:    subjects,
+		TimeLimit:   opts.TimeLimit,
+		IsPublic:    false,
+		CreatedBy:   userID,
+		CreatedAt:   now.UnixMilli(),
+	}
+	if err := s.Repo.CreateExamFromBank(exam, questionIDs); err != nil {
+		return domain.Exam{}, err
+	}
+	exam.IsVerified = calculateExamIsVerified(exam)
+	return exam, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	proctoringMaxBatch         = 100
+	proctoringDefaultThreshold = 5
+)
+
+// ProctoringContext identifica a tentativa e a origem da requisição que envia os eventos
+type ProctoringContext struct {
+	AttemptID string
+	ExamID    string
+	LinkID    string
+	UserID    string
+	IP        string
+	UserAgent string
+}
+
+// validProctoringTypes são os tipos aceitos do frontend (ip_change é gerado pelo backend)
+var validProctoringTypes = map[string]bool{
+	domain.ProctorTabHidden:      true,
+	domain.ProctorWindowBlur:     true,
+	domain.ProctorCopy:           true,
+	domain.ProctorPaste:          true,
+	domain.ProctorFullscreenExit: true,
+}
+
+// StartExamAttempt emite o attemptId de uma nova tentativa do usuário (userID) ou do candidato do link (linkID)
+func (s *Service) StartExamAttempt(examID, userID, linkID string) (domain.ExamAttempt, error) {
+	attempt := domain.ExamAttempt{
+		ID:        uuid.New().String(),
+		ExamID:    examID,
+		UserID:    userID,
+		LinkID:    linkID,
+		StartedAt: time.Now().UnixMilli(),
+	}
+	if err := s.Repo.CreateExamAttempt(attempt); err != nil {
+		return domain.ExamAttempt{}, err
+	}
+	return attempt, nil
+}
+
+// checkAttemptOwner confere se a tentativa foi emitida para o exame e o candidato (usuário ou link) do contexto
+// e ainda não foi submetida
+func (s *Service) checkAttemptOwner(ctx ProctoringContext) error {
+	if _, err := uuid.Parse(ctx.AttemptID); err != nil {
+		return errors.New("attemptId inválido")
+	}
+	attempt, err := s.Repo.GetExamAttempt(ctx.AttemptID)
+	if err != nil || attempt.ExamID != ctx.ExamID || attempt.UserID != ctx.UserID || attempt.LinkID != ctx.LinkID ||
+		attempt.SubmittedAt > 0 {
+		return errors.New("tentativa não encontrada")
+	}
+	return nil
+}
+
+// RecordProctoringEvents valida e grava um lote de eventos de integridade da tentativa, que precisa ter sido
+// emitida para o mesmo candidato. Quando o IP difere do último evento recebido, registra também um evento ip_change.
+func (s *Service) RecordProctoringEvents(ctx ProctoringContext, events []domain.ProctoringEvent) (int, error) {
+	if len(events) == 0 || len(events) > proctoringMaxBatch {
+		return 0, errors.New("quantidade de eventos inválida")
+	}
+	if err := s.checkAttemptOwner(ctx); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	batch := make([]domain.ProctoringEvent, 0, len(events)+1)
+
+	if ctx.IP != "" {
+		lastIP, err := s.Repo.GetLastProctoringIP(ctx.AttemptID)
+		if err != nil {
+			return 0, err
+		}
+		if lastIP != "" && lastIP != ctx.IP {
+			batch = append(batch, domain.ProctoringEvent{
+				Type:       domain.ProctorIPChange,
+				Details:    map[string]interface{}{"from": lastIP, "to": ctx.IP},
+				OccurredAt: now.UnixMilli(),
+			})
+		}
+	}
+
+	for _, e := range events {
+		if !validProctoringTypes[e.Type] {
+			return 0, errors.New("tipo de evento inválido")
+		}
+		// Horário do cliente não pode estar no futuro
+		if e.OccurredAt <= 0 || e.OccurredAt > now.UnixMilli() {
+			e.OccurredAt = now.UnixMilli()
+		}
+		batch = append(batch, e)
+	}
+
+	for i := range batch {
+		batch[i].ID = uuid.New().String()
+		batch[i].AttemptID = ctx.AttemptID
+		batch[i].ExamID = ctx.ExamID
+		batch[i].LinkID = ctx.LinkID
+		batch[i].UserID = ctx.UserID
+		batch[i].ResultID = ""
+		batch[i].IP = ctx.IP
+		batch[i].UserAgent = ctx.UserAgent
+		batch[i].ReceivedAt = now.UnixMilli()
+	}
+	if err := s.Repo.CreateProctoringEvents(batch); err != nil {
+		return 0, err
+	}
+	return len(batch), nil
+}
+
+// AttachAttemptEvents associa os eventos da tentativa ao resultado criado
+func (s *Service) AttachAttemptEvents(attemptID, examID, resultID string) error {
+	return s.Repo.AttachProctoringEvents(attemptID, examID, resultID)
+}
+
+// GetCompanyResults retorna os resultados da organização com o resumo de integridade de cada candidato
+func (s *Service) GetCompanyResults(orgID string) ([]domain.ExamResult, error) {
+	results, err := s.Repo.GetCompanyResults(orgID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(results))
+	for _, res := range results {
+		ids = append(ids, res.ID)
+	}
+	counts, err := s.Repo.GetProctoringCounts(ids)
+	if err != nil {
+		return nil, err
+	}
+	links, _ := s.Repo.GetLinks(orgID)
+	thresholds := map[string]int{}
+	for _, l := range links {
+		thresholds[l.ID] = l.FlagThreshold
+	}
+
+	for i := range results {
+		threshold := thresholds[results[i].LinkID]
+		if threshold <= 0 {
+			threshold = proctoringDefaultThreshold
+		}
+		summary := domain.IntegritySummary{Counts: map[string]int{}, Threshold: threshold}
+		for eventType, n := range counts[results[i].ID] {
+			summary.Counts[eventType] = n
+			summary.TotalEvents += n
+		}
+		summary.Flagged = summary.TotalEvents >= threshold
+		results[i].Integrity = &summary
+	}
+	return results, nil
+}
+
+// GetResultProctoringEvents lista os eventos de um resultado, se pertencer à organização
+func (s *Service) GetResultProctoringEvents(orgID, resultID string) ([]domain.ProctoringEvent, error) {
+	ok, err := s.Repo.ResultBelongsToCompany(resultID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("resultado não encontrado")
+	}
+	return s.Repo.GetProctoringEvents(resultID)
+}
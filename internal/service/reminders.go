@@ -0,0 +1,102 @@
+package service
+
+import (
+	"esimulate-backend/internal/logger"
+	"time"
+)
+
+// ReminderService envia lembretes aos candidatos convidados antes da abertura da janela da prova
+type ReminderService struct {
+	svc      *Service
+	interval time.Duration
+	stopChan chan bool
+	running  bool
+}
+
+// NewReminderService cria o serviço de lembretes; interval é a frequência de verificação (padrão 1 minuto)
+func NewReminderService(svc *Service, interval time.Duration) *ReminderService {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &ReminderService{
+		svc:      svc,
+		interval: interval,
+		stopChan: make(chan bool),
+	}
+}
+
+// Start inicia a verificação periódica em background
+func (rs *ReminderService) Start() {
+	if rs.running {
+		logger.Warn("ReminderService já está em execução")
+		return
+	}
+	rs.running = true
+	logger.Info("ReminderService iniciado | Verificando lembretes a cada %v", rs.interval)
+
+	go func() {
+		ticker := time.NewTicker(rs.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				rs.sendDueReminders(time.Now())
+			case <-rs.stopChan:
+				logger.Info("ReminderService parado")
+				return
+			}
+		}
+	}()
+}
+
+// Stop para o serviço de lembretes
+func (rs *ReminderService) Stop() {
+	if !rs.running {
+		return
+	}
+	rs.running = false
+	close(rs.stopChan)
+}
+
+// sendDueReminders envia os lembretes cuja antecedência (reminderMinutes) já foi atingida.
+// Cada convite é reivindicado antes do envio, então réplicas concorrentes não duplicam o email.
+// Convites feitos após a abertura são marcados sem envio.
+func (rs *ReminderService) sendDueReminders(now time.Time) {
+	reminders, err := rs.svc.Repo.GetPendingLinkReminders()
+	if err != nil {
+		logger.Error("Erro ao buscar lembretes pendentes: %v", err)
+		return
+	}
+	sent := 0
+	for _, rem := range reminders {
+		w := effectiveAvailability(rem.ExamAvailability, rem.LinkAvailability)
+		if w.AvailableFrom == 0 || w.ReminderMinutes == 0 {
+			continue
+		}
+		opens := time.UnixMilli(w.AvailableFrom)
+		if now.Before(opens.Add(-time.Duration(w.ReminderMinutes) * time.Minute)) {
+			continue
+		}
+		claimed, err := rs.svc.Repo.ClaimLinkInvitationReminder(rem.InvitationID)
+		if err != nil {
+			logger.Error("Erro ao marcar lembrete: %v", err)
+			continue
+		}
+		if !claimed || !now.Before(opens) {
+			continue
+		}
+		opensLocal := opens.In(windowLocation(w)).Format("02/01/2006 15:04 MST")
+		if err := rs.svc.EmailService.SendExamReminderEmail(rem.Email, rem.OrgName, rem.OrgLogo, rem.ExamTitle, rem.LinkToken, opensLocal); err != nil {
+			logger.Error("Erro ao enviar lembrete para %s: %v", rem.Email, err)
+			// Nova tentativa na próxima verificação
+			if err := rs.svc.Repo.ReleaseLinkInvitationReminder(rem.InvitationID); err != nil {
+				logger.Error("Erro ao liberar lembrete: %v", err)
+			}
+			continue
+		}
+		sent++
+	}
+	if sent > 0 {
+		logger.Info("Lembretes de prova enviados: %d", sent)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+	"math"
+	"time"
+)
+
+const (
+	// reviewDefaultLimit é o número de cartões servidos por GET /api/me/review/next
+	reviewDefaultLimit = 10
+	reviewMaxLimit     = 50
+	// smMinEaseFactor é o fator de facilidade mínimo do SM-2
+	smMinEaseFactor = 1.3
+)
+
+// ReviewSession é a resposta de GET /api/me/review/next
+type ReviewSession struct {
+	Cards    []domain.ReviewCard `json:"cards"`
+	DueCount int                 `json:"dueCount"` // Total de cartões vencidos (pode ser maior que len(cards))
+}
+
+// ReviewAnswerResult é a resposta ao responder um cartão: correção + novo agendamento
+type ReviewAnswerResult struct {
+	Correct      bool              `json:"correct"`
+	CorrectIndex int               `json:"correctIndex"`
+	Explanation  string            `json:"explanation,omitempty"`
+	Card         domain.ReviewCard `json:"card"`
+}
+
+// GetNextReview retorna os cartões vencidos do usuário, sem gabarito
+func (s *Service) GetNextReview(userID string, limit int) (ReviewSession, error) {
+	if limit <= 0 {
+		limit = reviewDefaultLimit
+	}
+	if limit > reviewMaxLimit {
+		limit = reviewMaxLimit
+	}
+	cards, err := s.Repo.GetDueReviewCards(userID, limit)
+	if err != nil {
+		return ReviewSession{}, err
+	}
+	due, err := s.Repo.CountDueReviewCards(userID)
+	if err != nil {
+		return ReviewSession{}, err
+	}
+	// Sanitização: o gabarito só é revelado após a resposta
+	for i := range cards {
+		if cards[i].Question != nil {
+			cards[i].Question.CorrectIndex = -1
+			cards[i].Question.Explanation = ""
+		}
+	}
+	return ReviewSession{Cards: cards, DueCount: due}, nil
+}
+
+// AnswerReview corrige a resposta de um cartão e reagenda pelo SM-2
+// quality (0-5) é opcional; sem ele, acerto = 4 e erro = 1
+func (s *Service) AnswerReview(userID, questionID string, selectedIndex int, quality *int) (ReviewAnswerResult, error) {
+	if quality != nil && (*quality < 0 || *quality > 5) {
+		return ReviewAnswerResult{}, errors.New("qualidade deve estar entre 0 e 5")
+	}
+	card, err := s.Repo.GetReviewCard(userID, questionID)
+	if err != nil {
+		return ReviewAnswerResult{}, errors.New("cartão não encontrado")
+	}
+
+	correct := card.Question != nil && selectedIndex == card.Question.CorrectIndex
+	q := 1
+	if correct {
+		q = 4
+	}
+	if quality != nil {
+		q = *quality
+		// Uma resposta errada nunca conta como lembrada, independente da autoavaliação
+		if !correct && q >= 3 {
+			q = 2
+		}
+	}
+
+	card = scheduleSM2(card, q, time.Now())
+	if err := s.Repo.UpdateReviewCard(userID, card); err != nil {
+		return ReviewAnswerResult{}, err
+	}
+
+	result := ReviewAnswerResult{Correct: correct, Card: card}
+	if card.Question != nil {
+		result.CorrectIndex = card.Question.CorrectIndex
+		result.Explanation = card.Question.Explanation
+	}
+	result.Card.Question = nil
+	return result, nil
+}
+
+// scheduleSM2 aplica o algoritmo SuperMemo-2 ao cartão para uma resposta de qualidade q (0-5)
+func scheduleSM2(c domain.ReviewCard, q int, now time.Time) domain.ReviewCard {
+	if q >= 3 {
+		switch c.Repetitions {
+		case 0:
+			c.IntervalDays = 1
+		case 1:
+			c.IntervalDays = 6
+		default:
+			c.IntervalDays = int(math.Round(float64(c.IntervalDays) * c.EaseFactor))
+		}
+		c.Repetitions++
+	} else {
+		c.Repetitions = 0
+		c.IntervalDays = 1
+		c.Lapses++
+	}
+
+	d := float64(5 - q)
+	c.EaseFactor += 0.1 - d*(0.08+d*0.02)
+	if c.EaseFactor < smMinEaseFactor {
+		c.EaseFactor = smMinEaseFactor
+	}
+
+	c.LastReviewedAt = now.UnixMilli()
+	c.DueAt = now.AddDate(0, 0, c.IntervalDays).UnixMilli()
+	return c
+}
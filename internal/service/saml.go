@@ -0,0 +1,549 @@
+package service
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/logger"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// samlRequestTTL é o tempo para o usuário autenticar no IdP
+const samlRequestTTL = 10 * time.Minute
+
+// Registro DNS TXT que comprova a posse de um domínio do SSO: <samlVerificationRecord>.<domínio> = <prefixo><token>
+const (
+	samlVerificationRecord = "_esimulate-verification"
+	samlVerificationPrefix = "esimulate-verification="
+)
+
+// lookupTXT consulta os registros DNS TXT do domínio
+var lookupTXT = net.LookupTXT
+
+// Papéis que o SSO pode atribuir (owners são gerenciados na aplicação)
+var samlAssignableRoles = map[string]bool{domain.OrgRecruiter: true, domain.OrgViewer: true}
+
+// Atributos usados quando o mapeamento não é informado (Azure AD, Okta e nomes LDAP comuns)
+var (
+	samlDefaultEmailAttrs = []string{"email", "mail", "emailaddress", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress"}
+	samlDefaultNameAttrs  = []string{"name", "displayName", "http://schemas.microsoft.com/identity/claims/displayname"}
+)
+
+// Credenciais opcionais do SP (SAML_SP_CERT_FILE/SAML_SP_KEY_FILE): assinam os AuthnRequests
+// e permitem asserções criptografadas
+var (
+	samlCredentialsOnce sync.Once
+	samlSPKey           *rsa.PrivateKey
+	samlSPCert          *x509.Certificate
+)
+
+func samlCredentials() (*rsa.PrivateKey, *x509.Certificate) {
+	samlCredentialsOnce.Do(func() {
+		certFile, keyFile := getEnv("SAML_SP_CERT_FILE", ""), getEnv("SAML_SP_KEY_FILE", "")
+		if certFile == "" || keyFile == "" {
+			return
+		}
+		pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			logger.Error("Erro ao carregar certificado SAML do SP: %v", err)
+			return
+		}
+		key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+		if !ok {
+			logger.Error("Chave SAML do SP deve ser RSA")
+			return
+		}
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			logger.Error("Erro ao ler certificado SAML do SP: %v", err)
+			return
+		}
+		samlSPKey, samlSPCert = key, cert
+	})
+	return samlSPKey, samlSPCert
+}
+
+// samlBaseURL é a base das rotas SAML da organização
+func samlBaseURL(orgID string) string {
+	return strings.TrimSuffix(getEnv("API_URL", "http://localhost:8080"), "/") + "/api/sso/saml/" + orgID
+}
+
+// withSPInfo preenche os dados do SP que o administrador cadastra no IdP e os registros TXT dos domínios pendentes
+func withSPInfo(c domain.SAMLConfig) domain.SAMLConfig {
+	base := samlBaseURL(c.OrgID)
+	c.SPEntityID = base + "/metadata"
+	c.MetadataURL = base + "/metadata"
+	c.ACSURL = base + "/acs"
+	c.LoginURL = base + "/login"
+	for i, d := range c.Domains {
+		if !d.Verified && d.Token != "" {
+			c.Domains[i].TXTName = samlVerificationRecord + "." + d.Domain
+			c.Domains[i].TXTValue = samlVerificationPrefix + d.Token
+		}
+	}
+	return c
+}
+
+// samlDomainVerified informa se o domínio do email está entre os domínios verificados da configuração
+func samlDomainVerified(c domain.SAMLConfig, emailDomain string) bool {
+	for _, d := range c.Domains {
+		if d.Verified && d.Domain == emailDomain {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIdPMetadata lê o EntityDescriptor do IdP (aceita também um EntitiesDescriptor)
+func parseIdPMetadata(raw string) (*saml.EntityDescriptor, error) {
+	var ed saml.EntityDescriptor
+	if err := xml.Unmarshal([]byte(raw), &ed); err != nil || len(ed.IDPSSODescriptors) == 0 {
+		var eds saml.EntitiesDescriptor
+		if err := xml.Unmarshal([]byte(raw), &eds); err != nil {
+			return nil, errors.New("metadata do IdP inválida")
+		}
+		found := false
+		for _, e := range eds.EntityDescriptors {
+			if len(e.IDPSSODescriptors) > 0 {
+				ed, found = e, true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("metadata do IdP inválida")
+		}
+	}
+	if ed.EntityID == "" {
+		return nil, errors.New("metadata do IdP inválida: entityID ausente")
+	}
+	hasRedirect, hasCert := false, false
+	for _, idp := range ed.IDPSSODescriptors {
+		for _, sso := range idp.SingleSignOnServices {
+			if sso.Binding == saml.HTTPRedirectBinding {
+				hasRedirect = true
+			}
+		}
+		for _, kd := range idp.KeyDescriptors {
+			if (kd.Use == "" || kd.Use == "signing") && len(kd.KeyInfo.X509Data.X509Certificates) > 0 {
+				hasCert = true
+			}
+		}
+	}
+	if !hasRedirect {
+		return nil, errors.New("metadata do IdP inválida: SSO com binding HTTP-Redirect ausente")
+	}
+	if !hasCert {
+		return nil, errors.New("metadata do IdP inválida: certificado de assinatura ausente")
+	}
+	return &ed, nil
+}
+
+// samlServiceProvider monta o SP da organização a partir da configuração salva
+func samlServiceProvider(c domain.SAMLConfig) (*saml.ServiceProvider, error) {
+	idp, err := parseIdPMetadata(c.IdPMetadata)
+	if err != nil {
+		return nil, err
+	}
+	c = withSPInfo(c)
+	metadataURL, _ := url.Parse(c.MetadataURL)
+	acsURL, _ := url.Parse(c.ACSURL)
+	sp := &saml.ServiceProvider{
+		EntityID:          c.SPEntityID,
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idp,
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+		AllowIDPInitiated: false, // Apenas fluxos iniciados pelo SP (InResponseTo validado)
+	}
+	if key, cert := samlCredentials(); key != nil {
+		sp.Key, sp.Certificate = key, cert
+		sp.SignatureMethod = dsig.RSASHA256SignatureMethod
+	}
+	return sp, nil
+}
+
+// --- Configuração (owner da organização) ---
+
+// GetSAMLConfig retorna a configuração de SSO da organização (apenas owner)
+func (s *Service) GetSAMLConfig(orgID, userID string) (domain.SAMLConfig, error) {
+	if _, err := s.OrgMembership(orgID, userID, domain.OrgOwner); err != nil {
+		return domain.SAMLConfig{}, err
+	}
+	c, err := s.Repo.GetSAMLConfig(orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.SAMLConfig{}, errors.New("SSO não configurado")
+	}
+	if err != nil {
+		return domain.SAMLConfig{}, err
+	}
+	return withSPInfo(c), nil
+}
+
+// SaveSAMLConfig valida a metadata do IdP, os domínios e os mapeamentos e grava a configuração.
+// Domínios novos ficam pendentes até a verificação por DNS TXT ou a aprovação de um admin.
+func (s *Service) SaveSAMLConfig(orgID, userID string, c domain.SAMLConfig) (domain.SAMLConfig, error) {
+	if _, err := s.OrgMembership(orgID, userID, domain.OrgOwner); err != nil {
+		return domain.SAMLConfig{}, err
+	}
+	idp, err := parseIdPMetadata(c.IdPMetadata)
+	if err != nil {
+		return domain.SAMLConfig{}, err
+	}
+
+	domains := []string{}
+	seen := map[string]bool{}
+	for _, d := range c.EmailDomains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+		if d == "" || seen[d] {
+			continue
+		}
+		if !strings.Contains(d, ".") || strings.ContainsAny(d, "@ /") {
+			return domain.SAMLConfig{}, errors.New("domínio de email inválido: " + d)
+		}
+		owner, err := s.Repo.GetSAMLDomainOwner(d)
+		if err == nil && owner != orgID {
+			return domain.SAMLConfig{}, errors.New("domínio já utilizado por outra organização: " + d)
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return domain.SAMLConfig{}, err
+		}
+		token, err := generateSecureToken()
+		if err != nil {
+			return domain.SAMLConfig{}, err
+		}
+		seen[d] = true
+		domains = append(domains, d)
+		c.Domains = append(c.Domains, domain.SAMLDomain{Domain: d, Token: token})
+	}
+	if len(domains) == 0 {
+		return domain.SAMLConfig{}, errors.New("informe ao menos um domínio de email")
+	}
+
+	if c.DefaultRole == "" {
+		c.DefaultRole = domain.OrgRecruiter
+	}
+	if !samlAssignableRoles[c.DefaultRole] {
+		return domain.SAMLConfig{}, errors.New("papel inválido: use recruiter ou viewer")
+	}
+	for _, role := range c.RoleMapping {
+		if !samlAssignableRoles[role] {
+			return domain.SAMLConfig{}, errors.New("papel inválido: use recruiter ou viewer")
+		}
+	}
+
+	c.OrgID = orgID
+	c.IdPEntityID = idp.EntityID
+	c.EmailDomains = domains
+	c.UpdatedBy = userID
+	c.UpdatedAt = time.Now().UnixMilli()
+	if err := s.Repo.SaveSAMLConfig(c); err != nil {
+		return domain.SAMLConfig{}, err
+	}
+	// Relê para obter a situação de verificação preservada dos domínios mantidos
+	saved, err := s.Repo.GetSAMLConfig(orgID)
+	if err != nil {
+		return domain.SAMLConfig{}, err
+	}
+	return withSPInfo(saved), nil
+}
+
+// VerifySAMLDomain confirma a posse do domínio pelo registro DNS TXT publicado pela organização (apenas owner)
+func (s *Service) VerifySAMLDomain(orgID, userID, domainName string) (domain.SAMLConfig, error) {
+	if _, err := s.OrgMembership(orgID, userID, domain.OrgOwner); err != nil {
+		return domain.SAMLConfig{}, err
+	}
+	c, err := s.Repo.GetSAMLConfig(orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.SAMLConfig{}, errors.New("SSO não configurado")
+	}
+	if err != nil {
+		return domain.SAMLConfig{}, err
+	}
+	domainName = strings.ToLower(strings.TrimSpace(domainName))
+	var pending *domain.SAMLDomain
+	for i := range c.Domains {
+		if c.Domains[i].Domain == domainName {
+			pending = &c.Domains[i]
+		}
+	}
+	if pending == nil {
+		return domain.SAMLConfig{}, errors.New("domínio não encontrado")
+	}
+	if pending.Verified {
+		return withSPInfo(c), nil
+	}
+
+	records, err := lookupTXT(samlVerificationRecord + "." + domainName)
+	if err != nil {
+		logger.Debug("Consulta TXT de %s falhou: %v", domainName, err)
+	}
+	found := false
+	for _, rec := range records {
+		if pending.Token != "" && strings.TrimSpace(rec) == samlVerificationPrefix+pending.Token {
+			found = true
+		}
+	}
+	if !found {
+		return domain.SAMLConfig{}, errors.New("registro TXT de verificação não encontrado")
+	}
+	if err := s.Repo.VerifySAMLDomain(orgID, domainName, ""); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return domain.SAMLConfig{}, err
+	}
+	return s.GetSAMLConfig(orgID, userID)
+}
+
+// ApproveSAMLDomain verifica manualmente o domínio pendente da organização (uso do admin)
+func (s *Service) ApproveSAMLDomain(orgID, domainName, adminID string) error {
+	err := s.Repo.VerifySAMLDomain(orgID, strings.ToLower(strings.TrimSpace(domainName)), adminID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("domínio não encontrado")
+	}
+	return err
+}
+
+// DeleteSAMLConfig remove o SSO da organização (os usuários provisionados continuam membros)
+func (s *Service) DeleteSAMLConfig(orgID, userID string) error {
+	if _, err := s.OrgMembership(orgID, userID, domain.OrgOwner); err != nil {
+		return err
+	}
+	if err := s.Repo.DeleteSAMLConfig(orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("SSO não configurado")
+		}
+		return err
+	}
+	return nil
+}
+
+// --- Fluxo de login (público) ---
+
+// SAMLMetadata retorna o XML de metadata do SP da organização
+func (s *Service) SAMLMetadata(orgID string) ([]byte, error) {
+	c, err := s.Repo.GetSAMLConfig(orgID)
+	if err != nil {
+		return nil, errors.New("SSO não configurado")
+	}
+	sp, err := samlServiceProvider(c)
+	if err != nil {
+		return nil, err
+	}
+	md := sp.Metadata()
+	// Apenas o binding HTTP-POST é aceito no ACS
+	for i := range md.SPSSODescriptors {
+		acs := md.SPSSODescriptors[i].AssertionConsumerServices
+		filtered := acs[:0]
+		for _, e := range acs {
+			if e.Binding == saml.HTTPPostBinding {
+				filtered = append(filtered, e)
+			}
+		}
+		md.SPSSODescriptors[i].AssertionConsumerServices = filtered
+	}
+	return xml.MarshalIndent(md, "", "  ")
+}
+
+// DiscoverSSO indica a URL de login SSO para o email (vazio se o domínio não usa SSO ou ainda não foi verificado)
+func (s *Service) DiscoverSSO(email string) (string, error) {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return "", nil
+	}
+	orgID, err := s.Repo.GetSAMLDomainOwner(strings.ToLower(strings.TrimSpace(email[at+1:])))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	c, err := s.Repo.GetSAMLConfig(orgID)
+	if err != nil || !c.Enabled {
+		return "", nil
+	}
+	return withSPInfo(c).LoginURL, nil
+}
+
+// StartSAMLLogin emite o AuthnRequest e retorna a URL do IdP (binding HTTP-Redirect)
+func (s *Service) StartSAMLLogin(orgID string) (string, error) {
+	c, err := s.Repo.GetSAMLConfig(orgID)
+	if err != nil || !c.Enabled {
+		return "", errors.New("SSO não configurado")
+	}
+	sp, err := samlServiceProvider(c)
+	if err != nil {
+		return "", err
+	}
+	req, err := sp.MakeAuthenticationRequest(sp.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return "", err
+	}
+	relayState, err := generateSecureToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.Repo.CreateSAMLRequest(relayState, req.ID, orgID, time.Now().Add(samlRequestTTL)); err != nil {
+		return "", err
+	}
+	redirectURL, err := req.Redirect(relayState, sp)
+	if err != nil {
+		return "", err
+	}
+	return redirectURL.String(), nil
+}
+
+// HandleSAMLResponse valida a resposta do IdP (assinatura, emissor, audiência, validade e InResponseTo),
+// aplica o mapeamento de atributos e provisiona o usuário na organização (just-in-time).
+// Retorna o código de login trocado em /api/auth/oauth/exchange.
+func (s *Service) HandleSAMLResponse(orgID, samlResponse, relayState string) (OAuthCallbackResult, error) {
+	requestID, requestOrg, expiresAt, err := s.Repo.ConsumeSAMLRequest(relayState)
+	if err != nil || requestOrg != orgID || time.Now().After(expiresAt) {
+		return OAuthCallbackResult{}, errors.New("requisição SAML inválida ou expirada")
+	}
+	c, err := s.Repo.GetSAMLConfig(orgID)
+	if err != nil || !c.Enabled {
+		return OAuthCallbackResult{}, errors.New("SSO não configurado")
+	}
+	sp, err := samlServiceProvider(c)
+	if err != nil {
+		return OAuthCallbackResult{}, err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return OAuthCallbackResult{}, errors.New("resposta SAML inválida")
+	}
+	assertion, err := sp.ParseXMLResponse(raw, []string{requestID})
+	if err != nil {
+		var invalid *saml.InvalidResponseError
+		if errors.As(err, &invalid) {
+			err = invalid.PrivateErr
+		}
+		logger.Warn("Resposta SAML rejeitada (org %s): %v", orgID, err)
+		return OAuthCallbackResult{}, errors.New("resposta SAML inválida")
+	}
+
+	email, name, roleValues := samlAttributes(assertion, c.AttributeMapping)
+	email = strings.ToLower(email)
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return OAuthCallbackResult{}, errors.New("asserção SAML sem email")
+	}
+	// Domínios pendentes de verificação não autenticam nem provisionam usuários
+	if !samlDomainVerified(c, email[at+1:]) {
+		return OAuthCallbackResult{}, errors.New("email fora dos domínios da organização")
+	}
+	mappedRole := samlMappedRole(roleValues, c.RoleMapping)
+
+	userID, err := s.Repo.GetUserIDByEmailFold(email)
+	switch {
+	case err == nil:
+		// Contas existentes só entram pelo SSO se já forem membros da organização
+		role, err := s.Repo.GetOrgMemberRole(orgID, userID)
+		if err != nil {
+			return OAuthCallbackResult{}, err
+		}
+		if role == "" {
+			return OAuthCallbackResult{}, errors.New("conta existente não pertence à organização")
+		}
+		if mappedRole != "" && role != domain.OrgOwner && role != mappedRole {
+			if err := s.Repo.UpdateOrgMemberRole(orgID, userID, mappedRole); err != nil {
+				logger.Error("Erro ao atualizar papel SSO de %s: %v", userID, err)
+			}
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		created, err := s.provisionSAMLUser(orgID, email, name, mappedRole, c.DefaultRole)
+		if err != nil {
+			return OAuthCallbackResult{}, err
+		}
+		userID = created.ID
+		logger.Info("Usuário %s provisionado via SSO na organização %s", created.ID, orgID)
+	default:
+		return OAuthCallbackResult{}, err
+	}
+	return s.oauthLoginCode(userID)
+}
+
+// provisionSAMLUser cria a conta (email verificado pelo IdP, senha aleatória) como membro da organização.
+// Só é chamado para emails de domínios verificados pela organização.
+func (s *Service) provisionSAMLUser(orgID, email, name, mappedRole, defaultRole string) (domain.User, error) {
+	randomPassword, err := generateSecureToken()
+	if err != nil {
+		return domain.User{}, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if name == "" {
+		name = strings.Split(email, "@")[0]
+	}
+	role := mappedRole
+	if role == "" {
+		role = defaultRole
+	}
+	return s.Repo.CreateSAMLUser(domain.User{
+		Name:       name,
+		Email:      email,
+		Password:   string(hashed),
+		Role:       domain.RoleCompany,
+		Provider:   "saml",
+		IsVerified: true,
+	}, orgID, role)
+}
+
+// samlAttributes extrai email, nome e valores do atributo de papel da asserção
+func samlAttributes(a *saml.Assertion, m domain.SAMLAttributeMapping) (string, string, []string) {
+	values := map[string][]string{}
+	for _, st := range a.AttributeStatements {
+		for _, attr := range st.Attributes {
+			for _, v := range attr.Values {
+				values[attr.Name] = append(values[attr.Name], strings.TrimSpace(v.Value))
+				if attr.FriendlyName != "" {
+					values[attr.FriendlyName] = append(values[attr.FriendlyName], strings.TrimSpace(v.Value))
+				}
+			}
+		}
+	}
+	first := func(names []string) string {
+		for _, n := range names {
+			if v := values[n]; n != "" && len(v) > 0 && v[0] != "" {
+				return v[0]
+			}
+		}
+		return ""
+	}
+
+	email := first(append([]string{m.Email}, samlDefaultEmailAttrs...))
+	if email == "" && a.Subject != nil && a.Subject.NameID != nil && strings.Contains(a.Subject.NameID.Value, "@") {
+		email = strings.TrimSpace(a.Subject.NameID.Value)
+	}
+	name := first(append([]string{m.Name}, samlDefaultNameAttrs...))
+	var roles []string
+	if m.Role != "" {
+		roles = values[m.Role]
+	}
+	return email, name, roles
+}
+
+// samlMappedRole retorna o papel de maior nível entre os valores mapeados ("" se nenhum)
+func samlMappedRole(values []string, mapping map[string]string) string {
+	best := ""
+	for _, v := range values {
+		if role, ok := mapping[v]; ok && orgRank[role] > orgRank[best] {
+			best = role
+		}
+	}
+	return best
+}
@@ -17,17 +17,25 @@ import (
 )
 
 type Service struct {
-	Repo         *postgres.PostgresRepo
-	Config       *config.Config
-	EmailService *EmailService
+	Repo           *postgres.PostgresRepo
+	Exams          ExamAccessRepo // Regras de acesso e gestão de exames (o próprio Repo; substituível nos testes)
+	Config         *config.Config
+	EmailService   *EmailService
+	OAuthProviders map[string]*security.OAuthProvider // Login social configurado (google, github, microsoft)
+	JWTKeys        *security.JWTKeySet                // Chaves de assinatura/verificação dos access tokens
+	KeyCipher      *security.KeyCipher                // Cifra as chaves privadas JWT gravadas no banco (JWT_KEY_ENCRYPTION_KEY)
 }
 
 func NewService(repo *postgres.PostgresRepo, cfg *config.Config) *Service {
-	return &Service{
-		Repo:         repo,
-		Config:       cfg,
-		EmailService: NewEmailService(),
-	}
+	s := &Service{
+		Repo:           repo,
+		Exams:          repo,
+		Config:         cfg,
+		EmailService:   NewEmailService(),
+		OAuthProviders: loadOAuthProviders(),
+	}
+	s.JWTKeys = security.NewJWTKeySet(s.loadSigningKeys)
+	return s
 }
 
 // --- Auth Services ---
@@ -48,6 +56,10 @@ func (s *Service) RegisterUser(u domain.User) (domain.User, error) {
 	if err != nil {
 		return created, err
 	}
+	// Contas company nascem como organizações de um membro
+	if created.Role == domain.RoleCompany {
+		s.createCompanyOrganization(created)
+	}
 	
 	// Só enviar email de verificação se o usuário não estiver já verificado
 	if !created.IsVerified {
@@ -70,14 +82,16 @@ type LoginResponse struct {
 }
 
 // LoginUser autentica o usuário e retorna access token + refresh token
-func (s *Service) LoginUser(email, password string) (LoginResponse, string, error) {
+func (s *Service) LoginUser(email, password string, client ClientInfo) (LoginResponse, string, error) {
 	u, err := s.Repo.GetUserByEmail(email)
 	if err != nil {
+		spendPasswordCheck(password)
 		return LoginResponse{}, "", errors.New("usuário não encontrado")
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)); err != nil {
-		return LoginResponse{}, "", errors.New("senha incorreta")
+	// Senha com atraso progressivo e bloqueio temporário por conta
+	if err := s.verifyPassword(u, password); err != nil {
+		return LoginResponse{}, "", err
 	}
 
 	// Verificar se email está verificado - conforme contrato FRONTEND_CONTRACT_API.md
@@ -86,25 +100,40 @@ func (s *Service) LoginUser(email, password string) (LoginResponse, string, erro
 		return LoginResponse{}, "", errors.New("Email não verificado")
 	}
 
-	// Gerar Access Token (15 minutos) - conforme contrato v2.4.0
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": u.ID,
-		"role":    u.Role,
-		"exp":     time.Now().Add(15 * time.Minute).Unix(), // 15 minutos
-	})
-	
-	accessTokenString, err := accessToken.SignedString([]byte(s.Config.JWTSecret))
-	if err != nil {
+	return s.sessionOrChallenge(u, client)
+}
+
+// sessionOrChallenge emite a sessão do usuário já autenticado, ou o desafio de 2FA quando
+// o 2FA está ativo (ou é exigido pelo papel) e o login continua em /api/auth/2fa/verify
+func (s *Service) sessionOrChallenge(u domain.User, client ClientInfo) (LoginResponse, string, error) {
+	if challenge, err := s.twoFactorChallenge(u); err != nil {
 		return LoginResponse{}, "", err
+	} else if challenge != nil {
+		return LoginResponse{}, "", challenge
 	}
+	return s.issueSession(u, client)
+}
 
-	// Verificar limite de refresh tokens ativos (máximo 5 por usuário)
+// issueSession abre uma sessão (dispositivo) e gera access token e refresh token para o usuário já autenticado
+func (s *Service) issueSession(u domain.User, client ClientInfo) (LoginResponse, string, error) {
+	// Verificar limite de sessões ativas (máximo 5 por usuário)
 	activeCount, err := s.Repo.GetActiveRefreshTokensCount(u.ID)
 	if err == nil && activeCount >= 5 {
-		// Revogar tokens antigos, mantendo apenas os 4 mais recentes
+		// Revogar sessões antigas, mantendo apenas as 4 mais recentes
 		s.Repo.RevokeOldRefreshTokens(u.ID, 4)
 	}
-	
+
+	sessionID, err := s.Repo.CreateSession(u.ID, client.IP, client.UserAgent)
+	if err != nil {
+		return LoginResponse{}, "", fmt.Errorf("erro ao criar sessão: %w", err)
+	}
+
+	// Gerar Access Token (15 minutos) - conforme contrato v2.4.0
+	accessTokenString, err := s.signAccessToken(u, sessionID)
+	if err != nil {
+		return LoginResponse{}, "", err
+	}
+
 	// Gerar Refresh Token criptograficamente seguro (7 dias) - conforme contrato v2.4.0
 	refreshToken, err := generateSecureToken()
 	if err != nil {
@@ -113,7 +142,7 @@ func (s *Service) LoginUser(email, password string) (LoginResponse, string, erro
 	refreshExpiresAt := time.Now().Add(7 * 24 * time.Hour) // 7 dias
 	
 	// Armazenar refresh token no banco
-	if err := s.Repo.CreateToken(u.ID, refreshToken, "refresh_token", refreshExpiresAt); err != nil {
+	if err := s.Repo.CreateSessionRefreshToken(sessionID, u.ID, refreshToken, refreshExpiresAt); err != nil {
 		return LoginResponse{}, "", fmt.Errorf("erro ao criar refresh token: %w", err)
 	}
 
@@ -138,6 +167,16 @@ func (s *Service) LoginUser(email, password string) (LoginResponse, string, erro
 	}, refreshToken, nil
 }
 
+// signAccessToken gera o access token (15 minutos) com o id da sessão (sid), assinado pela chave ativa (kid)
+func (s *Service) signAccessToken(u domain.User, sessionID string) (string, error) {
+	return s.JWTKeys.Sign(jwt.MapClaims{
+		"user_id": u.ID,
+		"role":    u.Role,
+		"sid":     sessionID,
+		"exp":     time.Now().Add(15 * time.Minute).Unix(), // 15 minutos
+	})
+}
+
 // RefreshAccessToken gera um novo access token a partir de um refresh token válido
 // Implementa rotação de refresh tokens e detecção de reutilização
 func (s *Service) RefreshAccessToken(refreshToken string) (string, string, error) {
@@ -172,14 +211,22 @@ func (s *Service) RefreshAccessToken(refreshToken string) (string, string, error
 		return "", "", errors.New("usuário não encontrado")
 	}
 
-	// Gerar novo Access Token (15 minutos)
-	newAccessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"role":    user.Role,
-		"exp":     time.Now().Add(15 * time.Minute).Unix(),
-	})
+	// O novo refresh token continua na mesma sessão
+	sessionID, err := s.Repo.GetRefreshTokenSessionID(refreshToken)
+	if err != nil {
+		return "", "", errors.New("refresh token inválido")
+	}
+	if sessionID == "" {
+		// Token anterior às sessões: abre a sessão agora (sem IP/dispositivo do login)
+		if sessionID, err = s.Repo.CreateSession(userID, "", ""); err != nil {
+			return "", "", fmt.Errorf("erro ao criar sessão: %w", err)
+		}
+	} else {
+		s.Repo.TouchSession(sessionID)
+	}
 
-	accessTokenString, err := newAccessToken.SignedString([]byte(s.Config.JWTSecret))
+	// Gerar novo Access Token (15 minutos)
+	accessTokenString, err := s.signAccessToken(user, sessionID)
 	if err != nil {
 		return "", "", err
 	}
@@ -192,7 +239,7 @@ func (s *Service) RefreshAccessToken(refreshToken string) (string, string, error
 	newRefreshExpiresAt := time.Now().Add(7 * 24 * time.Hour)
 	
 	// Armazenar novo refresh token
-	if err := s.Repo.CreateToken(userID, newRefreshToken, "refresh_token", newRefreshExpiresAt); err != nil {
+	if err := s.Repo.CreateSessionRefreshToken(sessionID, userID, newRefreshToken, newRefreshExpiresAt); err != nil {
 		return "", "", fmt.Errorf("erro ao criar novo refresh token: %w", err)
 	}
 
@@ -236,11 +283,27 @@ func (s *Service) GetSanitizedExam(token string) (domain.Exam, domain.PublicLink
 	if err != nil {
 		return domain.Exam{}, domain.PublicLink{}, errors.New("prova não encontrada")
 	}
+	if err := examOpenForLinks(exam); err != nil {
+		return domain.Exam{}, domain.PublicLink{}, err
+	}
+	// Fora da janela agendada (antes da abertura retorna *AvailabilityError com a contagem regressiva)
+	if err := checkAvailability(exam, link, time.Now(), 0); err != nil {
+		return domain.Exam{}, domain.PublicLink{}, err
+	}
+	// Dados internos da empresa não são expostos ao candidato
+	link.CompanyID = ""
+	link.OrgID = ""
 
 	// Calcular isVerified baseado nas questões (antes de sanitizar, mas após buscar)
 	// Nota: isVerified é calculado antes de sanitizar para manter a informação
 	exam.IsVerified = calculateExamIsVerified(exam)
 
+	// Exames adaptativos não expõem o banco de questões: as questões são servidas
+	// uma a uma pela sessão adaptativa
+	if exam.Adaptive != nil && exam.Adaptive.Enabled {
+		exam.Questions = []domain.Question{}
+	}
+
 	// Sanitização Crítica: Remover gabarito
 	for i := range exam.Questions {
 		exam.Questions[i].CorrectIndex = -1
@@ -264,6 +327,42 @@ func calculateExamIsVerified(exam domain.Exam) bool {
 	return true
 }
 
+// SubmittedAnswers converte as respostas enviadas (array ou mapa de {questionId, selectedIndex}) para o formato de CalculateScore
+func SubmittedAnswers(v any) []map[string]interface{} {
+	var answers []map[string]interface{}
+	if answersMap, ok := v.(map[string]interface{}); ok {
+		// Se for um mapa, converter para array
+		for _, a := range answersMap {
+			if answerMap, ok := a.(map[string]interface{}); ok {
+				answers = append(answers, answerMap)
+			}
+		}
+	} else if answersArray, ok := v.([]interface{}); ok {
+		for _, a := range answersArray {
+			if answerMap, ok := a.(map[string]interface{}); ok {
+				answers = append(answers, answerMap)
+			}
+		}
+	}
+	return answers
+}
+
+// ExamAnswers mantém apenas as respostas enviadas para questões do exame. As respostas gravadas alimentam
+// answer_facts, o caderno de erros e a calibração TRI; IDs de outras questões são descartados.
+func ExamAnswers(exam domain.Exam, v any) []map[string]interface{} {
+	inExam := make(map[string]bool, len(exam.Questions))
+	for _, q := range exam.Questions {
+		inExam[q.ID] = true
+	}
+	answers := []map[string]interface{}{}
+	for _, a := range SubmittedAnswers(v) {
+		if id, ok := a["questionId"].(string); ok && inExam[id] {
+			answers = append(answers, a)
+		}
+	}
+	return answers
+}
+
 // CalculateScore calcula a nota comparando respostas com gabarito do exame
 func (s *Service) CalculateScore(exam domain.Exam, answers []map[string]interface{}) (int, int) {
 	correctCount := 0
@@ -275,7 +374,8 @@ func (s *Service) CalculateScore(exam domain.Exam, answers []map[string]interfac
 		questionMap[q.ID] = q
 	}
 	
-	// Comparar cada resposta com o gabarito
+	// Comparar cada resposta com o gabarito (cada questão conta uma única vez)
+	answered := make(map[string]bool)
 	for _, answer := range answers {
 		questionID, ok1 := answer["questionId"].(string)
 		selectedIndex, ok2 := answer["selectedIndex"].(float64)
@@ -285,9 +385,10 @@ func (s *Service) CalculateScore(exam domain.Exam, answers []map[string]interfac
 		}
 		
 		question, exists := questionMap[questionID]
-		if !exists {
+		if !exists || answered[questionID] {
 			continue
 		}
+		answered[questionID] = true
 		
 		// Verificar se resposta está correta
 		if int(selectedIndex) == question.CorrectIndex {
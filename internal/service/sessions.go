@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"esimulate-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// ClientInfo identifica o dispositivo que abriu a sessão (exibido em /api/me/sessions)
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// GetSessions lista as sessões ativas do usuário, marcando a sessão da requisição
+func (s *Service) GetSessions(userID, currentSessionID string) ([]domain.Session, error) {
+	if _, err := s.Repo.GetUserByID(userID); err != nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	sessions, err := s.Repo.GetUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession encerra uma sessão do usuário; o access token dela deve ser bloqueado pelo chamador
+func (s *Service) RevokeSession(userID, sessionID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return errors.New("sessão não encontrada")
+	}
+	found, err := s.Repo.DeleteSession(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("sessão não encontrada")
+	}
+	return nil
+}
+
+// RevokeAllSessions encerra todas as sessões do usuário ("sair de todos os dispositivos")
+// e retorna os ids revogados para bloqueio dos access tokens
+func (s *Service) RevokeAllSessions(userID string) ([]string, error) {
+	if _, err := s.Repo.GetUserByID(userID); err != nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	return s.Repo.DeleteUserSessions(userID)
+}
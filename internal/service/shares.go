@@ -0,0 +1,204 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- Compartilhamento de exames ---
+
+// GetExamShares lista as concessões do exame (apenas owner ou admin)
+func (s *Service) GetExamShares(examID, userID, role string) ([]domain.ExamShare, error) {
+	exam, err := s.Exams.GetExamByID(examID)
+	if err != nil || exam.DeletedAt > 0 {
+		return nil, errors.New("prova não encontrada")
+	}
+	if !s.CanModifyExam(exam, userID, role) {
+		return nil, errors.New("acesso negado")
+	}
+	return s.Repo.GetExamShares(examID)
+}
+
+// ShareExam concede acesso ao exame para um usuário (por email) ou grupo; repetir altera o papel.
+// Email sem conta ou do próprio dono geram o mesmo erro, para não revelar quais emails têm conta.
+func (s *Service) ShareExam(examID, userID, role, email, groupID, shareRole string) (domain.ExamShare, error) {
+	if _, ok := shareRank[shareRole]; !ok {
+		return domain.ExamShare{}, errors.New("papel inválido (use viewer, editor ou owner)")
+	}
+	email = strings.TrimSpace(email)
+	if (email == "") == (groupID == "") {
+		return domain.ExamShare{}, errors.New("informe email ou groupId")
+	}
+	exam, err := s.Exams.GetExamByID(examID)
+	if err != nil || exam.DeletedAt > 0 {
+		return domain.ExamShare{}, errors.New("prova não encontrada")
+	}
+	if !s.CanModifyExam(exam, userID, role) {
+		return domain.ExamShare{}, errors.New("acesso negado")
+	}
+
+	share := domain.ExamShare{
+		ID:        uuid.New().String(),
+		ExamID:    examID,
+		Role:      shareRole,
+		GrantedBy: userID,
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	if email != "" {
+		u, err := s.Repo.GetUserByEmail(email)
+		if err != nil || u.ID == exam.CreatedBy {
+			return domain.ExamShare{}, errors.New("compartilhamento não permitido")
+		}
+		share.UserID, share.UserName, share.UserEmail = u.ID, u.Name, u.Email
+	} else {
+		g, err := s.Repo.GetGroupByID(groupID)
+		if err != nil {
+			return domain.ExamShare{}, errors.New("grupo não encontrado")
+		}
+		// Só é possível compartilhar com grupos dos quais se faz parte
+		if member, _ := s.Repo.IsGroupMember(g.ID, userID); !member && role != "admin" {
+			return domain.ExamShare{}, errors.New("grupo não encontrado")
+		}
+		share.GroupID, share.GroupName = g.ID, g.Name
+	}
+
+	id, err := s.Repo.UpsertExamShare(share)
+	if err != nil {
+		return domain.ExamShare{}, err
+	}
+	share.ID = id
+	return share, nil
+}
+
+// RevokeExamShare remove uma concessão. Owners e admins revogam qualquer uma;
+// o próprio usuário pode abrir mão do acesso concedido a ele.
+func (s *Service) RevokeExamShare(examID, shareID, userID, role string) error {
+	exam, err := s.Exams.GetExamByID(examID)
+	if err != nil {
+		return errors.New("prova não encontrada")
+	}
+	share, err := s.Repo.GetExamShare(examID, shareID)
+	if err != nil {
+		return errors.New("compartilhamento não encontrado")
+	}
+	if share.UserID != userID && !s.CanModifyExam(exam, userID, role) {
+		return errors.New("acesso negado")
+	}
+	if err := s.Repo.DeleteExamShare(examID, shareID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("compartilhamento não encontrado")
+		}
+		return err
+	}
+	return nil
+}
+
+// --- Grupos de usuários ---
+
+// CreateGroup cria um grupo do usuário, opcionalmente já com membros (por email).
+// Emails sem conta geram um erro genérico, sem indicar qual deles falhou.
+func (s *Service) CreateGroup(ownerID, name string, emails []string) (domain.UserGroup, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return domain.UserGroup{}, errors.New("nome do grupo é obrigatório")
+	}
+	members := []string{}
+	for _, email := range emails {
+		u, err := s.Repo.GetUserByEmail(strings.TrimSpace(email))
+		if err != nil {
+			return domain.UserGroup{}, errors.New("inclusão no grupo não permitida")
+		}
+		members = append(members, u.ID)
+	}
+	g := domain.UserGroup{ID: uuid.New().String(), Name: name, OwnerID: ownerID, CreatedAt: time.Now().UnixMilli()}
+	if err := s.Repo.CreateGroup(g); err != nil {
+		return domain.UserGroup{}, err
+	}
+	for _, id := range members {
+		if err := s.Repo.AddGroupMember(g.ID, id); err != nil {
+			return domain.UserGroup{}, err
+		}
+	}
+	return s.getUserGroup(ownerID, g.ID)
+}
+
+// getUserGroup retorna o grupo com membros, se visível ao usuário
+func (s *Service) getUserGroup(userID, groupID string) (domain.UserGroup, error) {
+	groups, err := s.Repo.GetUserGroups(userID)
+	if err != nil {
+		return domain.UserGroup{}, err
+	}
+	for _, g := range groups {
+		if g.ID == groupID {
+			return g, nil
+		}
+	}
+	return domain.UserGroup{}, errors.New("grupo não encontrado")
+}
+
+// ownedGroup carrega o grupo e exige que o usuário seja o dono (ou admin)
+func (s *Service) ownedGroup(groupID, userID, role string) (domain.UserGroup, error) {
+	g, err := s.Repo.GetGroupByID(groupID)
+	if err != nil {
+		return domain.UserGroup{}, errors.New("grupo não encontrado")
+	}
+	if g.OwnerID != userID && role != "admin" {
+		return domain.UserGroup{}, errors.New("acesso negado")
+	}
+	return g, nil
+}
+
+// AddGroupMember inclui um usuário (por email) no grupo; apenas o dono do grupo.
+// Email sem conta gera o mesmo erro genérico de CreateGroup.
+func (s *Service) AddGroupMember(groupID, userID, role, email string) (domain.UserGroup, error) {
+	g, err := s.ownedGroup(groupID, userID, role)
+	if err != nil {
+		return domain.UserGroup{}, err
+	}
+	u, err := s.Repo.GetUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return domain.UserGroup{}, errors.New("inclusão no grupo não permitida")
+	}
+	if err := s.Repo.AddGroupMember(g.ID, u.ID); err != nil {
+		return domain.UserGroup{}, err
+	}
+	return s.getUserGroup(g.OwnerID, g.ID)
+}
+
+// RemoveGroupMember remove um membro; o dono remove qualquer um e cada membro pode sair
+func (s *Service) RemoveGroupMember(groupID, memberID, userID, role string) error {
+	g, err := s.Repo.GetGroupByID(groupID)
+	if err != nil {
+		return errors.New("grupo não encontrado")
+	}
+	if memberID != userID && g.OwnerID != userID && role != "admin" {
+		return errors.New("acesso negado")
+	}
+	if memberID == g.OwnerID {
+		return errors.New("o dono não pode sair do grupo")
+	}
+	if err := s.Repo.RemoveGroupMember(groupID, memberID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("membro não encontrado")
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteGroup remove o grupo e os acessos concedidos a ele
+func (s *Service) DeleteGroup(groupID, userID, role string) error {
+	if _, err := s.ownedGroup(groupID, userID, role); err != nil {
+		return err
+	}
+	err := s.Repo.DeleteGroup(groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("grupo não encontrado")
+	}
+	return err
+}
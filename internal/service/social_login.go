@@ -0,0 +1,265 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/logger"
+	"esimulate-backend/internal/security"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Validades do fluxo de login social
+const (
+	oauthStateTTL       = 10 * time.Minute // Tempo para o usuário autorizar no provedor
+	oauthLoginCodeTTL   = 2 * time.Minute  // Código entregue ao frontend para buscar os tokens
+	oauthLinkRequestTTL = 15 * time.Minute // Tempo para confirmar o vínculo com a conta existente
+)
+
+// OAuthCallbackResult é o desfecho do retorno do provedor: login direto (LoginCode)
+// ou vínculo com uma conta existente aguardando confirmação (LinkToken)
+type OAuthCallbackResult struct {
+	LoginCode string
+	LinkToken string
+	Email     string
+}
+
+// loadOAuthProviders monta os provedores configurados por variáveis de ambiente.
+// Um provedor fica ativo quando OAUTH_<NOME>_CLIENT_ID está definido; o emissor OIDC pode ser
+// sobrescrito por OAUTH_<NOME>_ISSUER (ex.: emissor local em desenvolvimento).
+func loadOAuthProviders() map[string]*security.OAuthProvider {
+	providers := make(map[string]*security.OAuthProvider)
+	add := func(p *security.OAuthProvider) {
+		prefix := "OAUTH_" + strings.ToUpper(p.Name) + "_"
+		p.ClientID = getEnv(prefix+"CLIENT_ID", "")
+		p.ClientSecret = getEnv(prefix+"CLIENT_SECRET", "")
+		if p.Issuer != "" {
+			p.Issuer = getEnv(prefix+"ISSUER", p.Issuer)
+		}
+		if p.ClientID != "" {
+			providers[p.Name] = p
+		}
+	}
+
+	add(&security.OAuthProvider{
+		Name:   "google",
+		Issuer: "https://accounts.google.com",
+		Scopes: []string{"openid", "email", "profile"},
+	})
+	add(&security.OAuthProvider{
+		Name:   "microsoft",
+		Issuer: "https://login.microsoftonline.com/" + getEnv("OAUTH_MICROSOFT_TENANT", "common") + "/v2.0",
+		Scopes: []string{"openid", "email", "profile"},
+	})
+	add(&security.OAuthProvider{
+		Name:        "github",
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserInfoURL: "https://api.github.com/user",
+		EmailsURL:   "https://api.github.com/user/emails",
+		Scopes:      []string{"read:user", "user:email"},
+	})
+	return providers
+}
+
+// OAuthFrontendURL é a rota do frontend que recebe o desfecho do login social
+func OAuthFrontendURL(q url.Values) string {
+	return getEnv("APP_URL", "http://localhost:3000") + "/#/oauth/callback?" + q.Encode()
+}
+
+// oauthRedirectURI é a URL de retorno registrada no provedor (API_URL + callback)
+func oauthRedirectURI(provider string) string {
+	return strings.TrimSuffix(getEnv("API_URL", "http://localhost:8080"), "/") + "/api/auth/oauth/" + provider + "/callback"
+}
+
+// OAuthProviderNames lista os provedores sociais configurados
+func (s *Service) OAuthProviderNames() []string {
+	names := make([]string, 0, len(s.OAuthProviders))
+	for name := range s.OAuthProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartOAuthLogin registra state, nonce e code_verifier e retorna a URL de autorização do provedor
+func (s *Service) StartOAuthLogin(provider string) (string, error) {
+	p, ok := s.OAuthProviders[provider]
+	if !ok {
+		return "", errors.New("provedor não suportado")
+	}
+	state, err := generateSecureToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := generateSecureToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := security.GeneratePKCE()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := p.AuthorizationURL(state, nonce, challenge, oauthRedirectURI(provider))
+	if err != nil {
+		return "", err
+	}
+	s.Repo.DeleteExpiredOAuthStates()
+	if err := s.Repo.CreateOAuthState(state, provider, verifier, nonce, time.Now().Add(oauthStateTTL)); err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// HandleOAuthCallback valida o state, troca o código e resolve a conta local:
+// identidade já vinculada -> login; email de conta existente -> vínculo pendente; senão cria a conta.
+func (s *Service) HandleOAuthCallback(provider, code, state string) (OAuthCallbackResult, error) {
+	p, ok := s.OAuthProviders[provider]
+	if !ok {
+		return OAuthCallbackResult{}, errors.New("provedor não suportado")
+	}
+	stateProvider, verifier, nonce, expiresAt, err := s.Repo.ConsumeOAuthState(state)
+	if err != nil || stateProvider != provider || time.Now().After(expiresAt) {
+		return OAuthCallbackResult{}, errors.New("state inválido ou expirado")
+	}
+
+	identity, err := p.Exchange(code, verifier, oauthRedirectURI(provider), nonce)
+	if err != nil {
+		logger.Warn("Login social %s falhou: %v", provider, err)
+		return OAuthCallbackResult{}, errors.New("falha na autenticação com o provedor")
+	}
+
+	// Identidade já vinculada
+	userID, err := s.Repo.GetUserIDByOAuthIdentity(provider, identity.Subject)
+	if err == nil {
+		s.Repo.TouchOAuthIdentity(provider, identity.Subject)
+		return s.oauthLoginCode(userID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return OAuthCallbackResult{}, err
+	}
+
+	// A verificação do email é delegada ao provedor; sem ela não é possível criar nem vincular contas
+	email := strings.TrimSpace(identity.Email)
+	if email == "" || !identity.EmailVerified {
+		return OAuthCallbackResult{}, errors.New("email não verificado pelo provedor")
+	}
+
+	// Email de conta existente: o vínculo só é feito após confirmação do dono da conta
+	existingID, err := s.Repo.GetUserIDByEmailFold(email)
+	if err == nil {
+		token, err := generateSecureToken()
+		if err != nil {
+			return OAuthCallbackResult{}, err
+		}
+		req := domain.OAuthLinkRequest{
+			Token:     token,
+			UserID:    existingID,
+			Provider:  provider,
+			Subject:   identity.Subject,
+			Email:     email,
+			ExpiresAt: time.Now().Add(oauthLinkRequestTTL).UnixMilli(),
+		}
+		if err := s.Repo.CreateOAuthLinkRequest(req); err != nil {
+			return OAuthCallbackResult{}, err
+		}
+		return OAuthCallbackResult{LinkToken: token, Email: email}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return OAuthCallbackResult{}, err
+	}
+
+	// Nova conta: email já verificado e senha aleatória (pode ser definida depois em "esqueci minha senha")
+	randomPassword, err := generateSecureToken()
+	if err != nil {
+		return OAuthCallbackResult{}, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return OAuthCallbackResult{}, err
+	}
+	name := strings.TrimSpace(identity.Name)
+	if name == "" {
+		name = strings.Split(email, "@")[0]
+	}
+	created, err := s.Repo.CreateOAuthUser(domain.User{
+		Name:       name,
+		Email:      email,
+		Password:   string(hashed),
+		Role:       domain.RoleUser,
+		Provider:   provider,
+		IsVerified: true,
+	}, identity.Subject)
+	if err != nil {
+		return OAuthCallbackResult{}, err
+	}
+	return s.oauthLoginCode(created.ID)
+}
+
+// oauthLoginCode cria o código de uso único que o frontend troca pelos tokens
+func (s *Service) oauthLoginCode(userID string) (OAuthCallbackResult, error) {
+	code, err := generateSecureToken()
+	if err != nil {
+		return OAuthCallbackResult{}, err
+	}
+	if err := s.Repo.CreateToken(userID, code, "oauth_login", time.Now().Add(oauthLoginCodeTTL)); err != nil {
+		return OAuthCallbackResult{}, err
+	}
+	return OAuthCallbackResult{LoginCode: code}, nil
+}
+
+// ExchangeOAuthLoginCode troca o código do callback pelo access token e refresh token (ou desafio de 2FA)
+func (s *Service) ExchangeOAuthLoginCode(code string, client ClientInfo) (LoginResponse, string, error) {
+	userID, expiresAt, err := s.Repo.ConsumeToken(code, "oauth_login")
+	if err != nil || time.Now().After(expiresAt) {
+		return LoginResponse{}, "", errors.New("código inválido ou expirado")
+	}
+	u, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return LoginResponse{}, "", errors.New("usuário não encontrado")
+	}
+	return s.sessionOrChallenge(u, client)
+}
+
+// oauthLinkRequest retorna o vínculo pendente ainda válido
+func (s *Service) oauthLinkRequest(linkToken string) (domain.OAuthLinkRequest, error) {
+	req, err := s.Repo.GetOAuthLinkRequest(linkToken)
+	if err != nil || time.Now().UnixMilli() > req.ExpiresAt {
+		return domain.OAuthLinkRequest{}, errors.New("vínculo inválido ou expirado")
+	}
+	return req, nil
+}
+
+// ConfirmOAuthLink vincula a identidade social à conta existente após o usuário confirmar com a senha da conta
+func (s *Service) ConfirmOAuthLink(linkToken, password string, client ClientInfo) (LoginResponse, string, error) {
+	req, err := s.oauthLinkRequest(linkToken)
+	if err != nil {
+		return LoginResponse{}, "", err
+	}
+	u, err := s.Repo.GetUserByID(req.UserID)
+	if err != nil {
+		return LoginResponse{}, "", errors.New("vínculo inválido ou expirado")
+	}
+	if err := s.verifyPassword(u, password); err != nil {
+		return LoginResponse{}, "", err
+	}
+
+	if err := s.Repo.CreateOAuthIdentity(u.ID, req.Provider, req.Subject, req.Email); err != nil {
+		return LoginResponse{}, "", errors.New("esta conta já possui outro vínculo com o provedor")
+	}
+	s.Repo.DeleteOAuthLinkRequest(linkToken)
+
+	// O provedor confirmou a posse do email
+	if !u.IsVerified {
+		if err := s.Repo.UpdateUser(u.ID, map[string]interface{}{"is_verified": true}); err == nil {
+			u.IsVerified = true
+		}
+	}
+	return s.sessionOrChallenge(u, client)
+}
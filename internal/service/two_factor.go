@@ -0,0 +1,381 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"esimulate-backend/internal/domain"
+	"esimulate-backend/internal/logger"
+	"esimulate-backend/internal/security"
+	"math/big"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// twoFactorChallengeTTL é a validade do desafio entre a senha e o código
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// recoveryCodeCount é a quantidade de códigos de recuperação gerados por vez
+const recoveryCodeCount = 10
+
+// twoFactorChallengeMaxFailures é o número de códigos incorretos que invalida o desafio
+const twoFactorChallengeMaxFailures = 5
+
+// TwoFactorChallenge é a resposta do primeiro passo do login quando o 2FA é necessário.
+// Implementa error para que LoginUser interrompa a emissão dos tokens.
+type TwoFactorChallenge struct {
+	TwoFactorRequired  bool   `json:"twoFactorRequired"`
+	EnrollmentRequired bool   `json:"enrollmentRequired,omitempty"` // O papel exige 2FA e o usuário ainda não configurou
+	ChallengeToken     string `json:"challengeToken"`
+	ExpiresIn          int    `json:"expiresIn"` // Segundos
+}
+
+func (c *TwoFactorChallenge) Error() string {
+	return "autenticação em dois fatores necessária"
+}
+
+// TOTPSetup é retornado no cadastro do autenticador (o frontend exibe o URI como QR code)
+type TOTPSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// TwoFactorLoginResponse é a resposta do segundo passo; recoveryCodes só vem no cadastro durante o login
+type TwoFactorLoginResponse struct {
+	LoginResponse
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// twoFactorChallenge cria um desafio quando o usuário tem 2FA ativo ou o papel exige 2FA (nil caso contrário)
+func (s *Service) twoFactorChallenge(u domain.User) (*TwoFactorChallenge, error) {
+	_, enabled, err := s.Repo.GetTOTPState(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.Repo.IsTwoFactorRequired(string(u.Role))
+	if err != nil {
+		return nil, err
+	}
+	if !enabled && !required {
+		return nil, nil
+	}
+	token, err := generateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateToken(u.ID, token, "2fa_challenge", time.Now().Add(twoFactorChallengeTTL)); err != nil {
+		return nil, err
+	}
+	return &TwoFactorChallenge{
+		TwoFactorRequired:  true,
+		EnrollmentRequired: !enabled,
+		ChallengeToken:     token,
+		ExpiresIn:          int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// challengeUser valida o desafio (sem consumi-lo) e retorna o usuário
+func (s *Service) challengeUser(challengeToken string) (domain.User, error) {
+	userID, tokenType, expiresAt, used, err := s.Repo.GetToken(challengeToken)
+	if err != nil || tokenType != "2fa_challenge" || used || time.Now().After(expiresAt) {
+		return domain.User{}, errors.New("desafio inválido ou expirado")
+	}
+	u, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return domain.User{}, errors.New("desafio inválido ou expirado")
+	}
+	return u, nil
+}
+
+// newTOTPSetup gera e grava um segredo pendente para o usuário
+func (s *Service) newTOTPSetup(u domain.User) (TOTPSetup, error) {
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+	if err := s.Repo.SetPendingTOTPSecret(u.ID, secret); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TOTPSetup{}, errors.New("2FA já está ativo")
+		}
+		return TOTPSetup{}, err
+	}
+	issuer := getEnv("TOTP_ISSUER", "eSimulate")
+	return TOTPSetup{Secret: secret, ProvisioningURI: security.TOTPProvisioningURI(secret, issuer, u.Email)}, nil
+}
+
+// checkTOTP valida o código e consome o passo (um código não pode ser usado duas vezes)
+func (s *Service) checkTOTP(userID, secret, code string) (int64, error) {
+	step, ok := security.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return 0, errors.New("código inválido")
+	}
+	if err := s.Repo.ConsumeTOTPStep(userID, step); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("código inválido")
+		}
+		return 0, err
+	}
+	return step, nil
+}
+
+// verifySecondFactor aceita um código TOTP ou um código de recuperação (que é consumido)
+func (s *Service) verifySecondFactor(userID, code string) error {
+	secret, enabled, err := s.Repo.GetTOTPState(userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return errors.New("2FA não está ativo")
+	}
+	if _, err := s.checkTOTP(userID, secret, code); err == nil {
+		return nil
+	}
+	ok, err := s.Repo.UseRecoveryCode(userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("código inválido")
+	}
+	return nil
+}
+
+// newRecoveryCodes gera os códigos de recuperação (exibidos uma única vez) e seus hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	max := big.NewInt(int64(len(certificateAlphabet)))
+	for i := range codes {
+		var sb strings.Builder
+		for j := 0; j < 10; j++ {
+			if j == 5 {
+				sb.WriteByte('-')
+			}
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, nil, err
+			}
+			sb.WriteByte(certificateAlphabet[n.Int64()])
+		}
+		codes[i] = sb.String()
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normaliza (maiúsculas, sem hífens/espaços) e calcula o SHA-256 do código
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// --- Login em dois passos ---
+
+// StartTwoFactorEnrollment inicia o cadastro do autenticador durante o login (papel exige 2FA)
+func (s *Service) StartTwoFactorEnrollment(challengeToken string) (TOTPSetup, error) {
+	u, err := s.challengeUser(challengeToken)
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+	return s.newTOTPSetup(u)
+}
+
+// CompleteTwoFactorLogin conclui o login com o código TOTP ou de recuperação.
+// Se o usuário está cadastrando o autenticador neste login, o código ativa o 2FA e os códigos de recuperação são retornados.
+// O desafio é reservado antes da verificação (requisições concorrentes não testam códigos em paralelo).
+// Cada código incorreto conta para o desafio, invalidado após twoFactorChallengeMaxFailures, e para o bloqueio da conta.
+func (s *Service) CompleteTwoFactorLogin(challengeToken, code string, client ClientInfo) (TwoFactorLoginResponse, string, error) {
+	userID, err := s.Repo.ClaimTwoFactorChallenge(challengeToken, twoFactorChallengeMaxFailures)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			logger.Error("Erro ao reservar desafio de 2FA: %v", err)
+		}
+		return TwoFactorLoginResponse{}, "", errors.New("desafio inválido ou expirado")
+	}
+	u, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		s.Repo.MarkTokenAsUsed(challengeToken)
+		return TwoFactorLoginResponse{}, "", errors.New("desafio inválido ou expirado")
+	}
+	// A conta pode ter sido bloqueada depois da senha (falhas em outros desafios)
+	if lockout, err := s.Repo.GetLoginLockout(u.ID); err != nil || lockout.LockedUntil > time.Now().UnixMilli() {
+		s.Repo.MarkTokenAsUsed(challengeToken)
+		return TwoFactorLoginResponse{}, "", errors.New("desafio inválido ou expirado")
+	}
+	secret, enabled, err := s.Repo.GetTOTPState(u.ID)
+	if err != nil {
+		s.releaseTwoFactorChallenge(challengeToken, u, false)
+		return TwoFactorLoginResponse{}, "", err
+	}
+
+	var recoveryCodes []string
+	switch {
+	case enabled:
+		err = s.verifySecondFactor(u.ID, code)
+	case secret != "":
+		recoveryCodes, err = s.activateTOTP(u.ID, secret, code)
+	default:
+		err = errors.New("configure o autenticador antes de continuar")
+	}
+	if err != nil {
+		s.releaseTwoFactorChallenge(challengeToken, u, err.Error() == "código inválido")
+		return TwoFactorLoginResponse{}, "", err
+	}
+
+	if err := s.Repo.MarkTokenAsUsed(challengeToken); err != nil {
+		logger.Error("Erro ao excluir desafio de 2FA: %v", err)
+	}
+	s.Repo.ClearLoginFailures(u.ID)
+	resp, refreshToken, err := s.issueSession(u, client)
+	if err != nil {
+		return TwoFactorLoginResponse{}, "", err
+	}
+	return TwoFactorLoginResponse{LoginResponse: resp, RecoveryCodes: recoveryCodes}, refreshToken, nil
+}
+
+// releaseTwoFactorChallenge devolve o desafio reservado após uma verificação recusada.
+// Códigos incorretos contam como falha do desafio e da conta (bloqueio de login).
+func (s *Service) releaseTwoFactorChallenge(challengeToken string, u domain.User, wrongCode bool) {
+	if !wrongCode {
+		if err := s.Repo.ReleaseTwoFactorChallenge(challengeToken); err != nil {
+			logger.Error("Erro ao liberar desafio de 2FA: %v", err)
+		}
+		return
+	}
+	failures, err := s.Repo.FailTwoFactorChallenge(challengeToken, twoFactorChallengeMaxFailures)
+	if err != nil {
+		logger.Error("Erro ao registrar falha no desafio de 2FA: %v", err)
+	} else if failures >= twoFactorChallengeMaxFailures {
+		logger.Warn("[SECURITY] Desafio de 2FA de %s invalidado após %d códigos incorretos", u.ID, failures)
+	}
+	s.recordLoginFailure(u)
+}
+
+// activateTOTP confirma o segredo pendente com o primeiro código e gera os códigos de recuperação
+func (s *Service) activateTOTP(userID, secret, code string) ([]string, error) {
+	step, ok := security.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, errors.New("código inválido")
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.EnableTOTP(userID, step, hashes); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("2FA já está ativo")
+		}
+		return nil, err
+	}
+	return codes, nil
+}
+
+// --- Gerenciamento pelo usuário autenticado ---
+
+// GetTwoFactorStatus retorna se o 2FA está ativo, se é exigido e quantos códigos de recuperação restam
+func (s *Service) GetTwoFactorStatus(userID, role string) (domain.TwoFactorStatus, error) {
+	_, enabled, err := s.Repo.GetTOTPState(userID)
+	if err != nil {
+		return domain.TwoFactorStatus{}, err
+	}
+	required, err := s.Repo.IsTwoFactorRequired(role)
+	if err != nil {
+		return domain.TwoFactorStatus{}, err
+	}
+	remaining := 0
+	if enabled {
+		if remaining, err = s.Repo.CountRecoveryCodes(userID); err != nil {
+			return domain.TwoFactorStatus{}, err
+		}
+	}
+	return domain.TwoFactorStatus{Enabled: enabled, Required: required, RecoveryCodesRemaining: remaining}, nil
+}
+
+// SetupTwoFactor gera um novo segredo pendente (substitui um cadastro não concluído)
+func (s *Service) SetupTwoFactor(userID string) (TOTPSetup, error) {
+	u, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return TOTPSetup{}, errors.New("usuário não encontrado")
+	}
+	return s.newTOTPSetup(u)
+}
+
+// EnableTwoFactor confirma o cadastro com o primeiro código e retorna os códigos de recuperação
+func (s *Service) EnableTwoFactor(userID, code string) ([]string, error) {
+	secret, enabled, err := s.Repo.GetTOTPState(userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, errors.New("2FA já está ativo")
+	}
+	if secret == "" {
+		return nil, errors.New("configure o autenticador antes de continuar")
+	}
+	return s.activateTOTP(userID, secret, code)
+}
+
+// DisableTwoFactor desativa o 2FA mediante senha e código; não é permitido se o papel exige 2FA
+func (s *Service) DisableTwoFactor(userID, role, password, code string) error {
+	required, err := s.Repo.IsTwoFactorRequired(role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("2FA é obrigatório para este perfil")
+	}
+	u, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return errors.New("usuário não encontrado")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+		return errors.New("senha incorreta")
+	}
+	if err := s.verifySecondFactor(userID, code); err != nil {
+		return err
+	}
+	return s.Repo.DisableTOTP(userID)
+}
+
+// RegenerateRecoveryCodes invalida os códigos anteriores e gera novos (exige código TOTP)
+func (s *Service) RegenerateRecoveryCodes(userID, code string) ([]string, error) {
+	secret, enabled, err := s.Repo.GetTOTPState(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, errors.New("2FA não está ativo")
+	}
+	if _, err := s.checkTOTP(userID, secret, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// --- Políticas (admin) ---
+
+// SetTwoFactorPolicy define se o papel exige 2FA; usuários sem 2FA configuram no próximo login
+func (s *Service) SetTwoFactorPolicy(role string, required bool, adminID string) (domain.TwoFactorPolicy, error) {
+	switch domain.Role(role) {
+	case domain.RoleAdmin, domain.RoleUser, domain.RoleCompany, domain.RoleSpecialist:
+	default:
+		return domain.TwoFactorPolicy{}, errors.New("papel inválido")
+	}
+	p := domain.TwoFactorPolicy{Role: role, Required: required, UpdatedBy: adminID, UpdatedAt: time.Now().UnixMilli()}
+	if err := s.Repo.SetTwoFactorPolicy(p); err != nil {
+		return domain.TwoFactorPolicy{}, err
+	}
+	return p, nil
+}
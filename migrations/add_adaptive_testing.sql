@@ -0,0 +1,37 @@
+-- Migração: Modo de teste adaptativo (TRI)
+-- Data: 2026-10-18
+-- Descrição: Adiciona configuração adaptativa em exams, estimativa de habilidade em results,
+--            parâmetros calibrados por questão e sessões adaptativas.
+
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS adaptive_config JSONB;
+ALTER TABLE results ADD COLUMN IF NOT EXISTS ability JSONB;
+
+CREATE TABLE IF NOT EXISTS question_irt_params (
+    question_id UUID PRIMARY KEY REFERENCES questions(id) ON DELETE CASCADE,
+    model TEXT NOT NULL DEFAULT '1PL',
+    discrimination DOUBLE PRECISION NOT NULL DEFAULT 1,
+    difficulty DOUBLE PRECISION NOT NULL DEFAULT 0,
+    responses INT NOT NULL DEFAULT 0,
+    calibrated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS adaptive_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    link_id UUID REFERENCES public_links(id) ON DELETE SET NULL,
+    candidate_name TEXT,
+    candidate_email TEXT,
+    theta DOUBLE PRECISION NOT NULL DEFAULT 0,
+    se DOUBLE PRECISION NOT NULL DEFAULT 1,
+    responses JSONB NOT NULL DEFAULT '[]',
+    current_question_id UUID REFERENCES questions(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'active',
+    result_id UUID REFERENCES results(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_adaptive_sessions_exam_id ON adaptive_sessions(exam_id);
+CREATE INDEX IF NOT EXISTS idx_adaptive_sessions_user_id ON adaptive_sessions(user_id) WHERE user_id IS NOT NULL;
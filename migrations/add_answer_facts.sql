@@ -0,0 +1,20 @@
+-- Migração: Criar tabela answer_facts para analytics de desempenho
+-- Data: 2026-10-18
+-- Descrição: Uma linha por questão respondida, mantida por trigger em results.
+--            Usada por GET /api/me/analytics para agregar acertos por matéria/tópico.
+--            A tabela, funções e trigger estão em internal/database/schema.sql (seção 13);
+--            execute o schema antes desta migração para criar a estrutura.
+
+-- Backfill: popular answer_facts com os resultados já existentes
+INSERT INTO answer_facts (result_id, question_id, user_id, exam_id, subject_id, topic_id,
+                          selected_index, is_correct, time_seconds, answered_at)
+SELECT r.id, q.id, r.user_id, r.exam_id, q.subject_id, q.topic_id,
+       CASE WHEN jsonb_typeof(a->'selectedIndex') = 'number' THEN (a->>'selectedIndex')::numeric::int END,
+       jsonb_typeof(a->'selectedIndex') = 'number' AND (a->>'selectedIndex')::numeric::int = q.correct_index,
+       r.time_spent_seconds::DOUBLE PRECISION / GREATEST(r.total_questions, 1),
+       r.date
+FROM results r
+CROSS JOIN LATERAL jsonb_array_elements(answers_as_array(r.answers)) a
+JOIN questions q ON q.id::text = a->>'questionId'
+JOIN exam_questions eq ON eq.exam_id = r.exam_id AND eq.question_id = q.id
+ON CONFLICT (result_id, question_id) DO NOTHING;
@@ -0,0 +1,12 @@
+-- Migração: Limite de tentativas e cooldown por exame e por link
+-- Data: 2026-10-18
+-- Descrição: Adiciona attempt_policy em exams e public_links e registra o link
+--            usado em cada resultado (results.link_id) para contar tentativas por candidato.
+
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS attempt_policy JSONB;
+ALTER TABLE public_links ADD COLUMN IF NOT EXISTS attempt_policy JSONB;
+ALTER TABLE results ADD COLUMN IF NOT EXISTS link_id UUID REFERENCES public_links(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_results_link_id ON results(link_id) WHERE link_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_results_exam_candidate ON results(exam_id, lower(candidate_email))
+    WHERE candidate_email IS NOT NULL;
@@ -0,0 +1,26 @@
+-- Migração: Trilha de auditoria persistente
+-- Data: 2026-10-18
+-- Descrição: Grava os eventos de segurança (ator, ação, alvo, IP, user agent e alterações)
+--            para consulta pelos admins em /api/admin/audit.
+
+-- Eventos de segurança (logins, alterações de acesso, exclusões) consultados em /api/admin/audit.
+-- actor_id e target_id não referenciam as tabelas de origem: o registro sobrevive à exclusão do usuário ou recurso.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    action TEXT NOT NULL, -- Ex.: USER_DELETED, ORG_MEMBER_UPDATED, LOGIN_FAILED
+    actor_id TEXT, -- Usuário que executou (NULL: anônimo)
+    target_type TEXT, -- user, exam, org, group, class...
+    target_id TEXT,
+    ip TEXT,
+    user_agent TEXT,
+    details TEXT,
+    changes JSONB, -- {"campo": {"before": ..., "after": ...}} com valores sensíveis mascarados
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created ON audit_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, id);
+
+COMMENT ON TABLE audit_events IS 'Trilha de auditoria de segurança (retenção: AUDIT_RETENTION_DAYS)';
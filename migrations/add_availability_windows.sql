@@ -0,0 +1,20 @@
+-- Migração: Janelas de disponibilidade para exames e links
+-- Data: 2026-10-18
+-- Descrição: Adiciona availability (JSONB) a exams e public_links e registra os candidatos
+--            convidados por email, que recebem lembretes antes da abertura da janela.
+
+-- Janela de realização: {"availableFrom", "availableUntil", "timezone", "reminderMinutes"} (ms desde a época)
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS availability JSONB;
+ALTER TABLE public_links ADD COLUMN IF NOT EXISTS availability JSONB;
+
+-- Candidatos convidados por email para um link (destinatários dos lembretes)
+CREATE TABLE IF NOT EXISTS link_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    link_id UUID NOT NULL REFERENCES public_links(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    invited_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    reminded_at TIMESTAMP WITH TIME ZONE -- Lembrete enviado (ou dispensado, se convidado após a abertura)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_invitations_link_email ON link_invitations(link_id, lower(email));
+CREATE INDEX IF NOT EXISTS idx_link_invitations_pending ON link_invitations(link_id) WHERE reminded_at IS NULL;
@@ -0,0 +1,17 @@
+-- Migração: Certificados de conclusão verificáveis
+-- Data: 2026-10-18
+-- Descrição: Cria a tabela certificates (um certificado por resultado, com código de verificação único).
+
+CREATE TABLE IF NOT EXISTS certificates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code TEXT UNIQUE NOT NULL,
+    result_id UUID UNIQUE NOT NULL REFERENCES results(id) ON DELETE CASCADE,
+    candidate_name TEXT NOT NULL,
+    exam_title TEXT NOT NULL,
+    score INT NOT NULL,
+    total_questions INT NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    issuer_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    issuer_name TEXT NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
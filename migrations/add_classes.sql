@@ -0,0 +1,42 @@
+-- Migração: Turmas e atividades
+-- Data: 2026-10-18
+-- Descrição: Cria turmas (professor = criador), matrículas de alunos e atividades (exame atribuído
+--            à turma com abertura, encerramento e política de tentativas). Resultados passam a
+--            registrar a atividade à qual foram entregues.
+
+CREATE TABLE IF NOT EXISTS classes (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    teacher_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invite_code TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS class_members (
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (class_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS class_assignments (
+    id UUID PRIMARY KEY,
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    title TEXT,
+    opens_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    closes_at TIMESTAMP WITH TIME ZONE,
+    attempt_policy JSONB,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (closes_at IS NULL OR closes_at > opens_at)
+);
+
+ALTER TABLE results ADD COLUMN IF NOT EXISTS assignment_id UUID REFERENCES class_assignments(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_classes_teacher ON classes(teacher_id);
+CREATE INDEX IF NOT EXISTS idx_class_members_user ON class_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_class_assignments_class ON class_assignments(class_id);
+CREATE INDEX IF NOT EXISTS idx_class_assignments_exam ON class_assignments(exam_id);
+CREATE INDEX IF NOT EXISTS idx_results_assignment ON results(assignment_id) WHERE assignment_id IS NOT NULL;
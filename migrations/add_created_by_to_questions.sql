@@ -0,0 +1,20 @@
+-- Migração: Adicionar campo created_by na tabela questions
+-- Data: 2026-10-18
+-- Descrição: Autoria das questões, usada pelo gerador de simulados de prática
+--            (POST /api/me/practice) para sortear apenas questões públicas ou do usuário.
+
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_questions_created_by ON questions(created_by);
+
+-- Backfill: atribuir a autoria ao criador do primeiro exame que usa a questão
+UPDATE questions q SET created_by = sub.created_by
+FROM (
+    SELECT DISTINCT ON (eq.question_id) eq.question_id, e.created_by
+    FROM exam_questions eq
+    JOIN exams e ON e.id = eq.exam_id
+    WHERE e.created_by IS NOT NULL
+    ORDER BY eq.question_id, e.created_at
+) sub
+WHERE q.id = sub.question_id AND q.created_by IS NULL;
+
+COMMENT ON COLUMN questions.created_by IS 'Usuário que criou a questão (NULL para questões legadas)';
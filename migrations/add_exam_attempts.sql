@@ -0,0 +1,19 @@
+-- Migração: Tentativas emitidas pelo backend
+-- Data: 2026-10-18
+-- Descrição: Cria exam_attempts. O attemptId passa a ser emitido ao iniciar a prova e é exigido
+--            nos eventos de proctoring e na submissão, que só aceitam tentativas do próprio candidato.
+
+CREATE TABLE IF NOT EXISTS exam_attempts (
+    id UUID PRIMARY KEY,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- NULL para candidatos via link público
+    link_id UUID REFERENCES public_links(id) ON DELETE CASCADE, -- NULL para usuários autenticados
+    result_id UUID REFERENCES results(id) ON DELETE SET NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    submitted_at TIMESTAMP WITH TIME ZONE -- Preenchido uma única vez, na submissão
+);
+
+CREATE INDEX IF NOT EXISTS idx_exam_attempts_exam_id ON exam_attempts(exam_id);
+CREATE INDEX IF NOT EXISTS idx_exam_attempts_pending ON exam_attempts(started_at) WHERE submitted_at IS NULL;
+
+COMMENT ON TABLE exam_attempts IS 'Tentativas iniciadas: attemptId emitido pelo backend para proctoring e submissão';
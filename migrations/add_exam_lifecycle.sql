@@ -0,0 +1,13 @@
+-- Migração: Ciclo de vida do exame (rascunho, publicado, arquivado) e exclusão lógica
+-- Data: 2026-10-18
+-- Descrição: Adiciona exams.status e exams.deleted_at. Exames existentes ficam publicados.
+--            DELETE /api/exams/{id} passa a marcar is_active = FALSE; a purga definitiva
+--            é feita pelo CleanupService após EXAM_RETENTION_DAYS (padrão: 30).
+
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published', 'archived'));
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+UPDATE exams SET is_active = TRUE WHERE is_active IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_exams_status_active ON exams(status, is_active);
+CREATE INDEX IF NOT EXISTS idx_exams_deleted_at ON exams(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,35 @@
+-- Migração: Compartilhamento e coautoria de exames
+-- Data: 2026-10-18
+-- Descrição: Cria grupos de usuários e concessões de acesso por exame (viewer, editor, owner)
+--            para usuários individuais ou grupos. O autor (exams.created_by) continua dono.
+
+CREATE TABLE IF NOT EXISTS user_groups (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_group_members (
+    group_id UUID NOT NULL REFERENCES user_groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS exam_shares (
+    id UUID PRIMARY KEY,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    group_id UUID REFERENCES user_groups(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((user_id IS NULL) <> (group_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exam_shares_exam_user ON exam_shares(exam_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exam_shares_exam_group ON exam_shares(exam_id, group_id) WHERE group_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_exam_shares_user ON exam_shares(user_id);
+CREATE INDEX IF NOT EXISTS idx_exam_shares_group ON exam_shares(group_id);
+CREATE INDEX IF NOT EXISTS idx_user_group_members_user ON user_group_members(user_id);
@@ -0,0 +1,8 @@
+-- Migração: Clonagem de exames e catálogo de modelos
+-- Data: 2026-10-18
+-- Descrição: Adiciona exams.is_template (modelos curados por admins) e exams.cloned_from (origem da cópia).
+
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS is_template BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS cloned_from UUID REFERENCES exams(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_exams_is_template ON exams(is_template) WHERE is_template = TRUE;
@@ -0,0 +1,20 @@
+-- Migração: Assinatura assimétrica dos access tokens
+-- Data: 2026-10-18
+-- Descrição: Adiciona as chaves de assinatura JWT (RS256/EdDSA) com rotação agendada.
+--            Substitui o HS256 com JWT_SECRET: access tokens antigos deixam de valer e os
+--            clientes obtêm um novo em /api/auth/refresh.
+
+-- Chaves de assinatura dos access tokens (RS256/EdDSA), compartilhadas entre réplicas.
+-- A chave mais recente já ativa assina; as demais continuam publicadas em /.well-known/jwks.json
+-- até que os tokens assinados por elas expirem.
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    kid TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL, -- 'RS256' | 'EdDSA'
+    private_key TEXT NOT NULL, -- PEM PKCS#8 cifrado com AES-256-GCM (JWT_KEY_ENCRYPTION_KEY): "v1:" + base64(nonce || ciphertext)
+    activates_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_activates ON jwt_signing_keys(activates_at);
+
+COMMENT ON TABLE jwt_signing_keys IS 'Chaves de assinatura JWT com rotação agendada';
@@ -0,0 +1,14 @@
+-- Migração: Bloqueio de contas por tentativas de login
+-- Data: 2026-10-18
+-- Descrição: Adiciona o controle de falhas de login por conta, com atraso progressivo entre
+--            tentativas e bloqueio temporário após LOGIN_MAX_FAILURES falhas seguidas.
+
+-- Falhas consecutivas de login por conta (atraso progressivo e bloqueio temporário)
+CREATE TABLE IF NOT EXISTS login_failures (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    failed_count INTEGER NOT NULL DEFAULT 0, -- Falhas desde o último login ou bloqueio
+    last_failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP WITH TIME ZONE -- Bloqueio temporário (NULL: não bloqueada)
+);
+
+COMMENT ON TABLE login_failures IS 'Tentativas de login com senha incorreta por conta';
@@ -0,0 +1,63 @@
+-- Migração: Organizações (contas empresariais multiusuário)
+-- Data: 2026-10-18
+-- Descrição: Cria organizações, membros (owner, recruiter, viewer) e convites. Cada usuário
+--            com role 'company' vira uma organização de um membro (owner), com o mesmo id
+--            do usuário. Links e exames das empresas são atribuídos a essa organização.
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    commercial_name TEXT NOT NULL,
+    logo TEXT,
+    tax_id TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'recruiter', 'viewer')),
+    joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS organization_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'recruiter', 'viewer')),
+    token TEXT UNIQUE NOT NULL,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE public_links ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user ON organization_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_org ON organization_invitations(org_id) WHERE accepted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_public_links_org_id ON public_links(org_id);
+CREATE INDEX IF NOT EXISTS idx_exams_org_id ON exams(org_id) WHERE org_id IS NOT NULL;
+
+-- Empresas existentes -> organizações de um membro
+INSERT INTO organizations (id, commercial_name, logo, tax_id, created_by, created_at)
+SELECT u.id, COALESCE(NULLIF(u.profile->>'commercialName', ''), u.name), NULLIF(u.profile->>'companyLogo', ''),
+       NULLIF(COALESCE(u.profile->>'taxId', u.profile->>'cnpj'), ''), u.id, u.created_at
+FROM users u
+WHERE u.role = 'company' AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = u.id)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO organization_members (org_id, user_id, role)
+SELECT u.id, u.id, 'owner'
+FROM users u JOIN organizations o ON o.id = u.id
+WHERE u.role = 'company' AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = u.id);
+
+UPDATE public_links SET org_id = company_id
+WHERE org_id IS NULL AND company_id IN (SELECT id FROM organizations);
+
+-- Exames criados pelas empresas passam a ser da organização (executar uma única vez)
+UPDATE exams SET org_id = created_by
+WHERE org_id IS NULL AND created_by IN (SELECT o.id FROM organizations o JOIN users u ON u.id = o.id WHERE u.role = 'company');
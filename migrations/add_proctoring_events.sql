@@ -0,0 +1,28 @@
+-- Migração: Log de eventos de integridade (proctoring)
+-- Data: 2026-10-18
+-- Descrição: Cria proctoring_events, registra IP/user agent e tentativa em results
+--            e adiciona o limite de sinalização por link público.
+
+ALTER TABLE results ADD COLUMN IF NOT EXISTS attempt_id UUID;
+ALTER TABLE results ADD COLUMN IF NOT EXISTS submit_ip TEXT;
+ALTER TABLE results ADD COLUMN IF NOT EXISTS user_agent TEXT;
+ALTER TABLE public_links ADD COLUMN IF NOT EXISTS flag_threshold INT CHECK (flag_threshold > 0);
+
+CREATE TABLE IF NOT EXISTS proctoring_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    attempt_id UUID NOT NULL,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    link_id UUID REFERENCES public_links(id) ON DELETE SET NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    result_id UUID REFERENCES results(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('tab_hidden', 'window_blur', 'copy', 'paste', 'fullscreen_exit', 'ip_change')),
+    details JSONB,
+    ip TEXT,
+    user_agent TEXT,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_proctoring_events_attempt ON proctoring_events(attempt_id, received_at);
+CREATE INDEX IF NOT EXISTS idx_proctoring_events_result ON proctoring_events(result_id) WHERE result_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_results_attempt_id ON results(attempt_id) WHERE attempt_id IS NOT NULL;
@@ -0,0 +1,18 @@
+-- Migração: Rate limit compartilhado (token bucket)
+-- Data: 2026-10-18
+-- Descrição: Guarda no banco os baldes de tokens do rate limit, para que os limites por IP
+--            e usuário valham entre todas as réplicas da API.
+
+-- Baldes de tokens do rate limit, compartilhados entre réplicas.
+-- key é "<regra>:ip:<ip>" ou "<regra>:user:<id>".
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL, -- Saldo após a última requisição
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    full_at TIMESTAMP WITH TIME ZONE NOT NULL, -- Quando o balde volta a ficar cheio (pode ser removido)
+    allowed BOOLEAN NOT NULL DEFAULT TRUE -- Resultado da última requisição
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full ON rate_limit_buckets(full_at);
+
+COMMENT ON TABLE rate_limit_buckets IS 'Rate limit por IP e usuário (token bucket)';
@@ -0,0 +1,13 @@
+-- Migração: Criar caderno de erros (review_cards)
+-- Data: 2026-10-18
+-- Descrição: Questões erradas viram cartões de revisão espaçada (SM-2).
+--            A tabela e o trigger estão em internal/database/schema.sql (seção 14);
+--            execute o schema antes desta migração para criar a estrutura.
+
+-- Backfill: uma entrada por questão errada nos resultados já existentes
+INSERT INTO review_cards (user_id, question_id, lapses, due_at)
+SELECT af.user_id, af.question_id, COUNT(*), NOW()
+FROM answer_facts af
+WHERE af.user_id IS NOT NULL AND NOT af.is_correct
+GROUP BY af.user_id, af.question_id
+ON CONFLICT (user_id, question_id) DO NOTHING;
@@ -0,0 +1,15 @@
+-- Migração: Blacklist persistente de access tokens
+-- Data: 2026-10-18
+-- Descrição: Grava os access tokens revogados no banco, para que continuem bloqueados após
+--            reinícios e em todas as réplicas da API até o exp de cada token.
+
+-- Access tokens revogados (logout, sessões encerradas), compartilhados entre réplicas.
+-- token_id é o SHA-256 do token ou "session:<id>" para todos os tokens de uma sessão.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    token_id TEXT PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL -- exp do token + tolerância de clock skew
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON revoked_tokens(expires_at);
+
+COMMENT ON TABLE revoked_tokens IS 'Blacklist persistente de access tokens';
@@ -0,0 +1,15 @@
+-- Migração: Verificação de domínios do SSO
+-- Data: 2026-10-18
+-- Descrição: Domínios de email do SSO SAML passam a exigir verificação por registro DNS TXT
+--            ou aprovação de um admin. Domínios já cadastrados ficam pendentes, com um token novo.
+
+ALTER TABLE org_saml_domains ADD COLUMN IF NOT EXISTS verification_token TEXT;
+ALTER TABLE org_saml_domains ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE org_saml_domains ADD COLUMN IF NOT EXISTS verified_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE org_saml_domains SET verification_token = md5(random()::text || domain || clock_timestamp()::text)
+WHERE verification_token IS NULL;
+
+COMMENT ON COLUMN org_saml_domains.verification_token IS 'Valor do registro TXT _esimulate-verification.<domínio>';
+COMMENT ON COLUMN org_saml_domains.verified_at IS 'Verificação do domínio (NULL: pendente, ignorado no login)';
+COMMENT ON COLUMN org_saml_domains.verified_by IS 'Admin que aprovou o domínio (NULL: verificado por DNS)';
@@ -0,0 +1,39 @@
+-- Migração: SSO SAML 2.0 para organizações
+-- Data: 2026-10-18
+-- Descrição: Adiciona a configuração SAML por organização (metadata do IdP, mapeamento de
+--            atributos e papéis), os domínios de email atendidos pelo SSO e os AuthnRequests
+--            pendentes. O login concluído usa a troca de código do login social (tokens 'oauth_login').
+
+-- Configuração do Service Provider por organização (IdP corporativo: Azure AD, Okta, ...)
+CREATE TABLE IF NOT EXISTS org_saml_configs (
+    org_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    idp_metadata TEXT NOT NULL, -- XML de metadata do IdP (entityID, SSO e certificado de assinatura)
+    idp_entity_id TEXT NOT NULL,
+    attribute_mapping JSONB, -- {"email": "...", "name": "...", "role": "..."}
+    role_mapping JSONB, -- Valor do atributo de papel -> papel na organização
+    default_role TEXT NOT NULL DEFAULT 'recruiter',
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Domínios de email atendidos pelo SSO (um domínio pertence a uma única organização)
+CREATE TABLE IF NOT EXISTS org_saml_domains (
+    domain TEXT PRIMARY KEY,
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+-- AuthnRequests emitidos (InResponseTo da resposta); o id viaja no RelayState
+CREATE TABLE IF NOT EXISTS saml_requests (
+    relay_state TEXT PRIMARY KEY,
+    request_id TEXT NOT NULL,
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_org_saml_domains_org ON org_saml_domains(org_id);
+CREATE INDEX IF NOT EXISTS idx_saml_requests_expires ON saml_requests(expires_at);
+
+COMMENT ON TABLE org_saml_configs IS 'SSO SAML 2.0 das organizações';
+COMMENT ON TABLE org_saml_domains IS 'Domínios de email que usam o SSO da organização';
+COMMENT ON TABLE saml_requests IS 'AuthnRequests pendentes (uso único)';
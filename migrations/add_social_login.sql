@@ -0,0 +1,46 @@
+-- Migração: Login social (OIDC / OAuth2)
+-- Data: 2026-10-18
+-- Descrição: Adiciona identidades de provedores sociais (Google, GitHub, Microsoft), o estado
+--            dos fluxos authorization code + PKCE e os vínculos pendentes de confirmação.
+--            Códigos de troca do login usam a tabela tokens com type = 'oauth_login'.
+
+-- Identidades externas vinculadas a usuários (sub do provedor)
+CREATE TABLE IF NOT EXISTS oauth_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL, -- 'google' | 'github' | 'microsoft'
+    subject TEXT NOT NULL,
+    email TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+-- Fluxos de autorização em andamento (state, nonce e code_verifier do PKCE)
+CREATE TABLE IF NOT EXISTS oauth_states (
+    state TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Vínculos pendentes: identidade cujo email já pertence a uma conta (aguarda confirmação do usuário)
+CREATE TABLE IF NOT EXISTS oauth_link_requests (
+    token TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_identities_user ON oauth_identities(user_id);
+CREATE INDEX IF NOT EXISTS idx_oauth_states_expires ON oauth_states(expires_at);
+
+COMMENT ON TABLE oauth_identities IS 'Contas de provedores sociais (OIDC/OAuth2) vinculadas a usuários';
+COMMENT ON TABLE oauth_states IS 'State/nonce/PKCE de logins sociais em andamento (uso único)';
+COMMENT ON TABLE oauth_link_requests IS 'Vínculos de login social aguardando confirmação da conta existente';
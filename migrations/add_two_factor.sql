@@ -0,0 +1,29 @@
+-- Migração: Autenticação em dois fatores (TOTP)
+-- Data: 2026-10-18
+-- Descrição: Adiciona o segredo TOTP aos usuários, códigos de recuperação de uso único e a
+--            política de obrigatoriedade do 2FA por papel. Desafios de login usam a tabela
+--            tokens com type = '2fa_challenge'.
+
+-- Segredo TOTP (base32). Fica pendente (totp_enabled = FALSE) até o primeiro código válido
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT; -- Último passo aceito (impede reutilizar o código)
+
+-- Códigos de recuperação de uso único (hash SHA-256)
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Papéis que exigem 2FA no login
+CREATE TABLE IF NOT EXISTS two_factor_policies (
+    role TEXT PRIMARY KEY,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_id) WHERE used_at IS NULL;
@@ -0,0 +1,8 @@
+-- Migração: Falhas no desafio de 2FA
+-- Data: 2026-10-18
+-- Descrição: Conta os códigos incorretos por desafio de login. O desafio é invalidado ao atingir o
+--            limite, e cada código incorreto também conta para o bloqueio da conta.
+
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS failed_attempts INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN tokens.failed_attempts IS 'Códigos incorretos no desafio de 2FA (2fa_challenge)';
@@ -0,0 +1,23 @@
+-- Migração: Gerenciamento de sessões ativas
+-- Data: 2026-10-18
+-- Descrição: Adiciona as sessões de login (IP, user agent, criação e último uso) e associa
+--            os refresh tokens à sessão. Refresh tokens emitidos antes da migração não têm
+--            sessão e deixam de valer em até 7 dias (ou em "sair de todos os dispositivos").
+
+-- Cada login abre uma sessão; a rotação do refresh token mantém a mesma sessão
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip TEXT, -- IP do login
+    user_agent TEXT, -- Navegador/dispositivo do login
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW() -- Último refresh
+);
+
+-- Revogar a sessão exclui os refresh tokens dela
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS session_id UUID REFERENCES user_sessions(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_tokens_session ON tokens(session_id);
+
+COMMENT ON TABLE user_sessions IS 'Sessões de login (dispositivos) dos usuários';